@@ -0,0 +1,187 @@
+// polka-sim : 로컬에서 실행 중인 chirpy 서버의 /api/polka/webhooks로 polka webhook 시나리오를 보내고
+// 각 요청의 응답 코드가 기대한 값인지 보여준다
+//
+// -user를 주지 않으면 테스트용 유저를 새로 만들어 사용하고, 시나리오마다 그 유저의 구독 상태도 보여준다
+// 키는 -key 또는 .env의 POLKA_KEY
+//
+// ex: go run ./cmd/polka-sim -url http://localhost:8080 -scenario lifecycle,duplicates
+// ex: go run ./cmd/polka-sim -list
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/paokimsiwoong/chirpy/internal/polkasim"
+)
+
+type user struct {
+	ID    uuid.UUID `json:"id"`
+	Token string    `json:"token"`
+}
+
+func main() {
+	godotenv.Load()
+
+	baseURL := flag.String("url", "http://localhost:8080", "chirpy server base url")
+	key := flag.String("key", os.Getenv("POLKA_KEY"), "polka api key (defaults to POLKA_KEY)")
+	userFlag := flag.String("user", "", "id of the user to upgrade (creates a new user if empty)")
+	scenarioFlag := flag.String("scenario", "all", "comma separated scenario names, or all")
+	list := flag.Bool("list", false, "list scenarios and exit")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	// -list에서는 유저가 필요 없으므로 아무 id로 만든다
+	if *list {
+		for _, s := range polkasim.Scenarios(uuid.Nil, time.Now()) {
+			fmt.Printf("%-16s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+
+	if *key == "" {
+		log.Fatal("POLKA_KEY must be set (or pass -key)")
+	}
+
+	u := user{}
+	if *userFlag != "" {
+		id, err := uuid.Parse(*userFlag)
+		if err != nil {
+			log.Fatalf("Error parsing -user: %v", err)
+		}
+		u.ID = id
+	} else {
+		var err error
+		u, err = createUser(ctx, *baseURL, fmt.Sprintf("polka-sim-%d@example.com", time.Now().UnixNano()))
+		if err != nil {
+			log.Fatalf("Error creating user: %v", err)
+		}
+		log.Printf("Created user %s", u.ID)
+	}
+
+	scenarios := polkasim.Scenarios(u.ID, time.Now())
+	if *scenarioFlag != "all" {
+		var selected []polkasim.Scenario
+		for _, name := range strings.Split(*scenarioFlag, ",") {
+			s, ok := polkasim.Find(scenarios, strings.TrimSpace(name))
+			if !ok {
+				log.Fatalf("Unknown scenario %q (see -list)", name)
+			}
+			selected = append(selected, s)
+		}
+		scenarios = selected
+	}
+
+	client := polkasim.NewClient(*baseURL+"/api/polka/webhooks", *key)
+	failed := 0
+	for _, s := range scenarios {
+		fmt.Printf("== %s: %s\n", s.Name, s.Description)
+
+		results, err := client.Run(ctx, s)
+		for _, r := range results {
+			mark := "ok  "
+			if !r.OK() {
+				mark = "FAIL"
+				failed++
+			}
+			fmt.Printf("  %s %-26s %-20s %s -> %d (want %d)", mark, r.Step.Name, r.Step.Event.Event, r.Step.Event.ID, r.Status, r.Step.WantStatus)
+			if r.Body != "" {
+				fmt.Printf(" %s", r.Body)
+			}
+			fmt.Println()
+		}
+		if err != nil {
+			log.Fatalf("Error running scenario: %v", err)
+		}
+
+		if u.Token != "" {
+			fmt.Printf("  subscription: %s\n", subscription(ctx, *baseURL, u.Token))
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d unexpected responses\n", failed)
+		os.Exit(1)
+	}
+}
+
+// 유저의 구독 상태를 한줄로 반환하는 함수
+func subscription(ctx context.Context, baseURL, token string) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/users/me/subscription", nil)
+	if err != nil {
+		return err.Error()
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "none"
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err.Error()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Status
+	}
+	return string(bytes.TrimSpace(body))
+}
+
+// 유저를 만들고 로그인해서 JWT를 받는 함수
+func createUser(ctx context.Context, baseURL, email string) (user, error) {
+	const password = "polka-sim-password"
+	creds := map[string]string{"email": email, "password": password}
+
+	if err := post(ctx, baseURL+"/api/users", creds, nil); err != nil {
+		return user{}, err
+	}
+	u := user{}
+	if err := post(ctx, baseURL+"/api/login", creds, &u); err != nil {
+		return user{}, err
+	}
+	return u, nil
+}
+
+func post(ctx context.Context, url string, body, out any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
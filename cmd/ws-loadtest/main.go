@@ -0,0 +1,247 @@
+// ws-loadtest : 로컬에서 실행 중인 chirpy 서버의 /api/ws에 websocket 연결을 많이 열어두고
+// 한 유저가 chirp를 올릴 때 모든 연결의 timeline 채널에 도착하기까지 걸린 시간을 잰다
+//
+// 테스트용 유저들을 새로 만들고 (작성자 1명 + 연결마다 1명) 모두 작성자를 팔로우한 뒤 시작한다
+// 새로 만든 유저들은 지워지지 않으므로 PLATFORM=dev 서버에서 /admin/reset으로 정리
+//
+// ex: go run ./cmd/ws-loadtest -url http://localhost:8080 -clients 200 -chirps 50 -rate 10
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+type user struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// 서버 메시지 중 필요한 필드만
+type serverMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Chirp   *struct {
+		Body string `json:"body"`
+	} `json:"chirp"`
+	Error string `json:"error"`
+}
+
+type result struct {
+	mu sync.Mutex
+	// 연결 + 구독까지 걸린 시간
+	connect []time.Duration
+	// chirp를 올린 시간부터 받은 시간까지
+	delivery []time.Duration
+	errors   atomic.Int64
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080", "chirpy server base url")
+	clients := flag.Int("clients", 100, "number of websocket connections")
+	chirps := flag.Int("chirps", 20, "number of chirps to post")
+	rate := flag.Float64("rate", 5, "chirps posted per second")
+	timeout := flag.Duration("timeout", 30*time.Second, "how long to wait for deliveries after the last chirp")
+	flag.Parse()
+
+	ctx := context.Background()
+	run := time.Now().UnixNano()
+
+	author, err := createUser(ctx, *baseURL, fmt.Sprintf("ws-loadtest-%d-author@example.com", run))
+	if err != nil {
+		log.Fatalf("Error creating author: %v", err)
+	}
+
+	res := &result{}
+	// chirp body -> 올린 시간
+	var sentAt sync.Map
+	var delivered atomic.Int64
+
+	log.Printf("Connecting %d clients...", *clients)
+	var ready sync.WaitGroup
+	var done sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < *clients; i++ {
+		u, err := createUser(ctx, *baseURL, fmt.Sprintf("ws-loadtest-%d-%d@example.com", run, i))
+		if err != nil {
+			log.Fatalf("Error creating user: %v", err)
+		}
+		if err := post(ctx, *baseURL+"/api/users/"+author.ID+"/follow", u.Token, nil, nil); err != nil {
+			log.Fatalf("Error following author: %v", err)
+		}
+
+		ready.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			runClient(ctx, *baseURL, u, res, &ready, stop, func(body string) {
+				if v, ok := sentAt.Load(body); ok {
+					res.mu.Lock()
+					res.delivery = append(res.delivery, time.Since(v.(time.Time)))
+					res.mu.Unlock()
+					delivered.Add(1)
+				}
+			})
+		}()
+	}
+	ready.Wait()
+	log.Printf("%d clients subscribed (%d errors)", *clients-int(res.errors.Load()), res.errors.Load())
+
+	log.Printf("Posting %d chirps at %.1f/s...", *chirps, *rate)
+	interval := time.Duration(float64(time.Second) / *rate)
+	for i := 0; i < *chirps; i++ {
+		body := fmt.Sprintf("ws loadtest %d chirp %d", run, i)
+		sentAt.Store(body, time.Now())
+		if err := post(ctx, *baseURL+"/api/chirps", author.Token, map[string]string{"body": body}, nil); err != nil {
+			log.Printf("Error posting chirp: %v", err)
+			res.errors.Add(1)
+		}
+		time.Sleep(interval)
+	}
+
+	expected := int64(*chirps) * (int64(*clients) - res.errors.Load())
+	deadline := time.Now().Add(*timeout)
+	for delivered.Load() < expected && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	close(stop)
+	done.Wait()
+
+	fmt.Printf("clients:    %d\n", *clients)
+	fmt.Printf("chirps:     %d\n", *chirps)
+	fmt.Printf("delivered:  %d / %d\n", delivered.Load(), expected)
+	fmt.Printf("errors:     %d\n", res.errors.Load())
+	printPercentiles("connect", res.connect)
+	printPercentiles("delivery", res.delivery)
+}
+
+// 연결해서 timeline을 구독하고 stop이 닫힐 때까지 받은 chirp를 onChirp로 넘기는 함수
+func runClient(ctx context.Context, baseURL string, u user, res *result, ready *sync.WaitGroup, stop <-chan struct{}, onChirp func(body string)) {
+	readyOnce := sync.OnceFunc(ready.Done)
+	defer readyOnce()
+
+	start := time.Now()
+	wsURL := "ws" + strings.TrimPrefix(baseURL, "http") + "/api/ws"
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		log.Printf("Error connecting: %v", err)
+		res.errors.Add(1)
+		return
+	}
+	defer conn.CloseNow()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	if err := wsjson.Write(ctx, conn, map[string]string{"type": "auth", "token": u.Token}); err != nil {
+		log.Printf("Error authenticating: %v", err)
+		res.errors.Add(1)
+		return
+	}
+	if err := wsjson.Write(ctx, conn, map[string]string{"type": "subscribe", "channel": "timeline"}); err != nil {
+		log.Printf("Error subscribing: %v", err)
+		res.errors.Add(1)
+		return
+	}
+
+	for {
+		msg := serverMessage{}
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error reading: %v", err)
+				res.errors.Add(1)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case "subscribed":
+			res.mu.Lock()
+			res.connect = append(res.connect, time.Since(start))
+			res.mu.Unlock()
+			readyOnce()
+		case "chirp_created":
+			if msg.Chirp != nil {
+				onChirp(msg.Chirp.Body)
+			}
+		case "error":
+			log.Printf("Error from server: %s", msg.Error)
+		}
+	}
+}
+
+// 유저를 만들고 로그인해서 JWT를 받는 함수
+func createUser(ctx context.Context, baseURL, email string) (user, error) {
+	const password = "ws-loadtest-password"
+	creds := map[string]string{"email": email, "password": password}
+
+	if err := post(ctx, baseURL+"/api/users", "", creds, nil); err != nil {
+		return user{}, err
+	}
+	u := user{}
+	if err := post(ctx, baseURL+"/api/login", "", creds, &u); err != nil {
+		return user{}, err
+	}
+	return u, nil
+}
+
+func post(ctx context.Context, url, token string, body, out any) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func printPercentiles(name string, d []time.Duration) {
+	if len(d) == 0 {
+		fmt.Printf("%-10s  no samples\n", name+":")
+		return
+	}
+	slices.Sort(d)
+	p := func(q float64) time.Duration {
+		return d[int(q*float64(len(d)-1))]
+	}
+	fmt.Printf("%-10s  p50 %v  p95 %v  p99 %v  max %v\n", name+":", p(0.50), p(0.95), p(0.99), d[len(d)-1])
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"html"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
 )
 
 // /api/healthz path handler
@@ -22,16 +27,48 @@ func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	content := "OK"
-	code, err := w.Write([]byte(content))
 	// @@@ 해답: w.Write([]byte(http.StatusText(http.StatusOK)))
-	// @@@ Write 함수 반환값 무시
 	// @@@ func http.StatusText(code int) string : StatusText returns a text for the HTTP status code. It returns the empty string if the code is unknown
-	fmt.Printf("status code: %d\nerror message: %v\n", code, err)
+	// 요청 자체는 middleware가 기록하므로 쓰기에 실패한 경우만 기록
+	if _, err := w.Write([]byte(content)); err != nil {
+		reqlog.Logger(r.Context()).Error("Error writing readiness response", "error", err)
+	}
 }
 
-// /admin/metrics path handler : cfg에 저장된 fileserverHits 값을 표시
-// apiConfig의 fileserverHits에 접근해야 하므로 apiConfig의 method으로 정의
+// /admin/metrics path handler : /metrics와 같은 registry의 값들을 html로 표시
+// /app/ 방문 수는 route별 요청 수에서 읽는다
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
+	hits, err := cfg.metrics.RouteRequests("/app/")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error gathering metrics", fmt.Errorf("error gathering metrics: %w", err))
+		return
+	}
+	families, err := cfg.metrics.Families()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error gathering metrics", fmt.Errorf("error gathering metrics: %w", err))
+		return
+	}
+
+	// label 값에 들어갈 수 있는 문자는 html.EscapeString으로 escape
+	var b strings.Builder
+	fmt.Fprintf(&b, `<html>
+  <body>
+    <h1>Welcome, Chirpy Admin</h1>
+    <p>Chirpy has been visited %d times!</p>
+`, int64(hits))
+	for _, family := range families {
+		// go runtime, process metric은 /metrics에서만 보여준다
+		if !strings.HasPrefix(family.Name, "chirpy_") || len(family.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "    <h2>%s</h2>\n    <p>%s (%s)</p>\n    <table>\n", html.EscapeString(family.Name), html.EscapeString(family.Help), family.Type)
+		for _, sample := range family.Samples {
+			fmt.Fprintf(&b, "      <tr><td>%s{%s}</td><td>%s</td></tr>\n", html.EscapeString(sample.Name), html.EscapeString(sample.Labels), strconv.FormatFloat(sample.Value, 'g', -1, 64))
+		}
+		b.WriteString("    </table>\n")
+	}
+	b.WriteString("  </body>\n</html>")
+
 	// header 설정
 	w.Header().Add("Content-Type", "text/html")
 	// html로 설정해야 html이 담긴 response body를 정상적으로 표시
@@ -39,22 +76,10 @@ func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 	// status code 는 200 ok 지정
 	w.WriteHeader(http.StatusOK)
 
-	// response body 설정
-	formattedHtml := fmt.Sprintf(
-		`<html>
-  <body>
-    <h1>Welcome, Chirpy Admin</h1>
-    <p>Chirpy has been visited %d times!</p>
-  </body>
-</html>`,
-		cfg.fileserverHits.Load(),
-	)
-
-	w.Write([]byte(formattedHtml))
+	w.Write([]byte(b.String()))
 }
 
-// /admin/reset path handler : cfg에 저장된 fileserverHits 값을 초기화
-// apiConfig의 fileserverHits에 접근해야 하므로 apiConfig의 method으로 정의
+// /admin/reset path handler : 요청 수 metric(방문 수 포함)과 db 초기화
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	// 개발자가 아니면 reset 사용 금지
 	if cfg.platform != "dev" {
@@ -65,11 +90,14 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// fileserverHits 값을 0으로 초기화
-	cfg.fileserverHits.Store(0)
+	// 방문 수를 포함한 요청 수 metric 초기화
+	cfg.metrics.ResetRequests()
 
-	cfg.ptrDB.ResetUsers(r.Context())
-	// db의 user 테이블 reset
+	// db reset : 유저 데이터와 유저를 지워도 남는 job, stream event, trend 테이블까지 비운다
+	if err := cfg.ptrDB.ResetDatabase(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error resetting database", fmt.Errorf("error resetting database: %w", err))
+		return
+	}
 
 	// header 설정
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
@@ -9,6 +9,7 @@ import (
 
 	"github.com/paokimsiwoong/chirpy/internal/auth"
 	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
 )
 
 // /api/login path POST handler : 로그인 요청 처리
@@ -29,17 +30,20 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	// http.Request의 Context() method는 req의 context.Context를 반환
 	// ==> 만약 접속이 끊기거나 타임아웃이 되면 그 정보가 context로 전달되서 db 쿼리를 알아서 중단시켜준다
 	if err != nil {
+		cfg.metrics.LoginFailures.Inc()
 		respondWithError(w, http.StatusUnauthorized, "Error incorrect email or password", fmt.Errorf("error incorrect email or password: %w", err)) // 4번째 인자 error는 response로 가지 않으므로 이메일 오류인지 비밀번호 오류인지 요청자가 알 수 없다.
 		// code는 401
 		return
 	}
 
 	if err := auth.CheckPasswordHash(user.HashedPassword, reqBody.Password); err != nil {
+		cfg.metrics.LoginFailures.Inc()
 		respondWithError(w, http.StatusUnauthorized, "Error incorrect email or password", fmt.Errorf("error incorrect email or password: %w", err)) // 4번째 인자 error는 response로 가지 않으므로 이메일 오류인지 비밀번호 오류인지 요청자가 알 수 없다.
 		// code는 401
 		return
 	}
 	// err == nil 이면 비밀번호 일치
+	reqlog.SetUserID(r.Context(), user.ID)
 
 	// token 수명 설정값
 	// var expiresIn time.Duration
@@ -81,16 +85,20 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 
 	// json에 저장할 데이터들 구조체에 저장
 	resBody := uResBodySuccess{
-		ID:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        tokenString,
-		RefreshToken: refreshTokenString,
-		IsChirpyRed:  user.IsChirpyRed,
+		ID:                  user.ID,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+		Email:               user.Email,
+		Token:               tokenString,
+		RefreshToken:        refreshTokenString,
+		IsChirpyRed:         user.IsChirpyRed,
+		IsProtected:         user.IsProtected,
+		IsModerator:         user.IsModerator,
+		AutoExpandSensitive: user.AutoExpandSensitive,
 		// @@@ hashed password는 절대 response로 반환하면 안된다 => 보안문제
 	}
 
+	cfg.metrics.Logins.Inc()
 	respondWithJSON(w, http.StatusOK, resBody)
 	// code는 200
 }
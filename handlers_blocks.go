@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
+)
+
+// /api/users/{userID}/block path PUT handler : 유저 차단
+// 차단 관계인 유저와는 대화를 시작하거나 1:1 메시지를 주고받을 수 없다
+func (cfg *apiConfig) handlerBlockPUT(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if blockedID == userID {
+		respondWithError(w, http.StatusBadRequest, "Error can't block yourself", errors.New("error can't block yourself"))
+		// code 400
+		return
+	}
+
+	if _, err := cfg.ptrDB.GetUserByID(r.Context(), blockedID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+
+	if err := cfg.ptrDB.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating block in DB", fmt.Errorf("error creating block in DB: %w", err))
+		return
+	}
+	cfg.notifyBlocksChanged(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/users/{userID}/block path DELETE handler : 유저 차단 해제
+func (cfg *apiConfig) handlerBlockDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if err := cfg.ptrDB.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting block in DB", fmt.Errorf("error deleting block in DB: %w", err))
+		return
+	}
+	cfg.notifyBlocksChanged(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// 접속 중인 유저의 websocket 연결들이 차단 목록을 다시 읽도록 알리는 함수
+// (차단한 유저의 입력 중, 접속 상태가 더이상 가지 않게 / 차단 해제한 유저의 것은 다시 가게)
+// 차단은 이미 저장됐으므로 실패해도 log만 남긴다
+func (cfg *apiConfig) notifyBlocksChanged(ctx context.Context, userID uuid.UUID) {
+	if err := cfg.sendRealtimeSignal(ctx, realtimeSignal{Type: realtimeSignalBlocks, UserID: userID}); err != nil {
+		reqlog.Logger(ctx).Error("Error sending blocks changed signal", "error", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// /api/chirps/{chirpID}/bookmark path PUT handler : chirp 북마크
+// 이미 북마크한 chirp면 아무것도 바뀌지 않는다
+func (cfg *apiConfig) handlerBookmarkPUT(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	// 볼 수 없는 chirp는 북마크할 수 없다 (GET과 마찬가지로 404)
+	chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
+		return
+	}
+	// 삭제된 chirp는 북마크할 수 없다
+	if chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", errors.New("error chirp is deleted"))
+		// code 404
+		return
+	}
+
+	if err := cfg.ptrDB.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating bookmark in DB", fmt.Errorf("error creating bookmark in DB: %w", err))
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// /api/chirps/{chirpID}/bookmark path DELETE handler : 북마크 삭제
+func (cfg *apiConfig) handlerBookmarkDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if err := cfg.ptrDB.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting bookmark in DB", fmt.Errorf("error deleting bookmark in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/users/me/bookmarks path GET handler : 내 북마크 목록 (최근 북마크 순, ?limit=&cursor= 페이지)
+// 북마크는 본인만 볼 수 있으므로 다른 유저의 북마크를 조회하는 path는 없다
+func (cfg *apiConfig) handlerBookmarksGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetBookmarkedChirpsParams{
+		UserID: userID,
+		Limit:  limit,
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	bookmarks, err := cfg.ptrDB.GetBookmarkedChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting bookmarks in DB", fmt.Errorf("error getting bookmarks in DB: %w", err))
+		return
+	}
+
+	resBody := pageResBody[cResBodySuccess]{
+		Items: make([]cResBodySuccess, 0, len(bookmarks)),
+	}
+	for _, bookmark := range bookmarks {
+		resBody.Items = append(resBody.Items, newCResBodySuccess(bookmark.Chirp))
+	}
+
+	if err := cfg.fillChirpStats(r.Context(), resBody.Items, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 북마크 위치를 cursor로 전달
+	if len(bookmarks) == int(limit) {
+		last := bookmarks[len(bookmarks)-1]
+		resBody.NextCursor = pageCursor{CreatedAt: last.BookmarkedAt, ID: last.Chirp.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
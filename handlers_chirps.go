@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/chirptext"
 	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/hashtags"
+	"github.com/paokimsiwoong/chirpy/internal/links"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
 )
 
 // /api/chirps path POST handler : 새로운 chirp post 생성
@@ -30,6 +39,8 @@ func (cfg *apiConfig) handlerChirpsPOST(w http.ResponseWriter, r *http.Request)
 		// code 401
 		return
 	}
+	// 요청 로그에 로그인한 유저 기록
+	reqlog.SetUserID(r.Context(), userID)
 
 	// request body의 json 데이터를 담을 구조체
 	reqBody := cReqBody{}
@@ -40,23 +51,180 @@ func (cfg *apiConfig) handlerChirpsPOST(w http.ResponseWriter, r *http.Request)
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		code = 500
 		respondWithError(w, code, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
-		// respondWithError는 입력된 error를 log.Println으로 출력하고 입력된 msg를 json에 담아 response하는 함수
+		// respondWithError는 입력된 error를 요청 logger로 기록하고 입력된 msg(와 요청 id)를 json에 담아 response하는 함수
 		return
 	}
 
-	// 문자열 길이 확인 후 140 초과면 에러, 아니면 성공
-	if len(reqBody.Body) > 140 {
-		code = 400
-		respondWithError(w, code, "Error posting chirp : Chirp is too long", errors.New("chirp is too long"))
+	// 같은 글자가 여러 방식으로 인코딩될 수 있으므로(ex: 한글 자모 조합) NFC로 정규화해서 저장
+	reqBody.Body = chirptext.Normalize(reqBody.Body)
+
+	// 문자열 길이 확인 : byte 수가 아니라 화면에 보이는 글자 수 기준, chirpy red 유저는 더 길게 쓸 수 있다
+	user, err := cfg.ptrDB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+	limit := cfg.chirpMaxLength
+	if user.IsChirpyRed {
+		limit = cfg.chirpMaxLengthRed
+	}
+	if length := chirptext.Length(reqBody.Body); length > limit {
+		reqlog.Logger(r.Context()).Info("chirp is too long", "length", length, "limit", limit)
+		respondWithJSON(w, http.StatusBadRequest, chirpTooLongResBody{
+			Error:     "Error posting chirp : Chirp is too long",
+			Length:    length,
+			Limit:     limit,
+			RequestID: w.Header().Get(reqlog.Header),
+		})
+		// code 400
 		return
 	}
 
+	// 공개 범위 확인 (비어있으면 public)
+	visibility := reqBody.Visibility
+	if visibility == "" {
+		visibility = visibilityPublic
+	}
+	if visibility != visibilityPublic && visibility != visibilityFollowers && visibility != visibilityMentioned {
+		respondWithError(w, http.StatusBadRequest, "Error invalid visibility", fmt.Errorf("error invalid visibility: %s", visibility))
+		// code 400
+		return
+	}
+	if visibility == visibilityMentioned && len(reqBody.MentionedUserIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Error mentioned chirp needs at least one mentioned user", errors.New("error mentioned chirp without mentions"))
+		// code 400
+		return
+	}
+
+	// 내용 경고 확인 (작성자가 직접 붙이는 경고)
+	contentWarning, err := normalizeContentWarning(reqBody.ContentWarning)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid content warning: "+err.Error(), err)
+		// code 400
+		return
+	}
+	contentWarningSetBy := uuid.NullUUID{}
+	if contentWarning.Valid || reqBody.Sensitive {
+		contentWarningSetBy = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	if len(reqBody.MediaIDs) > maxChirpMedia {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error chirp can't have more than %d media", maxChirpMedia), errors.New("error too many media"))
+		// code 400
+		return
+	}
+
+	// 게시 상태 확인 : 임시저장, 예약, 바로 게시 중 하나
+	status := chirpStatusPublished
+	publishAt := sql.NullTime{}
+	if reqBody.Draft && reqBody.PublishAt != nil {
+		respondWithError(w, http.StatusBadRequest, "Error chirp can't be both draft and scheduled", errors.New("error draft with publish_at"))
+		// code 400
+		return
+	}
+	if reqBody.Draft {
+		status = chirpStatusDraft
+	}
+	if reqBody.PublishAt != nil {
+		if !reqBody.PublishAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "Error publish_at must be in the future", errors.New("error publish_at in the past"))
+			// code 400
+			return
+		}
+		status = chirpStatusScheduled
+		publishAt = sql.NullTime{Time: *reqBody.PublishAt, Valid: true}
+	}
+
+	// 투표 확인 : 마감 시간은 chirp가 게시되는 시간 기준
+	var pollOptions []string
+	if reqBody.Poll != nil {
+		startsAt := time.Now()
+		if publishAt.Valid {
+			startsAt = publishAt.Time
+		}
+		pollOptions, err = validatePoll(*reqBody.Poll, startsAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error invalid poll: "+err.Error(), fmt.Errorf("error invalid poll: %w", err))
+			// code 400
+			return
+		}
+	}
+
+	// quote chirp이면 인용할 chirp가 작성자에게 보이는 chirp인지 확인
+	quotedChirpID := uuid.NullUUID{}
+	if reqBody.QuotedChirpID != nil {
+		quoted, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+			ID:       *reqBody.QuotedChirpID,
+			ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Couldn't find quoted chirp", err)
+				// code 404
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error getting quoted chirp in DB", fmt.Errorf("error getting quoted chirp in DB: %w", err))
+			return
+		}
+		// 아직 게시되지 않았거나 삭제된 chirp는 인용할 수 없다
+		if quoted.Status != chirpStatusPublished || quoted.DeletedAt.Valid {
+			respondWithError(w, http.StatusNotFound, "Couldn't find quoted chirp", errors.New("quoted chirp is not published"))
+			// code 404
+			return
+		}
+		quotedChirpID = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	// 답글이면 답글을 달 chirp가 작성자에게 보이는 chirp인지 확인
+	inReplyToChirpID := uuid.NullUUID{}
+	if reqBody.InReplyToChirpID != nil {
+		parent, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+			ID:       *reqBody.InReplyToChirpID,
+			ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+				// code 404
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error getting chirp to reply to in DB", fmt.Errorf("error getting chirp to reply to in DB: %w", err))
+			return
+		}
+		// 아직 게시되지 않았거나 삭제된 chirp에는 답글을 달 수 없다
+		if parent.Status != chirpStatusPublished || parent.DeletedAt.Valid {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp to reply to", errors.New("chirp to reply to is not published"))
+			// code 404
+			return
+		}
+		inReplyToChirpID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
 	cleaned := censor(reqBody.Body)
 	// 특정 단어들 검열
 
-	chirp, err := cfg.ptrDB.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
+	// chirp와 mention들을 한 트랜잭션 안에서 생성
+	// ==> mention 저장에 실패하면 chirp 생성도 취소되어 아무도 볼 수 없는 chirp가 남지 않는다
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	// Commit 이후의 Rollback은 아무 일도 하지 않는다
+	qtx := cfg.txQueries(tx)
+
+	chirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:                cleaned,
+		UserID:              userID,
+		Visibility:          visibility,
+		QuotedChirpID:       quotedChirpID,
+		Status:              status,
+		PublishAt:           publishAt,
+		ContentWarning:      contentWarning,
+		Sensitive:           reqBody.Sensitive,
+		ContentWarningSetBy: contentWarningSetBy,
+		InReplyToChirpID:    inReplyToChirpID,
 	})
 	// http.Request의 Context() method는 req의 context.Context를 반환
 	// ==> 만약 접속이 끊기거나 타임아웃이 되면 그 정보가 context로 전달되서 db 쿼리를 알아서 중단시켜준다
@@ -66,26 +234,134 @@ func (cfg *apiConfig) handlerChirpsPOST(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// 언급된 유저 저장 (존재하지 않는 유저 id는 쿼리에서 무시됨)
+	for _, mentionedID := range reqBody.MentionedUserIDs {
+		if err := qtx.CreateChirpMention(r.Context(), database.CreateChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  mentionedID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating chirp mention in DB", fmt.Errorf("error creating chirp mention in DB: %w", err))
+			return
+		}
+	}
+
+	// media 첨부 (내가 업로드했고 아직 다른 chirp에 첨부되지 않은 media만 가능)
+	if len(reqBody.MediaIDs) > 0 {
+		attached, err := qtx.AttachMediaToChirp(r.Context(), database.AttachMediaToChirpParams{
+			ChirpID:  chirp.ID,
+			MediaIds: reqBody.MediaIDs,
+			UserID:   userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error attaching media in DB", fmt.Errorf("error attaching media in DB: %w", err))
+			return
+		}
+		if attached != int64(len(reqBody.MediaIDs)) {
+			respondWithError(w, http.StatusBadRequest, "Error media not found or already attached", errors.New("error media not attachable"))
+			// code 400
+			return
+		}
+	}
+
+	// 본문의 url 저장 (검열 후 저장되는 본문 기준 위치)
+	// 미리보기는 커밋 후에 job이 가져온다
+	for i, link := range links.Find(cleaned) {
+		if err := qtx.CreateChirpLink(r.Context(), database.CreateChirpLinkParams{
+			ChirpID:    chirp.ID,
+			Position:   int32(i + 1),
+			Url:        link.URL,
+			StartIndex: int32(link.Start),
+			EndIndex:   int32(link.End),
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating chirp link in DB", fmt.Errorf("error creating chirp link in DB: %w", err))
+			return
+		}
+		queued, err := qtx.QueueLinkPreview(r.Context(), link.URL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error queueing link preview in DB", fmt.Errorf("error queueing link preview in DB: %w", err))
+			return
+		}
+		if queued > 0 {
+			if err := jobLinkPreview.enqueue(r.Context(), qtx, linkPreviewJobPayload{URL: link.URL}); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error enqueueing job in DB", fmt.Errorf("error enqueueing job in DB: %w", err))
+				return
+			}
+		}
+	}
+
+	// 본문의 hashtag 저장 (trend 집계용)
+	for _, tag := range hashtags.Find(cleaned) {
+		if err := qtx.CreateChirpHashtag(r.Context(), database.CreateChirpHashtagParams{
+			ChirpID: chirp.ID,
+			Tag:     tag,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating chirp hashtag in DB", fmt.Errorf("error creating chirp hashtag in DB: %w", err))
+			return
+		}
+	}
+
+	// 투표와 선택지 생성
+	if reqBody.Poll != nil {
+		if _, err := qtx.CreatePoll(r.Context(), database.CreatePollParams{
+			ChirpID:  chirp.ID,
+			ClosesAt: reqBody.Poll.ClosesAt,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating poll in DB", fmt.Errorf("error creating poll in DB: %w", err))
+			return
+		}
+		for i, option := range pollOptions {
+			if _, err := qtx.CreatePollOption(r.Context(), database.CreatePollOptionParams{
+				ChirpID:  chirp.ID,
+				Position: int32(i + 1),
+				Text:     option,
+			}); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error creating poll option in DB", fmt.Errorf("error creating poll option in DB: %w", err))
+				return
+			}
+		}
+	}
+
+	// 바로 게시되는 chirp면 언급된 유저, 답글 받은 chirp 작성자에게 알림 (임시저장, 예약 chirp는 게시될 때 알린다)
+	if status == chirpStatusPublished {
+		if err := notifyChirpPublished(r.Context(), qtx, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating notifications in DB", fmt.Errorf("error creating notifications in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+	cfg.metrics.ChirpsCreated.WithLabelValues(chirp.Status).Inc()
+
 	// json에 저장할 데이터들 구조체에 저장
-	resBody := cResBodySuccess{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpAttachments(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp attachments in DB", fmt.Errorf("error getting chirp attachments in DB: %w", err))
+		return
 	}
 
 	// HTTP 201 Created는 http.StatusCreated
 	code = http.StatusCreated
 
-	respondWithJSON(w, code, resBody)
+	respondWithJSON(w, code, resBody[0])
 }
 
-// /api/chirps path GET handler : 모든 chirps 반환
+// /api/chirps path GET handler : 보는 유저가 볼 수 있는 모든 chirps 반환
 // ? 쿼리에 "author_id" 또는 "sort" key가 있을 때와 없을 때 행동이 다름
 func (cfg *apiConfig) handlerChirpsGET(w http.ResponseWriter, r *http.Request) {
 	var chirps []database.Chirp
+	var rechirps []database.GetVisibleRechirpsRow
 	var err error
+	// author_id 쿼리가 있을 때만 Valid
+	var authorID uuid.NullUUID
+
+	// 로그인 여부는 선택 (비로그인이면 공개 chirp만)
+	viewerID, ok := cfg.viewerID(w, r)
+	if !ok {
+		return
+	}
 
 	// query 확인하기
 	// @@@ query는 ?first=name&second=age와 같이 &로 여러개의 key, value pair가 포함될 수 있다
@@ -98,41 +374,62 @@ func (cfg *apiConfig) handlerChirpsGET(w http.ResponseWriter, r *http.Request) {
 			// code 400
 			return
 		}
-		chirps, err = cfg.ptrDB.GetChirpsByAuthorID(r.Context(), userID)
+		authorID = uuid.NullUUID{UUID: userID, Valid: true}
+		chirps, err = cfg.ptrDB.GetVisibleChirpsByAuthorID(r.Context(), database.GetVisibleChirpsByAuthorIDParams{
+			AuthorID: userID,
+			ViewerID: viewerID,
+		})
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error getting chirp list by author id in DB", fmt.Errorf("error getting chirp list by author id in DB: %w", err))
 			return
 		}
+		// 그 유저가 rechirp한 chirp들도 타임라인에 포함
+		rechirps, err = cfg.ptrDB.GetVisibleRechirps(r.Context(), database.GetVisibleRechirpsParams{
+			UserID:   authorID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting rechirp list in DB", fmt.Errorf("error getting rechirp list in DB: %w", err))
+			return
+		}
 	} else {
-		chirps, err = cfg.ptrDB.GetChirps(r.Context())
+		chirps, err = cfg.ptrDB.GetVisibleChirps(r.Context(), viewerID)
 		// http.Request의 Context() method는 req의 context.Context를 반환
 		// ==> 만약 접속이 끊기거나 타임아웃이 되면 그 정보가 context로 전달되서 db 쿼리를 알아서 중단시켜준다
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Error getting chirp list in DB", fmt.Errorf("error getting chirp list in DB: %w", err))
 			return
 		}
+		rechirps, err = cfg.ptrDB.GetVisibleRechirps(r.Context(), database.GetVisibleRechirpsParams{
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting rechirp list in DB", fmt.Errorf("error getting rechirp list in DB: %w", err))
+			return
+		}
 	}
 	// @@@ 해답은 user_id로 거르는 별도의 SQL 쿼리를 쓰지 않고 밑의 for문에서 userid 일치하는 chirp만 append하는 방식 사용
 
 	// json에 저장할 데이터들 구조체에 저장
-	var resBody []cResBodySuccess
+	// chirp와 rechirp를 합치면서 같은 chirp는 한번만 나오도록 중복 제거
+	resBody := mergeTimeline(chirps, rechirps)
 	// resBody := make([]cResBodySuccess, len(chirps)) 사용하면 쓰레기 json이 두개 앞에 추가됨??
 
-	for _, chirp := range chirps {
-		resBody = append(resBody, cResBodySuccess{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		})
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
 	}
 
 	// query 확인하기 2
-	// sort 키가 있으면 value "asc", "desc"에 따라 정렬하기(created_at 기준)
+	// sort 키가 있으면 value "asc", "desc"에 따라 정렬하기(게시 시간 또는 rechirp 시간 기준)
 	if r.URL.Query().Get("sort") == "desc" {
-		sort.Slice(resBody, func(i, j int) bool { return resBody[i].CreatedAt.After(resBody[j].CreatedAt) })
-		// 이미 resBody가 asc로 정렬되어 있기때문에 slices.Reverse(resBody)도 가능
+		sort.SliceStable(resBody, func(i, j int) bool { return resBody[i].activityAt().After(resBody[j].activityAt()) })
+		// mergeTimeline이 asc로 정렬해서 반환하기 때문에 slices.Reverse(resBody)도 가능
+	}
+
+	// 특정 작성자의 chirp 목록이면 그 작성자가 고정한 chirp들을 정렬 방향과 상관없이 맨 앞에
+	if authorID.Valid {
+		resBody = pinnedFirst(resBody, authorID.UUID)
 	}
 	// @@@ 해답 예시
 	// sortDirection := "asc"
@@ -151,7 +448,14 @@ func (cfg *apiConfig) handlerChirpsGET(w http.ResponseWriter, r *http.Request) {
 }
 
 // /api/chirps/{chirpID} path GET handler : 특정 id chirp 반환
+// 보는 유저가 볼 수 없는 chirp는 존재 여부가 드러나지 않도록 403이 아니라 404 처리
 func (cfg *apiConfig) handlerChirpsGETOne(w http.ResponseWriter, r *http.Request) {
+	// 로그인 여부는 선택
+	viewerID, ok := cfg.viewerID(w, r)
+	if !ok {
+		return
+	}
+
 	// r.PathValue(path parameter 이름)로 chirpID 가져오고
 	// string 형태인 uuid를 uuid.Parse함수로 uuid.UUID 타입으로 변환
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
@@ -160,31 +464,42 @@ func (cfg *apiConfig) handlerChirpsGETOne(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// db에서 해당 id로 chirp 가져오기
-	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
+	// db에서 해당 id로 보는 유저가 볼 수 있는 chirp 가져오기
+	chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
 	// http.Request의 Context() method는 req의 context.Context를 반환
 	// ==> 만약 접속이 끊기거나 타임아웃이 되면 그 정보가 context로 전달되서 db 쿼리를 알아서 중단시켜준다
 	if err != nil {
-		// respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
-		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
-		// code 404
+		if errors.Is(err, sql.ErrNoRows) {
+			// 존재하지 않는 chirp와 볼 권한이 없는 chirp 모두 404
+			respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
 		return
 	}
 
 	// json에 저장할 데이터들 구조체에 저장
-	resBody := cResBodySuccess{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, resBody)
+	respondWithJSON(w, http.StatusOK, resBody[0])
 }
 
 // /api/chirps/{chirpID} path DELETE handler : 특정 id chirp 삭제
+// 실제로 지우지 않고 삭제 표시만 한다 (보관 기간이 지나면 retention job이 삭제)
+// 작성자는 자기 chirp를, moderator는 다른 유저의 chirp도 지울 수 있고 request body의 reason으로 사유를 남길 수 있다
 func (cfg *apiConfig) handlerChirpsDELETEOne(w http.ResponseWriter, r *http.Request) {
+	type deleteReqBody struct {
+		Reason string `json:"reason"`
+	}
+
 	// jWT sting이 Authorization header에 저장되어 있는지 확인
 	tokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -200,6 +515,8 @@ func (cfg *apiConfig) handlerChirpsDELETEOne(w http.ResponseWriter, r *http.Requ
 		// code 401
 		return
 	}
+	// 요청 로그에 로그인한 유저 기록
+	reqlog.SetUserID(r.Context(), userID)
 
 	// r.PathValue(path parameter 이름)로 chirpID 가져오고
 	// string 형태인 uuid를 uuid.Parse함수로 uuid.UUID 타입으로 변환
@@ -210,6 +527,14 @@ func (cfg *apiConfig) handlerChirpsDELETEOne(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// body는 선택이므로 비어있어도(io.EOF) 사유 없이 삭제
+	reqBody := deleteReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
 	// chirpID에 해당하는 chirp가 있는지 확인하고 가져오기
 	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
 	if err != nil {
@@ -217,24 +542,134 @@ func (cfg *apiConfig) handlerChirpsDELETEOne(w http.ResponseWriter, r *http.Requ
 		// code 404
 		return
 	}
+	// 이미 삭제된 chirp
+	if chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", errors.New("error chirp is already deleted"))
+		// code 404
+		return
+	}
 
-	// chirp의 작성자와 지금 지우려는 유저가 동일 유저인지 확인
+	// chirp의 작성자와 지금 지우려는 유저가 동일 유저인지 확인 (moderator는 다른 유저의 chirp도 삭제 가능)
 	if chirp.UserID != userID {
-		respondWithError(w, http.StatusForbidden, "Error can't delete other user's chirp", errors.New("error can't delete other user's chirp"))
-		// code 403
+		user, err := cfg.ptrDB.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+			return
+		}
+		if !user.IsModerator {
+			respondWithError(w, http.StatusForbidden, "Error can't delete other user's chirp", errors.New("error can't delete other user's chirp"))
+			// code 403
+			return
+		}
+	}
+
+	reason := sql.NullString{}
+	if trimmed := strings.TrimSpace(reqBody.Reason); trimmed != "" {
+		reason = sql.NullString{String: trimmed, Valid: true}
+	}
+
+	// 삭제 표시와 stream event, webhook 전송 기록을 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
 
-	// chirp db에서 삭제
-	if err := cfg.ptrDB.DeleteChirpByID(r.Context(), database.DeleteChirpByIDParams{
-		ID:     chirpID,
-		UserID: userID,
+	// chirp db에서 삭제 표시
+	if _, err := qtx.SoftDeleteChirp(r.Context(), database.SoftDeleteChirpParams{
+		DeletedBy:      uuid.NullUUID{UUID: userID, Valid: true},
+		DeletionReason: reason,
+		ID:             chirpID,
 	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 동시에 온 다른 삭제 요청이 먼저 처리된 경우
+			respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", err)
+			// code 404
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Error deleting chirp in DB", fmt.Errorf("error deleting chirp in DB: %w", err))
 		return
 	}
 
+	if err := recordStreamEvent(r.Context(), qtx, database.QueueStreamEventParams{
+		Type:     streamEventChirpDeleted,
+		ChirpID:  uuid.NullUUID{UUID: chirpID, Valid: true},
+		AuthorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating stream event in DB", fmt.Errorf("error creating stream event in DB: %w", err))
+		return
+	}
+
+	// 게시되지 않은 chirp(임시저장, 예약)는 외부에 알려진 적이 없으므로 보내지 않는다
+	if chirp.Status == chirpStatusPublished {
+		if err := enqueueChirpWebhook(r.Context(), qtx, webhookEventChirpDeleted, chirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating webhook deliveries in DB", fmt.Errorf("error creating webhook deliveries in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
 	// 정상적으로 삭제가 완료되면 status code 설정 후 함수 종료
 	w.WriteHeader(http.StatusNoContent)
 	// code 204
 }
+
+// response용 chirp들에 rechirp 수 등 보는 유저별 값들을 한번의 쿼리로 채우고 첨부 media, 투표, 링크도 채우는 함수
+func (cfg *apiConfig) fillChirpStats(ctx context.Context, resBody []cResBodySuccess, viewerID uuid.NullUUID) error {
+	if len(resBody) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(resBody))
+	for _, c := range resBody {
+		chirpIDs = append(chirpIDs, c.ID)
+	}
+
+	stats, err := cfg.ptrDB.GetChirpStats(ctx, database.GetChirpStatsParams{
+		ViewerID: viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	// chirp id로 찾을 수 있도록 map에 저장
+	statsByID := make(map[uuid.UUID]database.GetChirpStatsRow, len(stats))
+	for _, stat := range stats {
+		statsByID[stat.ID] = stat
+	}
+
+	for i := range resBody {
+		stat := statsByID[resBody[i].ID]
+		resBody[i].RechirpCount = stat.RechirpCount
+		resBody[i].RechirpedByMe = stat.RechirpedByMe
+		resBody[i].LikeCount = stat.LikeCount
+		resBody[i].LikedByMe = stat.LikedByMe
+		resBody[i].BookmarkedByMe = stat.BookmarkedByMe
+	}
+
+	return cfg.fillChirpAttachments(ctx, resBody, viewerID)
+}
+
+// response용 chirp들에 첨부 media, 투표, 링크를 채우고
+// 삭제된 chirp는 tombstone으로 바꾸고 보는 유저 설정에 맞춰 경고 chirp를 접는 함수
+// 게시 전 chirp처럼 rechirp 수 등이 필요 없는 경우 fillChirpStats 대신 사용
+func (cfg *apiConfig) fillChirpAttachments(ctx context.Context, resBody []cResBodySuccess, viewerID uuid.NullUUID) error {
+	if err := cfg.fillChirpMedia(ctx, resBody); err != nil {
+		return err
+	}
+	if err := cfg.fillChirpLinks(ctx, resBody); err != nil {
+		return err
+	}
+	if err := cfg.fillChirpPolls(ctx, resBody, viewerID); err != nil {
+		return err
+	}
+	redactDeletedChirps(resBody, viewerID)
+	return cfg.applyCollapsePreference(ctx, resBody, viewerID)
+}
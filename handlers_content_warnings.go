@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/chirptext"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// 경고 문구 최대 글자 수
+const maxContentWarningLength = 100
+
+// 경고 문구를 chirp 본문과 같은 방식으로 정규화, 공백 정리하고 길이를 확인하는 함수 (빈 문구는 경고 없음)
+func normalizeContentWarning(warning string) (sql.NullString, error) {
+	warning = strings.TrimSpace(chirptext.Normalize(warning))
+	if warning == "" {
+		return sql.NullString{}, nil
+	}
+	if chirptext.Length(warning) > maxContentWarningLength {
+		return sql.NullString{}, fmt.Errorf("content warning can't be longer than %d characters", maxContentWarningLength)
+	}
+	return sql.NullString{String: warning, Valid: true}, nil
+}
+
+// /api/chirps/{chirpID}/content_warning path PUT handler : moderator가 chirp에 경고를 붙이거나 떼기
+// content_warning이 비어있고 sensitive가 false면 경고 해제
+func (cfg *apiConfig) handlerContentWarningPUT(w http.ResponseWriter, r *http.Request) {
+	type contentWarningReqBody struct {
+		ContentWarning string `json:"content_warning"`
+		Sensitive      bool   `json:"sensitive"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	reqBody := contentWarningReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	user, err := cfg.ptrDB.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+	if !user.IsModerator {
+		respondWithError(w, http.StatusForbidden, "Error only moderators can set content warnings", errors.New("error user is not a moderator"))
+		// code 403
+		return
+	}
+
+	warning, err := normalizeContentWarning(reqBody.ContentWarning)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid content warning: "+err.Error(), err)
+		// code 400
+		return
+	}
+
+	// 경고를 해제하면 누가 붙였는지도 지운다
+	setBy := uuid.NullUUID{}
+	if warning.Valid || reqBody.Sensitive {
+		setBy = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	chirp, err := cfg.ptrDB.SetChirpContentWarning(r.Context(), database.SetChirpContentWarningParams{
+		ContentWarning:      warning,
+		Sensitive:           reqBody.Sensitive,
+		ContentWarningSetBy: setBy,
+		ID:                  chirpID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp in DB", fmt.Errorf("error updating chirp in DB: %w", err))
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// /api/users/me/preferences path PUT handler : 경고가 붙은 chirp를 바로 펼쳐서 볼지 설정
+func (cfg *apiConfig) handlerUsersPreferencesPUT(w http.ResponseWriter, r *http.Request) {
+	type preferencesReqBody struct {
+		AutoExpandSensitive bool `json:"auto_expand_sensitive"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody := preferencesReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	user, err := cfg.ptrDB.UpdateUserAutoExpandSensitive(r.Context(), database.UpdateUserAutoExpandSensitiveParams{
+		AutoExpandSensitive: reqBody.AutoExpandSensitive,
+		ID:                  userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating user in DB", fmt.Errorf("error updating user in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newUResBodySuccess(user))
+	// code 200
+}
+
+// 보는 유저의 설정에 따라 경고가 붙은 chirp들의 collapsed 값을 정하는 함수
+// 비로그인 유저는 항상 접힌 상태, 작성자 본인과 auto_expand_sensitive 유저는 펼친 상태
+func (cfg *apiConfig) applyCollapsePreference(ctx context.Context, resBody []cResBodySuccess, viewerID uuid.NullUUID) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	autoExpand := false
+	for _, c := range resBody {
+		if c.Collapsed {
+			// 접을 chirp가 있을 때만 설정을 조회
+			viewer, err := cfg.ptrDB.GetUserByID(ctx, viewerID.UUID)
+			if err != nil {
+				return err
+			}
+			autoExpand = viewer.AutoExpandSensitive
+			break
+		}
+	}
+
+	for i := range resBody {
+		if autoExpand || resBody[i].UserID == viewerID.UUID {
+			resBody[i].Collapsed = false
+		}
+	}
+
+	return nil
+}
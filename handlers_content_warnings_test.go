@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeContentWarning(t *testing.T) {
+	// 조합형 "한" (초성, 중성, 종성 자모를 따로 쓴 것)
+	decomposed := string([]rune{0x1112, 0x1161, 0x11ab})
+
+	cases := []struct {
+		name     string
+		input    string
+		expected sql.NullString
+		wantErr  bool
+	}{
+		{
+			name:     "empty means no warning",
+			input:    "",
+			expected: sql.NullString{},
+		},
+		{
+			name:     "blank means no warning",
+			input:    " \t\n ",
+			expected: sql.NullString{},
+		},
+		{
+			name:     "trimmed",
+			input:    "  spoilers ",
+			expected: sql.NullString{String: "spoilers", Valid: true},
+		},
+		{
+			name:     "normalized",
+			input:    decomposed + " 스포일러",
+			expected: sql.NullString{String: "한 스포일러", Valid: true},
+		},
+		{
+			// 한글 100자는 300 byte지만 100글자로 센다
+			name:     "max length in characters",
+			input:    strings.Repeat("한", maxContentWarningLength),
+			expected: sql.NullString{String: strings.Repeat("한", maxContentWarningLength), Valid: true},
+		},
+		{
+			name:     "decomposed max length",
+			input:    strings.Repeat(decomposed, maxContentWarningLength),
+			expected: sql.NullString{String: strings.Repeat("한", maxContentWarningLength), Valid: true},
+		},
+		{
+			name:    "too long",
+			input:   strings.Repeat("a", maxContentWarningLength+1),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		actual, err := normalizeContentWarning(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: normalizeContentWarning returned %v, expecting error", tc.name, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: normalizeContentWarning error: %v", tc.name, err)
+			continue
+		}
+		if actual != tc.expected {
+			t.Errorf("%s: normalizeContentWarning = %v, expecting %v", tc.name, actual, tc.expected)
+		}
+	}
+}
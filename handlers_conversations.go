@@ -0,0 +1,663 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/chirptext"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	// 한 대화의 최대 참여자 수 (대화를 만든 유저 포함)
+	maxConversationParticipants = 10
+	// 메시지 최대 글자 수 (grapheme 단위)
+	maxMessageLength = 1000
+)
+
+type conversationParticipantResBody struct {
+	UserID   uuid.UUID `json:"user_id"`
+	JoinedAt time.Time `json:"joined_at"`
+	// 마지막으로 읽은 메시지의 시간 (아직 읽은 메시지가 없으면 null)
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type messageResBody struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	// 이 메시지를 읽은 참여자들 (보낸 유저 제외)
+	ReadBy []uuid.UUID `json:"read_by"`
+}
+
+type conversationResBody struct {
+	ID           uuid.UUID                        `json:"id"`
+	CreatedAt    time.Time                        `json:"created_at"`
+	UpdatedAt    time.Time                        `json:"updated_at"`
+	IsGroup      bool                             `json:"is_group"`
+	Participants []conversationParticipantResBody `json:"participants"`
+	// 메시지가 없으면 null
+	LastMessage *messageResBody `json:"last_message"`
+	UnreadCount int64           `json:"unread_count"`
+}
+
+// db의 message를 response용 구조체로 변환하는 함수
+// 참여자별 마지막으로 읽은 시간으로 읽음 표시를 채운다
+func newMessageResBody(message database.Message, participants []database.ConversationParticipant) messageResBody {
+	resBody := messageResBody{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+		ReadBy:         []uuid.UUID{},
+	}
+	for _, p := range participants {
+		if p.UserID != message.SenderID && p.LastReadAt.Valid && !p.LastReadAt.Time.Before(message.CreatedAt) {
+			resBody.ReadBy = append(resBody.ReadBy, p.UserID)
+		}
+	}
+	return resBody
+}
+
+// 1:1 대화의 direct_key : 두 유저 id를 정렬해서 이어붙여 누가 먼저 대화를 시작해도 같은 값이 되도록 한다
+func directConversationKey(a, b uuid.UUID) string {
+	ids := []string{a.String(), b.String()}
+	slices.Sort(ids)
+	return strings.Join(ids, ":")
+}
+
+// /api/conversations path POST handler : 대화 시작
+// participant_ids가 한명이면 1:1 대화 (이미 있으면 기존 대화를 200으로 반환), 여러명이면 그룹 대화
+func (cfg *apiConfig) handlerConversationsPOST(w http.ResponseWriter, r *http.Request) {
+	type conversationReqBody struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody := conversationReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	// 중복 제거 (순서 유지)
+	var others []uuid.UUID
+	for _, id := range reqBody.ParticipantIDs {
+		if id == userID {
+			respondWithError(w, http.StatusBadRequest, "Error participant_ids must not include yourself", errors.New("error participant_ids include requester"))
+			// code 400
+			return
+		}
+		if !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Error participant_ids is required", errors.New("error empty participant_ids"))
+		// code 400
+		return
+	}
+	if len(others)+1 > maxConversationParticipants {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error a conversation can't have more than %d participants", maxConversationParticipants), errors.New("error too many participants"))
+		// code 400
+		return
+	}
+
+	// 참여자들이 존재하는지, 차단 관계가 아닌지 확인
+	for _, id := range others {
+		if _, err := cfg.ptrDB.GetUserByID(r.Context(), id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+				// code 404
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+			return
+		}
+
+		blocked, err := cfg.ptrDB.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+			UserID:  userID,
+			OtherID: id,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error checking block in DB", fmt.Errorf("error checking block in DB: %w", err))
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Error can't start a conversation with this user", errors.New("error users are blocked"))
+			// code 403
+			return
+		}
+	}
+
+	isGroup := len(others) > 1
+	// 그룹 대화면 서로 차단한 참여자들도 같은 대화에 넣지 않는다
+	if isGroup {
+		blocked, err := cfg.ptrDB.IsBlockedAmong(r.Context(), others)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error checking block in DB", fmt.Errorf("error checking block in DB: %w", err))
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "Error participants have blocked each other", errors.New("error participants are blocked"))
+			// code 403
+			return
+		}
+	}
+
+	directKey := sql.NullString{}
+	if !isGroup {
+		directKey = sql.NullString{String: directConversationKey(userID, others[0]), Valid: true}
+	}
+
+	// 대화와 참여자들을 한 트랜잭션으로 생성
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	conversation, err := qtx.CreateConversation(r.Context(), database.CreateConversationParams{
+		CreatedBy: uuid.NullUUID{UUID: userID, Valid: true},
+		IsGroup:   isGroup,
+		DirectKey: directKey,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) && directKey.Valid {
+			// 이미 있는 1:1 대화면 그대로 반환 (동시에 온 요청이 먼저 만든 경우 포함)
+			existing, err := qtx.GetConversationByDirectKey(r.Context(), directKey)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error getting conversation in DB", fmt.Errorf("error getting conversation in DB: %w", err))
+				return
+			}
+			cfg.respondWithConversation(w, r, http.StatusOK, userID, existing)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error creating conversation in DB", fmt.Errorf("error creating conversation in DB: %w", err))
+		return
+	}
+
+	for _, id := range append([]uuid.UUID{userID}, others...) {
+		if err := qtx.AddConversationParticipant(r.Context(), database.AddConversationParticipantParams{
+			ConversationID: conversation.ID,
+			UserID:         id,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error adding participant in DB", fmt.Errorf("error adding participant in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	cfg.respondWithConversation(w, r, http.StatusCreated, userID, conversation)
+	// code 201
+}
+
+// /api/conversations path GET handler : 내가 참여중인 대화 목록 (최근 메시지 순, ?limit=&cursor= 페이지)
+func (cfg *apiConfig) handlerConversationsGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetConversationsForParticipantParams{
+		UserID: userID,
+		Limit:  limit,
+	}
+	if cursor != nil {
+		params.BeforeUpdatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.ptrDB.GetConversationsForParticipant(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversations in DB", fmt.Errorf("error getting conversations in DB: %w", err))
+		return
+	}
+
+	conversations := make([]database.Conversation, 0, len(rows))
+	unreadCounts := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		conversations = append(conversations, row.Conversation)
+		unreadCounts = append(unreadCounts, row.UnreadCount)
+	}
+
+	items, err := cfg.newConversationResBodies(r.Context(), userID, conversations, unreadCounts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation details in DB", fmt.Errorf("error getting conversation details in DB: %w", err))
+		return
+	}
+
+	resBody := pageResBody[conversationResBody]{Items: items}
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 대화 위치를 cursor로 전달
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1].Conversation
+		resBody.NextCursor = pageCursor{CreatedAt: last.UpdatedAt, ID: last.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/conversations/{conversationID} path GET handler : 대화 하나 (참여자가 아니면 404)
+func (cfg *apiConfig) handlerConversationsGETOne(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	cfg.respondWithConversation(w, r, http.StatusOK, userID, conversation)
+}
+
+// /api/conversations/unread_count path GET handler : 참여중인 모든 대화의 안읽은 메시지 수
+func (cfg *apiConfig) handlerConversationsUnreadCountGET(w http.ResponseWriter, r *http.Request) {
+	type unreadCountResBody struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := cfg.ptrDB.GetUnreadMessageCount(r.Context(), database.GetUnreadMessageCountParams{
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting unread messages in DB", fmt.Errorf("error counting unread messages in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, unreadCountResBody{UnreadCount: count})
+}
+
+// /api/conversations/{conversationID}/messages path GET handler : 대화의 메시지 목록 (최근 순, ?limit=&cursor= 페이지)
+func (cfg *apiConfig) handlerMessagesGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetMessagesForParticipantParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+		Limit:          limit,
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	messages, err := cfg.ptrDB.GetMessagesForParticipant(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting messages in DB", fmt.Errorf("error getting messages in DB: %w", err))
+		return
+	}
+
+	participants, err := cfg.ptrDB.GetConversationParticipants(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting participants in DB", fmt.Errorf("error getting participants in DB: %w", err))
+		return
+	}
+
+	resBody := pageResBody[messageResBody]{
+		Items: make([]messageResBody, 0, len(messages)),
+	}
+	for _, message := range messages {
+		resBody.Items = append(resBody.Items, newMessageResBody(message, participants))
+	}
+
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 메시지 위치를 cursor로 전달
+	if len(messages) == int(limit) {
+		last := messages[len(messages)-1]
+		resBody.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/conversations/{conversationID}/messages path POST handler : 메시지 보내기
+// 1:1 대화에서 상대와 차단 관계면 403
+func (cfg *apiConfig) handlerMessagesPOST(w http.ResponseWriter, r *http.Request) {
+	type messageReqBody struct {
+		Body string `json:"body"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody := messageReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	body := strings.TrimSpace(chirptext.Normalize(reqBody.Body))
+	if body == "" {
+		respondWithError(w, http.StatusBadRequest, "Error message body is empty", errors.New("error empty message body"))
+		// code 400
+		return
+	}
+	if chirptext.Length(body) > maxMessageLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error message can't be longer than %d characters", maxMessageLength), errors.New("error message too long"))
+		// code 400
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	participants, err := cfg.ptrDB.GetConversationParticipants(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting participants in DB", fmt.Errorf("error getting participants in DB: %w", err))
+		return
+	}
+
+	if !conversation.IsGroup {
+		for _, p := range participants {
+			if p.UserID == userID {
+				continue
+			}
+			blocked, err := cfg.ptrDB.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+				UserID:  userID,
+				OtherID: p.UserID,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error checking block in DB", fmt.Errorf("error checking block in DB: %w", err))
+				return
+			}
+			if blocked {
+				respondWithError(w, http.StatusForbidden, "Error can't send a message to this user", errors.New("error users are blocked"))
+				// code 403
+				return
+			}
+		}
+	}
+
+	// 메시지 생성, 대화 정렬 시간 갱신, 보낸 유저의 읽음 표시, 실시간 event를 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	message, err := qtx.CreateMessage(r.Context(), database.CreateMessageParams{
+		Body:           body,
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 확인 이후 대화에서 빠진 경우
+			respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error creating message in DB", fmt.Errorf("error creating message in DB: %w", err))
+		return
+	}
+
+	if err := qtx.TouchConversation(r.Context(), conversation.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating conversation in DB", fmt.Errorf("error updating conversation in DB: %w", err))
+		return
+	}
+
+	if _, err := qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error marking conversation read in DB", fmt.Errorf("error marking conversation read in DB: %w", err))
+		return
+	}
+
+	// 대화 채널을 구독 중인 참여자들에게 실시간으로 보낸다
+	if err := recordStreamEvent(r.Context(), qtx, database.QueueStreamEventParams{
+		Type:           streamEventMessageCreated,
+		ActorID:        uuid.NullUUID{UUID: userID, Valid: true},
+		ConversationID: uuid.NullUUID{UUID: conversation.ID, Valid: true},
+		MessageID:      uuid.NullUUID{UUID: message.ID, Valid: true},
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating stream event in DB", fmt.Errorf("error creating stream event in DB: %w", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newMessageResBody(message, participants))
+	// code 201
+}
+
+// /api/conversations/{conversationID}/read path POST handler : 읽음 표시
+// message_id가 주어지면 그 메시지까지, 없으면 마지막 메시지까지 읽음으로 표시
+func (cfg *apiConfig) handlerConversationsReadPOST(w http.ResponseWriter, r *http.Request) {
+	type readReqBody struct {
+		MessageID *uuid.UUID `json:"message_id"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// body는 선택이므로 비어있어도(io.EOF) 마지막 메시지까지 읽음 표시
+	reqBody := readReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var readAt time.Time
+	if reqBody.MessageID != nil {
+		message, err := cfg.ptrDB.GetMessageByID(r.Context(), *reqBody.MessageID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Couldn't find message", err)
+			// code 404
+			return
+		}
+		if message.ConversationID != conversation.ID {
+			respondWithError(w, http.StatusNotFound, "Couldn't find message", errors.New("error message is in another conversation"))
+			// code 404
+			return
+		}
+		readAt = message.CreatedAt
+	} else {
+		latest, err := cfg.ptrDB.GetLatestMessages(r.Context(), database.GetLatestMessagesParams{
+			ConversationIds: []uuid.UUID{conversation.ID},
+			UserID:          userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting messages in DB", fmt.Errorf("error getting messages in DB: %w", err))
+			return
+		}
+		if len(latest) == 0 {
+			// 읽을 메시지가 없다
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		readAt = latest[0].CreatedAt
+	}
+
+	if _, err := cfg.ptrDB.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ReadAt:         readAt,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error marking conversation read in DB", fmt.Errorf("error marking conversation read in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// path의 conversationID 대화를 유저가 참여중일 때만 가져오는 함수
+// 참여자가 아니면 대화가 있는지도 알 수 없도록 없는 대화와 같은 404 response를 보내고 ok false 반환
+func (cfg *apiConfig) participantConversation(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.Conversation, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return database.Conversation{}, false
+	}
+
+	conversation, err := cfg.ptrDB.GetConversationForParticipant(r.Context(), database.GetConversationForParticipantParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+			// code 404
+			return database.Conversation{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation in DB", fmt.Errorf("error getting conversation in DB: %w", err))
+		return database.Conversation{}, false
+	}
+
+	return conversation, true
+}
+
+// 대화 하나를 안읽은 메시지 수, 참여자, 마지막 메시지와 함께 response로 보내는 함수
+func (cfg *apiConfig) respondWithConversation(w http.ResponseWriter, r *http.Request, code int, userID uuid.UUID, conversation database.Conversation) {
+	unread, err := cfg.ptrDB.GetUnreadMessageCount(r.Context(), database.GetUnreadMessageCountParams{
+		UserID:         userID,
+		ConversationID: uuid.NullUUID{UUID: conversation.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting unread messages in DB", fmt.Errorf("error counting unread messages in DB: %w", err))
+		return
+	}
+
+	resBody, err := cfg.newConversationResBodies(r.Context(), userID, []database.Conversation{conversation}, []int64{unread})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting conversation details in DB", fmt.Errorf("error getting conversation details in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, code, resBody[0])
+}
+
+// response용 대화들에 참여자와 마지막 메시지를 대화 수와 상관없이 두번의 쿼리로 채우는 함수
+// unreadCounts는 conversations와 같은 순서
+func (cfg *apiConfig) newConversationResBodies(ctx context.Context, userID uuid.UUID, conversations []database.Conversation, unreadCounts []int64) ([]conversationResBody, error) {
+	resBody := make([]conversationResBody, 0, len(conversations))
+	if len(conversations) == 0 {
+		return resBody, nil
+	}
+
+	conversationIDs := make([]uuid.UUID, 0, len(conversations))
+	for _, c := range conversations {
+		conversationIDs = append(conversationIDs, c.ID)
+	}
+
+	participants, err := cfg.ptrDB.GetConversationParticipants(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	participantsByID := make(map[uuid.UUID][]database.ConversationParticipant)
+	for _, p := range participants {
+		participantsByID[p.ConversationID] = append(participantsByID[p.ConversationID], p)
+	}
+
+	latest, err := cfg.ptrDB.GetLatestMessages(ctx, database.GetLatestMessagesParams{
+		ConversationIds: conversationIDs,
+		UserID:          userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	latestByID := make(map[uuid.UUID]database.Message, len(latest))
+	for _, m := range latest {
+		latestByID[m.ConversationID] = m
+	}
+
+	for i, c := range conversations {
+		item := conversationResBody{
+			ID:           c.ID,
+			CreatedAt:    c.CreatedAt,
+			UpdatedAt:    c.UpdatedAt,
+			IsGroup:      c.IsGroup,
+			Participants: make([]conversationParticipantResBody, 0, len(participantsByID[c.ID])),
+			UnreadCount:  unreadCounts[i],
+		}
+		for _, p := range participantsByID[c.ID] {
+			participant := conversationParticipantResBody{
+				UserID:   p.UserID,
+				JoinedAt: p.JoinedAt,
+			}
+			if p.LastReadAt.Valid {
+				participant.LastReadAt = &p.LastReadAt.Time
+			}
+			item.Participants = append(item.Participants, participant)
+		}
+		if m, ok := latestByID[c.ID]; ok {
+			lastMessage := newMessageResBody(m, participantsByID[c.ID])
+			item.LastMessage = &lastMessage
+		}
+		resBody = append(resBody, item)
+	}
+
+	return resBody, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// 작성자가 지운 chirp를 복구할 수 있는 기간
+const chirpRestoreWindow = 30 * 24 * time.Hour
+
+// moderator가 지운 chirp 대신 목록에 표시되는 문구
+const moderatorTombstone = "This chirp was removed by a moderator"
+
+// /api/chirps/{chirpID}/restore path POST handler : 내가 지운 chirp 복구
+// moderator가 지운 chirp와 복구 기간이 지난 chirp는 복구할 수 없다
+func (cfg *apiConfig) handlerChirpsRestore(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// chirpID에 해당하는 chirp가 있는지 확인하고 가져오기
+	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+		// code 404
+		return
+	}
+
+	// chirp의 작성자와 지금 복구하려는 유저가 동일 유저인지 확인
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Error can't restore other user's chirp", errors.New("error can't restore other user's chirp"))
+		// code 403
+		return
+	}
+
+	if !chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Error chirp is not deleted", errors.New("error chirp is not deleted"))
+		// code 409
+		return
+	}
+
+	if chirp.DeletedBy.UUID != userID {
+		respondWithError(w, http.StatusForbidden, "Error can't restore chirp removed by a moderator", errors.New("error chirp removed by moderator"))
+		// code 403
+		return
+	}
+
+	restored, err := cfg.ptrDB.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:              chirpID,
+		UserID:          userID,
+		RestorableSince: time.Now().Add(-chirpRestoreWindow),
+	})
+	if err != nil {
+		// 복구 기간이 지났거나 그 사이 retention job이 삭제한 경우
+		respondWithError(w, http.StatusGone, "Error restore window has passed", fmt.Errorf("error restoring chirp in DB: %w", err))
+		// code 410
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(restored)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// 삭제된 chirp를 보는 유저에 맞게 바꾸는 함수
+// 작성자는 내용과 삭제 사유, 복구 가능 기한을 그대로 보고
+// 다른 유저에게는 내용을 모두 지운 tombstone만 보여준다 (목록에 남는 건 moderator가 지운 chirp뿐)
+func redactDeletedChirps(resBody []cResBodySuccess, viewerID uuid.NullUUID) {
+	for i := range resBody {
+		c := &resBody[i]
+		if c.DeletedAt == nil {
+			continue
+		}
+
+		if viewerID.Valid && c.UserID == viewerID.UUID {
+			c.DeletionReason = c.deletionReason
+			if !c.removedByModerator {
+				until := c.DeletedAt.Add(chirpRestoreWindow)
+				c.RestorableUntil = &until
+			}
+			continue
+		}
+
+		c.Body = ""
+		c.QuotedChirpID = nil
+		c.InReplyToChirpID = nil
+		c.ContentWarning = nil
+		c.Sensitive = false
+		c.Collapsed = false
+		c.Media = []mediaResBody{}
+		c.Links = []linkResBody{}
+		c.Poll = nil
+		c.DeletionReason = c.deletionReason
+		if c.removedByModerator {
+			c.Tombstone = moderatorTombstone
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRedactDeletedChirps(t *testing.T) {
+	author, moderator, other := uuid.New(), uuid.New(), uuid.New()
+	reason := "spam"
+	deletedAt := testTime(10)
+	restorableUntil := deletedAt.Add(chirpRestoreWindow)
+
+	// 내용이 모두 채워진 테스트용 chirp (deletedBy가 uuid.Nil이면 삭제되지 않은 chirp)
+	chirp := func(deletedBy uuid.UUID) cResBodySuccess {
+		c := testChirp(uuid.New(), 0, 0)
+		c.UserID = author
+		c.Body = "hello"
+		c.QuotedChirpID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		c.InReplyToChirpID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		c.ContentWarning = sql.NullString{String: "spoilers", Valid: true}
+		c.Sensitive = true
+		if deletedBy != uuid.Nil {
+			c.DeletedAt = sql.NullTime{Time: deletedAt, Valid: true}
+			c.DeletedBy = uuid.NullUUID{UUID: deletedBy, Valid: true}
+			c.DeletionReason = sql.NullString{String: reason, Valid: true}
+		}
+
+		resBody := newCResBodySuccess(c)
+		resBody.Media = []mediaResBody{{ID: uuid.New()}}
+		resBody.Links = []linkResBody{{URL: "https://example.com"}}
+		resBody.Poll = &pollResBody{}
+		return resBody
+	}
+
+	// 작성자가 아닌 유저가 보는 삭제된 chirp
+	tombstone := func(c cResBodySuccess) cResBodySuccess {
+		c.Body = ""
+		c.QuotedChirpID = nil
+		c.InReplyToChirpID = nil
+		c.ContentWarning = nil
+		c.Sensitive = false
+		c.Collapsed = false
+		c.Media = []mediaResBody{}
+		c.Links = []linkResBody{}
+		c.Poll = nil
+		c.DeletionReason = &reason
+		return c
+	}
+
+	cases := []struct {
+		name     string
+		input    cResBodySuccess
+		viewerID uuid.NullUUID
+		expected func(c cResBodySuccess) cResBodySuccess
+	}{
+		{
+			name:     "not deleted",
+			input:    chirp(uuid.Nil),
+			viewerID: uuid.NullUUID{UUID: other, Valid: true},
+			expected: func(c cResBodySuccess) cResBodySuccess { return c },
+		},
+		{
+			name:     "deleted by author seen by author",
+			input:    chirp(author),
+			viewerID: uuid.NullUUID{UUID: author, Valid: true},
+			expected: func(c cResBodySuccess) cResBodySuccess {
+				c.DeletionReason = &reason
+				c.RestorableUntil = &restorableUntil
+				return c
+			},
+		},
+		{
+			// moderator가 지운 chirp는 작성자가 복구할 수 없다
+			name:     "removed by moderator seen by author",
+			input:    chirp(moderator),
+			viewerID: uuid.NullUUID{UUID: author, Valid: true},
+			expected: func(c cResBodySuccess) cResBodySuccess {
+				c.DeletionReason = &reason
+				return c
+			},
+		},
+		{
+			name:     "deleted by author seen by other",
+			input:    chirp(author),
+			viewerID: uuid.NullUUID{UUID: other, Valid: true},
+			expected: tombstone,
+		},
+		{
+			name:     "removed by moderator seen by other",
+			input:    chirp(moderator),
+			viewerID: uuid.NullUUID{UUID: other, Valid: true},
+			expected: func(c cResBodySuccess) cResBodySuccess {
+				c = tombstone(c)
+				c.Tombstone = moderatorTombstone
+				return c
+			},
+		},
+		{
+			name:     "removed by moderator seen logged out",
+			input:    chirp(moderator),
+			viewerID: uuid.NullUUID{},
+			expected: func(c cResBodySuccess) cResBodySuccess {
+				c = tombstone(c)
+				c.Tombstone = moderatorTombstone
+				return c
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		expected := tc.expected(tc.input)
+		actual := []cResBodySuccess{tc.input}
+		redactDeletedChirps(actual, tc.viewerID)
+		if !reflect.DeepEqual(actual[0], expected) {
+			t.Errorf("%s: redactDeletedChirps = %+v, expecting %+v", tc.name, actual[0], expected)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// /api/users/me/drafts path GET handler : 내 임시저장, 예약 chirp 목록
+// 게시되지 않은 chirp는 다른 유저의 목록에 나오지 않으므로 작성자 본인만 이 path로 볼 수 있다
+func (cfg *apiConfig) handlerDraftsGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirps, err := cfg.ptrDB.GetUnpublishedChirpsByAuthorID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting draft list in DB", fmt.Errorf("error getting draft list in DB: %w", err))
+		return
+	}
+
+	resBody := make([]cResBodySuccess, 0, len(chirps))
+	for _, chirp := range chirps {
+		resBody = append(resBody, newCResBodySuccess(chirp))
+	}
+	if err := cfg.fillChirpAttachments(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp attachments in DB", fmt.Errorf("error getting chirp attachments in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/chirps/{chirpID}/publish path POST handler : 임시저장, 예약 chirp 게시
+// request body에 publish_at이 있으면 그 시간으로 예약(또는 예약 시간 변경), 없으면 바로 게시
+func (cfg *apiConfig) handlerChirpsPublish(w http.ResponseWriter, r *http.Request) {
+	type publishReqBody struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// body는 선택이므로 비어있어도(io.EOF) 바로 게시로 처리
+	reqBody := publishReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	// chirpID에 해당하는 chirp가 있는지 확인하고 가져오기
+	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+		// code 404
+		return
+	}
+
+	// chirp의 작성자와 지금 게시하려는 유저가 동일 유저인지 확인
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Error can't publish other user's chirp", errors.New("error can't publish other user's chirp"))
+		// code 403
+		return
+	}
+
+	if chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", errors.New("error chirp is deleted"))
+		// code 404
+		return
+	}
+
+	if chirp.Status == chirpStatusPublished {
+		respondWithError(w, http.StatusConflict, "Error chirp is already published", errors.New("error chirp is already published"))
+		// code 409
+		return
+	}
+
+	startsAt := time.Now()
+	if reqBody.PublishAt != nil {
+		if !reqBody.PublishAt.After(startsAt) {
+			respondWithError(w, http.StatusBadRequest, "Error publish_at must be in the future", errors.New("error publish_at in the past"))
+			// code 400
+			return
+		}
+		startsAt = *reqBody.PublishAt
+	}
+
+	// 투표가 있으면 마감 시간을 실제 게시 시간 기준으로 다시 확인 (작성할 때와 게시 시간이 달라졌을 수 있다)
+	poll, err := cfg.ptrDB.GetPollByChirpID(r.Context(), chirpID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error getting poll in DB", fmt.Errorf("error getting poll in DB: %w", err))
+		return
+	}
+	if err == nil {
+		if err := checkPollWindow(poll.ClosesAt, startsAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error invalid poll: "+err.Error(), fmt.Errorf("error invalid poll: %w", err))
+			// code 400
+			return
+		}
+	}
+
+	if reqBody.PublishAt != nil {
+		chirp, err = cfg.ptrDB.ScheduleChirp(r.Context(), database.ScheduleChirpParams{
+			PublishAt: sql.NullTime{Time: *reqBody.PublishAt, Valid: true},
+			ID:        chirpID,
+			UserID:    userID,
+		})
+	} else {
+		chirp, err = cfg.publishChirp(r.Context(), chirpID, userID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 그 사이 스케줄러가 먼저 게시한 경우
+			respondWithError(w, http.StatusConflict, "Error chirp is already published", err)
+			// code 409
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error publishing chirp in DB", fmt.Errorf("error publishing chirp in DB: %w", err))
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpAttachments(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp attachments in DB", fmt.Errorf("error getting chirp attachments in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// 임시저장, 예약 chirp를 바로 게시하고 언급, 답글 알림을 한 트랜잭션으로 만드는 함수
+func (cfg *apiConfig) publishChirp(ctx context.Context, chirpID, userID uuid.UUID) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	chirp, err := qtx.PublishChirp(ctx, database.PublishChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := notifyChirpPublished(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, tx.Commit()
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// 팔로우 관계(또는 요청) response용 구조체
+type fResBodySuccess struct {
+	FollowerID uuid.UUID  `json:"follower_id"`
+	FolloweeID uuid.UUID  `json:"followee_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	// "accepted" 또는 "pending"
+	Status string `json:"status"`
+}
+
+// db의 follow를 response용 구조체로 변환하는 함수
+func newFResBodySuccess(follow database.Follow) fResBodySuccess {
+	resBody := fResBodySuccess{
+		FollowerID: follow.FollowerID,
+		FolloweeID: follow.FolloweeID,
+		CreatedAt:  follow.CreatedAt,
+		Status:     "pending",
+	}
+	// accepted_at이 NULL이 아니면 승인된 팔로우
+	if follow.AcceptedAt.Valid {
+		resBody.AcceptedAt = &follow.AcceptedAt.Time
+		resBody.Status = "accepted"
+	}
+	return resBody
+}
+
+// /api/users/{userID}/follow path POST handler : 유저 팔로우
+// 비공개 계정이면 팔로우 요청만 생성되고 202, 아니면 바로 팔로우되고 200
+func (cfg *apiConfig) handlerFollowPOST(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "Error can't follow yourself", errors.New("error can't follow yourself"))
+		// code 400
+		return
+	}
+
+	// 팔로우할 유저가 존재하는지, 비공개 계정인지 확인
+	followee, err := cfg.ptrDB.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+
+	// 팔로우와 알림, webhook 전송 기록을 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	follow, err := qtx.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+		Accepted:   !followee.IsProtected,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating follow in DB", fmt.Errorf("error creating follow in DB: %w", err))
+		return
+	}
+
+	// 새로 만들어진 팔로우(요청)일 때만 알림 (이미 있던 행은 updated_at만 바뀐다)
+	if follow.CreatedAt.Equal(follow.UpdatedAt) {
+		kind := notificationFollow
+		if !follow.AcceptedAt.Valid {
+			kind = notificationFollowRequest
+		}
+		if err := createNotification(r.Context(), qtx, followeeID, kind, uuid.NullUUID{UUID: userID, Valid: true}, uuid.NullUUID{}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating notification in DB", fmt.Errorf("error creating notification in DB: %w", err))
+			return
+		}
+		if follow.AcceptedAt.Valid {
+			if err := enqueueFollowWebhook(r.Context(), qtx, follow, followee.IsProtected); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error creating webhook deliveries in DB", fmt.Errorf("error creating webhook deliveries in DB: %w", err))
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	resBody := newFResBodySuccess(follow)
+	if !follow.AcceptedAt.Valid {
+		respondWithJSON(w, http.StatusAccepted, resBody)
+		// code 202 : 상대가 승인해야 팔로우 완료
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/users/{userID}/follow path DELETE handler : 언팔로우 (또는 보낸 팔로우 요청 취소)
+func (cfg *apiConfig) handlerFollowDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if err := cfg.ptrDB.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting follow in DB", fmt.Errorf("error deleting follow in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/users/me/follow_requests path GET handler : 나에게 온 승인 대기중인 팔로우 요청 목록
+func (cfg *apiConfig) handlerFollowRequestsGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := cfg.ptrDB.GetFollowRequests(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting follow requests in DB", fmt.Errorf("error getting follow requests in DB: %w", err))
+		return
+	}
+
+	resBody := make([]fResBodySuccess, 0, len(requests))
+	for _, request := range requests {
+		resBody = append(resBody, newFResBodySuccess(request))
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/users/me/follow_requests/{userID}/approve path POST handler : 팔로우 요청 승인
+func (cfg *apiConfig) handlerFollowRequestsApprove(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followerID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// 승인과 알림, webhook 전송 기록을 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	follow, err := qtx.AcceptFollowRequest(r.Context(), database.AcceptFollowRequestParams{
+		FollowerID: followerID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find follow request", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error accepting follow request in DB", fmt.Errorf("error accepting follow request in DB: %w", err))
+		return
+	}
+
+	// 팔로우 요청은 비공개 계정에만 오므로 앱 endpoint에는 보내지 않는다
+	if err := notifyFollowAccepted(r.Context(), qtx, follow, true); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating follow notification in DB", fmt.Errorf("error creating follow notification in DB: %w", err))
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newFResBodySuccess(follow))
+}
+
+// /api/users/me/follow_requests/{userID} path DELETE handler : 팔로우 요청 거절
+func (cfg *apiConfig) handlerFollowRequestsDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	followerID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	deleted, err := cfg.ptrDB.DeleteFollowRequest(r.Context(), database.DeleteFollowRequestParams{
+		FollowerID: followerID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting follow request in DB", fmt.Errorf("error deleting follow request in DB: %w", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find follow request", errors.New("couldn't find follow request"))
+		// code 404
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/users/me/protected path PUT handler : 계정 비공개(protected) 설정 변경
+// 비공개를 해제하면 대기중이던 팔로우 요청은 모두 승인된다
+func (cfg *apiConfig) handlerUsersProtectedPUT(w http.ResponseWriter, r *http.Request) {
+	type protectedReqBody struct {
+		Protected bool `json:"protected"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody := protectedReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	user, err := qtx.UpdateUserProtected(r.Context(), database.UpdateUserProtectedParams{
+		IsProtected: reqBody.Protected,
+		ID:          userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating user in DB", fmt.Errorf("error updating user in DB: %w", err))
+		return
+	}
+
+	if !user.IsProtected {
+		accepted, err := qtx.AcceptAllFollowRequests(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error accepting follow requests in DB", fmt.Errorf("error accepting follow requests in DB: %w", err))
+			return
+		}
+		// 하나씩 승인할 때와 같이 승인된 팔로우마다 알림과 webhook 전송을 만든다
+		for _, follow := range accepted {
+			if err := notifyFollowAccepted(r.Context(), qtx, follow, false); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Error creating follow notification in DB", fmt.Errorf("error creating follow notification in DB: %w", err))
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newUResBodySuccess(user))
+	// code 200
+}
+
+// 팔로우 요청이 승인됐을 때 팔로우 당한 유저에게 팔로우 알림을 만들고 webhook 전송을 기록하는 함수 (승인한 트랜잭션의 q로 호출)
+func notifyFollowAccepted(ctx context.Context, q *database.Queries, follow database.Follow, followeeProtected bool) error {
+	if err := createNotification(ctx, q, follow.FolloweeID, notificationFollow, uuid.NullUUID{UUID: follow.FollowerID, Valid: true}, uuid.NullUUID{}); err != nil {
+		return err
+	}
+	return enqueueFollowWebhook(ctx, q, follow, followeeProtected)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+type jobResBody struct {
+	ID      uuid.UUID       `json:"id"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+	Status  string          `json:"status"`
+	// 지금까지 시도한 횟수
+	Attempts    int32 `json:"attempts"`
+	MaxAttempts int32 `json:"max_attempts"`
+	// pending일 때 다음 실행 시간
+	RunAt *time.Time `json:"run_at"`
+	// running일 때 다른 worker가 다시 가져갈 수 있게 되는 시간
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+func newJobResBody(job database.Job) jobResBody {
+	resBody := jobResBody{
+		ID:          job.ID,
+		Kind:        job.Kind,
+		Payload:     job.Payload,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LastError:   job.LastError.String,
+		CreatedAt:   job.CreatedAt,
+	}
+	if job.Status == jobStatusPending {
+		resBody.RunAt = &job.RunAt
+	}
+	if job.Status == jobStatusRunning && job.LockedUntil.Valid {
+		resBody.LockedUntil = &job.LockedUntil.Time
+	}
+	if job.FinishedAt.Valid {
+		resBody.FinishedAt = &job.FinishedAt.Time
+	}
+	return resBody
+}
+
+// path의 jobID를 parse하는 함수 (실패하면 400 response를 보내고 false 반환)
+func jobIDFromPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	jobID, err := uuid.Parse(r.PathValue("jobID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return uuid.Nil, false
+	}
+	return jobID, true
+}
+
+// /admin/jobs path GET handler : job 목록 (최근 순, ?status=, ?kind=로 거르기)
+func (cfg *apiConfig) handlerJobsGET(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetJobsParams{
+		Limit: limit,
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch status {
+		case jobStatusPending, jobStatusRunning, jobStatusSucceeded, jobStatusDead:
+		default:
+			respondWithError(w, http.StatusBadRequest, "Error invalid status query", fmt.Errorf("error invalid status query: %q", status))
+			// code 400
+			return
+		}
+		params.Status = sql.NullString{String: status, Valid: true}
+	}
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		params.Kind = sql.NullString{String: kind, Valid: true}
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	jobs, err := cfg.ptrDB.GetJobs(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting jobs in DB", fmt.Errorf("error getting jobs in DB: %w", err))
+		return
+	}
+
+	resBody := pageResBody[jobResBody]{
+		Items: make([]jobResBody, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		resBody.Items = append(resBody.Items, newJobResBody(job))
+	}
+
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 job 위치를 cursor로 전달
+	if len(jobs) == int(limit) {
+		last := jobs[len(jobs)-1]
+		resBody.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /admin/jobs/stats path GET handler : job 종류별, 상태별 개수
+// ex: {"link.preview": {"succeeded": 120, "dead": 2}}
+func (cfg *apiConfig) handlerJobStatsGET(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return
+	}
+
+	counts, err := cfg.ptrDB.GetJobCounts(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting job counts in DB", fmt.Errorf("error getting job counts in DB: %w", err))
+		return
+	}
+
+	resBody := map[string]map[string]int64{}
+	for _, count := range counts {
+		if resBody[count.Kind] == nil {
+			resBody[count.Kind] = map[string]int64{}
+		}
+		resBody[count.Kind][count.Status] = count.Count
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /admin/jobs/{jobID} path GET handler
+func (cfg *apiConfig) handlerJobsGETOne(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return
+	}
+
+	jobID, ok := jobIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := cfg.ptrDB.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find job", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting job in DB", fmt.Errorf("error getting job in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newJobResBody(job))
+}
+
+// /admin/jobs/{jobID}/retry path POST handler : dead job을 시도 횟수를 초기화해서 다시 대기열에 넣는다
+func (cfg *apiConfig) handlerJobsRetryPOST(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return
+	}
+
+	jobID, ok := jobIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := cfg.ptrDB.RetryDeadJob(r.Context(), jobID)
+	if err == nil {
+		respondWithJSON(w, http.StatusOK, newJobResBody(job))
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Error retrying job in DB", fmt.Errorf("error retrying job in DB: %w", err))
+		return
+	}
+
+	// 바뀐 row가 없음 ==> job이 없거나 dead가 아닌 경우
+	job, err = cfg.ptrDB.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find job", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting job in DB", fmt.Errorf("error getting job in DB: %w", err))
+		return
+	}
+	respondWithError(w, http.StatusConflict, "Error only dead jobs can be retried", fmt.Errorf("error job %s is %s", job.ID, job.Status))
+	// code 409
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// /api/chirps/{chirpID}/like path PUT handler : chirp 좋아요
+// 이미 좋아요한 chirp면 아무것도 바뀌지 않는다
+func (cfg *apiConfig) handlerLikePUT(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	// 볼 수 없는 chirp와 게시되지 않았거나 삭제된 chirp는 좋아요할 수 없다 (GET과 마찬가지로 404)
+	chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
+		return
+	}
+	if chirp.Status != chirpStatusPublished || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", errors.New("error chirp is not published or deleted"))
+		// code 404
+		return
+	}
+
+	// 좋아요와 작성자 알림, 실시간 event를 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	created, err := qtx.CreateLike(r.Context(), database.CreateLikeParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating like in DB", fmt.Errorf("error creating like in DB: %w", err))
+		return
+	}
+	if created > 0 {
+		if err := createNotification(r.Context(), qtx, chirp.UserID, notificationLike, viewerID, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating notification in DB", fmt.Errorf("error creating notification in DB: %w", err))
+			return
+		}
+		if err := recordStreamEvent(r.Context(), qtx, database.QueueStreamEventParams{
+			Type:     streamEventChirpLiked,
+			ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			AuthorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ActorID:  viewerID,
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating stream event in DB", fmt.Errorf("error creating stream event in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// /api/chirps/{chirpID}/like path DELETE handler : 좋아요 취소
+func (cfg *apiConfig) handlerLikeDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// 좋아요 취소와 실시간 event를 한 트랜잭션으로 처리
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	deleted, err := qtx.DeleteLike(r.Context(), database.DeleteLikeParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting like in DB", fmt.Errorf("error deleting like in DB: %w", err))
+		return
+	}
+	if deleted > 0 {
+		chirp, err := qtx.GetChirpByID(r.Context(), chirpID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
+			return
+		}
+		if err := recordStreamEvent(r.Context(), qtx, database.QueueStreamEventParams{
+			Type:     streamEventChirpUnliked,
+			ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			AuthorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ActorID:  uuid.NullUUID{UUID: userID, Valid: true},
+		}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating stream event in DB", fmt.Errorf("error creating stream event in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/blobstore"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/media"
+)
+
+const (
+	// 업로드 가능한 이미지 파일 최대 크기
+	maxMediaBytes = 10 << 20
+	// multipart header 등 파일 외 부분까지 포함한 request body 최대 크기
+	maxMediaRequestBytes = maxMediaBytes + 1<<20
+	// chirp 하나에 첨부할 수 있는 최대 media 수
+	maxChirpMedia = 4
+	// 파일은 바뀌지 않지만 chirp가 지워지거나 작성자가 비공개로 바뀌면 더는 보여주면 안 된다
+	// ==> 캐시는 해도 매번 ETag로 다시 확인하게 한다 (볼 수 있으면 본문 없이 304)
+	mediaCacheControl = "public, no-cache"
+	// 로그인한 유저만 볼 수 있는 media는 그 유저의 브라우저만 캐시한다 (공유 캐시, CDN에 남지 않도록)
+	mediaPrivateCacheControl = "private, no-cache"
+	// 업로드한 뒤 chirp에 첨부하지 않은 media를 보관하는 기간
+	unattachedMediaRetention = 24 * time.Hour
+	// 한번의 트랜잭션으로 지우는 최대 첨부되지 않은 media 수
+	unattachedMediaBatchSize = 100
+)
+
+type mediaResBody struct {
+	ID           uuid.UUID `json:"id"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func newMediaResBody(m database.Medium) mediaResBody {
+	return mediaResBody{
+		ID:           m.ID,
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+		URL:          "/media/" + m.ID.String(),
+		ThumbnailURL: "/media/" + m.ID.String() + "/thumbnail",
+	}
+}
+
+// BlobStore에 저장되는 원본, 썸네일 key
+func mediaKey(id uuid.UUID) string {
+	return "media/" + id.String()
+}
+
+func mediaThumbnailKey(id uuid.UUID) string {
+	return "media/" + id.String() + "_thumb"
+}
+
+// /api/media path POST handler : 이미지 업로드
+// multipart/form-data의 "file" 필드로 받은 이미지를 처리(메타데이터 제거, 썸네일 생성)해서 저장하고
+// chirp 작성 시 media_ids에 넣을 id를 반환
+func (cfg *apiConfig) handlerMediaPOST(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// 너무 큰 request body는 다 읽기 전에 중단
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaRequestBytes)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error request must be multipart/form-data", fmt.Errorf("error reading multipart request: %w", err))
+		// code 400
+		return
+	}
+
+	// 파일 전체를 메모리에 두지 않도록 "file" 필드를 찾을 때까지 part를 순서대로 읽는다
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			respondWithMediaReadError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		data, err = io.ReadAll(io.LimitReader(part, maxMediaBytes+1))
+		part.Close()
+		if err != nil {
+			respondWithMediaReadError(w, err)
+			return
+		}
+		break
+	}
+
+	if data == nil {
+		respondWithError(w, http.StatusBadRequest, "Error missing file field", errors.New("error missing file field"))
+		// code 400
+		return
+	}
+	if len(data) > maxMediaBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Error file is larger than %d bytes", maxMediaBytes), errors.New("error media too large"))
+		// code 413
+		return
+	}
+
+	// 클라이언트가 보낸 Content-Type이 아니라 파일 내용으로 형식 판별
+	processed, err := media.Process(data)
+	if err != nil {
+		if errors.Is(err, media.ErrUnsupportedType) {
+			respondWithError(w, http.StatusUnsupportedMediaType, "Error only jpeg, png and gif images are supported", err)
+			// code 415
+			return
+		}
+		if errors.Is(err, media.ErrTooManyPixels) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Error image dimensions are too large", err)
+			// code 413
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Error invalid image", err)
+		// code 400
+		return
+	}
+
+	id := uuid.New()
+	original, thumbnail := processed.Original, processed.Thumbnail
+
+	if err := cfg.blobStore.Put(r.Context(), mediaKey(id), bytes.NewReader(original.Data), int64(len(original.Data)), original.ContentType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing media", fmt.Errorf("error storing media: %w", err))
+		return
+	}
+	if err := cfg.blobStore.Put(r.Context(), mediaThumbnailKey(id), bytes.NewReader(thumbnail.Data), int64(len(thumbnail.Data)), thumbnail.ContentType); err != nil {
+		cfg.deleteMediaBlobs(id)
+		respondWithError(w, http.StatusInternalServerError, "Error storing media thumbnail", fmt.Errorf("error storing media thumbnail: %w", err))
+		return
+	}
+
+	m, err := cfg.ptrDB.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:                   id,
+		UserID:               userID,
+		ContentType:          original.ContentType,
+		SizeBytes:            int32(len(original.Data)),
+		Width:                int32(original.Width),
+		Height:               int32(original.Height),
+		ThumbnailContentType: thumbnail.ContentType,
+	})
+	if err != nil {
+		cfg.deleteMediaBlobs(id)
+		respondWithError(w, http.StatusInternalServerError, "Error creating media in DB", fmt.Errorf("error creating media in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newMediaResBody(m))
+}
+
+// multipart body를 읽다 난 에러가 크기 제한 때문이면 413, 아니면 400
+func respondWithMediaReadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Error file is larger than %d bytes", maxMediaBytes), err)
+		// code 413
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, "Error reading multipart request", fmt.Errorf("error reading multipart request: %w", err))
+	// code 400
+}
+
+// /media/{mediaID} path GET handler : 원본 이미지
+func (cfg *apiConfig) handlerMediaGET(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+// /media/{mediaID}/thumbnail path GET handler : 썸네일 이미지
+func (cfg *apiConfig) handlerMediaThumbnailGET(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+// media 파일을 BlobStore에서 읽어 캐시 header와 함께 보내는 함수
+// 첨부된 chirp를 보는 유저가 볼 수 있을 때만 보낸다 (팔로워 전용, 언급된 유저 전용 chirp의 이미지는 url만으로 볼 수 없다)
+// 아직 첨부되지 않은 media는 업로드한 유저만 볼 수 있다
+// 볼 수 없는 media는 chirp처럼 존재 여부가 드러나지 않도록 404
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	// 로그인 여부는 선택 (공개 chirp의 이미지는 로그인 없이 img 태그, CDN에서 그대로 쓸 수 있다)
+	viewerID, ok := cfg.viewerID(w, r)
+	if !ok {
+		return
+	}
+
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	m, err := cfg.ptrDB.GetMediaByID(r.Context(), mediaID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error finding media in DB", fmt.Errorf("error finding media in DB: %w", err))
+		// code 404
+		return
+	}
+
+	if !m.ChirpID.Valid {
+		if !viewerID.Valid || viewerID.UUID != m.UserID {
+			respondWithError(w, http.StatusNotFound, "Error finding media in DB", errors.New("error unattached media of another user"))
+			// code 404
+			return
+		}
+	} else {
+		chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+			ID:       m.ChirpID.UUID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "Error finding media in DB", fmt.Errorf("error finding visible chirp of media in DB: %w", err))
+				// code 404
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "Error finding chirp of media in DB", fmt.Errorf("error finding chirp of media in DB: %w", err))
+			return
+		}
+		// moderator가 지운 chirp는 tombstone으로 보이지만 첨부 media는 작성자 외에는 볼 수 없다
+		if chirp.DeletedAt.Valid && (!viewerID.Valid || viewerID.UUID != chirp.UserID) {
+			respondWithError(w, http.StatusNotFound, "Error finding media in DB", errors.New("error media of deleted chirp"))
+			// code 404
+			return
+		}
+	}
+
+	key, contentType, etag := mediaKey(m.ID), m.ContentType, `"`+m.ID.String()+`"`
+	if thumbnail {
+		key, contentType, etag = mediaThumbnailKey(m.ID), m.ThumbnailContentType, `"`+m.ID.String()+`-thumb"`
+	}
+
+	// 로그인 없이 볼 수 있었으면 누구나 볼 수 있는 media
+	cacheControl := mediaCacheControl
+	if viewerID.Valid {
+		cacheControl = mediaPrivateCacheControl
+	}
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Vary", "Authorization")
+	w.Header().Set("ETag", etag)
+
+	// 파일이 바뀌지 않으므로 ETag가 같으면 본문 없이 304
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		// code 304
+		return
+	}
+
+	body, err := cfg.blobStore.Get(r.Context(), key)
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		if errors.Is(err, blobstore.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Error finding media file", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error reading media file", fmt.Errorf("error reading media file: %w", err))
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	// 브라우저가 내용을 보고 다른 형식(html 등)으로 해석하지 않도록
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// response용 chirp들에 첨부된 media를 한번의 쿼리로 채우는 함수
+func (cfg *apiConfig) fillChirpMedia(ctx context.Context, resBody []cResBodySuccess) error {
+	if len(resBody) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(resBody))
+	for _, c := range resBody {
+		chirpIDs = append(chirpIDs, c.ID)
+	}
+
+	attached, err := cfg.ptrDB.GetMediaByChirpIDs(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	// 쿼리 결과가 position 순이므로 순서대로 append
+	mediaByChirpID := make(map[uuid.UUID][]mediaResBody)
+	for _, m := range attached {
+		mediaByChirpID[m.ChirpID.UUID] = append(mediaByChirpID[m.ChirpID.UUID], newMediaResBody(m))
+	}
+
+	for i := range resBody {
+		if ms, ok := mediaByChirpID[resBody[i].ID]; ok {
+			resBody[i].Media = ms
+		}
+	}
+
+	return nil
+}
+
+type deleteMediaBlobsJobPayload struct {
+	MediaIDs []uuid.UUID `json:"media_ids"`
+}
+
+// db에서 지운 media의 원본과 썸네일을 BlobStore에서 지우는 job (media를 지우는 트랜잭션에서 추가)
+// 저장소가 잠깐 응답하지 않아도 다시 시도하므로 파일이 남지 않는다
+var jobDeleteMediaBlobs = newJobKind("media.delete_blobs", 10, (*apiConfig).deleteMediaBlobsJob)
+
+func (cfg *apiConfig) deleteMediaBlobsJob(ctx context.Context, payload deleteMediaBlobsJobPayload) (jobCommit, error) {
+	// 없는 key를 지워도 에러가 아니므로 다시 시도할 때 처음부터 다시 지워도 된다
+	for _, id := range payload.MediaIDs {
+		for _, key := range []string{mediaKey(id), mediaThumbnailKey(id)} {
+			if err := cfg.blobStore.Delete(ctx, key); err != nil {
+				return nil, fmt.Errorf("error deleting media blob %s: %w", key, err)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// BlobStore에서 media 원본과 썸네일을 지우는 함수
+// db 작업이 이미 끝난 뒤 정리용으로 쓰이므로 실패해도 log만 남긴다
+func (cfg *apiConfig) deleteMediaBlobs(ids ...uuid.UUID) {
+	// request가 끝나 취소된 context로도 정리할 수 있도록 별도 context 사용
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, id := range ids {
+		for _, key := range []string{mediaKey(id), mediaThumbnailKey(id)} {
+			if err := cfg.blobStore.Delete(ctx, key); err != nil {
+				slog.Error("Error deleting media blob", "key", key, "error", err)
+			}
+		}
+	}
+}
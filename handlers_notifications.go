@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// notifications.type 값
+const (
+	notificationMention       = "mention"
+	notificationReply         = "reply"
+	notificationLike          = "like"
+	notificationFollow        = "follow"
+	notificationFollowRequest = "follow_request"
+	notificationChirpyRed     = "chirpy_red"
+)
+
+type notificationResBody struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	// 마지막으로 알림이 묶인 시간
+	UpdatedAt time.Time `json:"updated_at"`
+	Read      bool      `json:"read"`
+	// 가장 최근에 행동한 유저 최대 3명과 전체 유저 수
+	ActorIDs   []uuid.UUID `json:"actor_ids"`
+	ActorCount int64       `json:"actor_count"`
+	// ex: "5 people liked your chirp"
+	Summary string `json:"summary"`
+}
+
+// 알림 목록에 보여줄 문장
+func notificationSummary(kind string, actorCount int64) string {
+	who := "Someone"
+	if actorCount > 1 {
+		who = fmt.Sprintf("%d people", actorCount)
+	}
+
+	switch kind {
+	case notificationMention:
+		return who + " mentioned you"
+	case notificationReply:
+		return who + " replied to your chirp"
+	case notificationLike:
+		return who + " liked your chirp"
+	case notificationFollow:
+		return who + " followed you"
+	case notificationFollowRequest:
+		return who + " requested to follow you"
+	case notificationChirpyRed:
+		return "You've been upgraded to Chirpy Red"
+	}
+	return ""
+}
+
+// 알림을 만들거나 같은 그룹의 안읽은 알림에 묶는 함수
+// 같은 종류, 같은 chirp의 알림은 읽기 전까지 하나로 묶인다 (ex: 같은 chirp의 좋아요들)
+// 자기 자신의 행동이나 받는 유저가 차단한 유저의 행동은 알리지 않는다
+func createNotification(ctx context.Context, q *database.Queries, recipientID uuid.UUID, kind string, actorID, chirpID uuid.NullUUID) error {
+	if actorID.Valid && actorID.UUID == recipientID {
+		return nil
+	}
+
+	groupKey := kind
+	if chirpID.Valid {
+		groupKey += ":" + chirpID.UUID.String()
+	}
+
+	notification, err := q.UpsertNotification(ctx, database.UpsertNotificationParams{
+		UserID:   recipientID,
+		Type:     kind,
+		ChirpID:  chirpID,
+		GroupKey: groupKey,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 차단한 유저
+			return nil
+		}
+		return err
+	}
+
+	if actorID.Valid {
+		if err := q.AddNotificationActor(ctx, database.AddNotificationActorParams{
+			NotificationID: notification.ID,
+			ActorID:        actorID.UUID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 접속 중인 받는 유저의 stream으로도 보낸다
+	return recordStreamEvent(ctx, q, database.QueueStreamEventParams{
+		Type:           streamEventNotification,
+		ChirpID:        chirpID,
+		RecipientID:    uuid.NullUUID{UUID: recipientID, Valid: true},
+		NotificationID: uuid.NullUUID{UUID: notification.ID, Valid: true},
+	})
+}
+
+type chirpNotificationsJobPayload struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+}
+
+// 게시된 chirp의 언급, 답글 알림을 만드는 job
+// 언급된 유저가 많아도 게시 요청이 기다리지 않도록 커밋 후에 만든다
+var jobChirpNotifications = newJobKind("chirp.notifications", 0, (*apiConfig).createChirpNotificationsJob)
+
+// 알림은 DB 변경뿐이므로 모두 job 완료 표시와 같은 트랜잭션에서 만든다
+func (cfg *apiConfig) createChirpNotificationsJob(ctx context.Context, payload chirpNotificationsJobPayload) (jobCommit, error) {
+	return func(ctx context.Context, q *database.Queries) error {
+		chirp, err := q.GetChirpByID(ctx, payload.ChirpID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// job이 실행되기 전에 완전히 삭제됨
+				return nil
+			}
+			return err
+		}
+		// job이 실행되기 전에 삭제된 chirp는 알리지 않는다
+		if chirp.DeletedAt.Valid || chirp.Status != chirpStatusPublished {
+			return nil
+		}
+		return createChirpNotifications(ctx, q, chirp)
+	}, nil
+}
+
+// chirp가 게시될 때 언급된 유저들과 답글을 받은 chirp의 작성자에게 알리고 stream 구독자들과 webhook endpoint들에게 보내는 함수
+// 임시저장, 예약 chirp는 게시될 때 알린다 (chirp를 게시하는 트랜잭션의 q로 호출)
+// 알림은 job으로 커밋 후에 만든다
+func notifyChirpPublished(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := jobChirpNotifications.enqueue(ctx, q, chirpNotificationsJobPayload{ChirpID: chirp.ID}); err != nil {
+		return err
+	}
+
+	if err := enqueueChirpWebhook(ctx, q, webhookEventChirpCreated, chirp); err != nil {
+		return err
+	}
+
+	return recordStreamEvent(ctx, q, database.QueueStreamEventParams{
+		Type:     streamEventChirpCreated,
+		ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		AuthorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+	})
+}
+
+// 게시된 chirp에 언급된 유저들과 답글을 받은 chirp의 작성자에게 알림을 만드는 함수
+func createChirpNotifications(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	author := uuid.NullUUID{UUID: chirp.UserID, Valid: true}
+
+	mentioned, err := q.GetChirpMentionedUserIDs(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range mentioned {
+		if err := createNotification(ctx, q, userID, notificationMention, author, uuid.NullUUID{UUID: chirp.ID, Valid: true}); err != nil {
+			return err
+		}
+	}
+
+	if !chirp.InReplyToChirpID.Valid {
+		return nil
+	}
+	parent, err := q.GetChirpByID(ctx, chirp.InReplyToChirpID.UUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if parent.DeletedAt.Valid {
+		return nil
+	}
+	// 답글이 팔로워 전용 등으로 원래 chirp의 작성자에게 보이지 않으면 알리지 않는다
+	if _, err := q.GetVisibleChirpByID(ctx, database.GetVisibleChirpByIDParams{
+		ID:       chirp.ID,
+		ViewerID: uuid.NullUUID{UUID: parent.UserID, Valid: true},
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	// 답글들은 원래 chirp 기준으로 묶는다 ("3 people replied to your chirp")
+	return createNotification(ctx, q, parent.UserID, notificationReply, author, uuid.NullUUID{UUID: parent.ID, Valid: true})
+}
+
+// /api/notifications path GET handler : 내 알림 목록 (최근 순, ?unread=true면 안읽은 알림만, ?limit=&cursor= 페이지)
+func (cfg *apiConfig) handlerNotificationsGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: r.URL.Query().Get("unread") == "true",
+		Limit:      limit,
+	}
+	if cursor != nil {
+		params.BeforeUpdatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.ptrDB.GetNotifications(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting notifications in DB", fmt.Errorf("error getting notifications in DB: %w", err))
+		return
+	}
+
+	notificationIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		notificationIDs = append(notificationIDs, row.Notification.ID)
+	}
+	actors, err := cfg.ptrDB.GetRecentNotificationActors(r.Context(), notificationIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting notification actors in DB", fmt.Errorf("error getting notification actors in DB: %w", err))
+		return
+	}
+	// 쿼리 결과가 최근 순이므로 순서대로 append
+	actorsByID := make(map[uuid.UUID][]uuid.UUID)
+	for _, actor := range actors {
+		actorsByID[actor.NotificationID] = append(actorsByID[actor.NotificationID], actor.ActorID)
+	}
+
+	resBody := pageResBody[notificationResBody]{
+		Items: make([]notificationResBody, 0, len(rows)),
+	}
+	for _, row := range rows {
+		n := row.Notification
+		item := notificationResBody{
+			ID:         n.ID,
+			Type:       n.Type,
+			CreatedAt:  n.CreatedAt,
+			UpdatedAt:  n.UpdatedAt,
+			Read:       n.ReadAt.Valid,
+			ActorIDs:   actorsByID[n.ID],
+			ActorCount: row.ActorCount,
+			Summary:    notificationSummary(n.Type, row.ActorCount),
+		}
+		if item.ActorIDs == nil {
+			item.ActorIDs = []uuid.UUID{}
+		}
+		if n.ChirpID.Valid {
+			item.ChirpID = &n.ChirpID.UUID
+		}
+		resBody.Items = append(resBody.Items, item)
+	}
+
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 알림 위치를 cursor로 전달
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1].Notification
+		resBody.NextCursor = pageCursor{CreatedAt: last.UpdatedAt, ID: last.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/notifications/read path POST handler : 알림 읽음 표시
+// notification_ids가 주어지면 그 알림들만, 없으면 모든 알림을 읽음으로 표시
+func (cfg *apiConfig) handlerNotificationsReadPOST(w http.ResponseWriter, r *http.Request) {
+	type readReqBody struct {
+		NotificationIDs []uuid.UUID `json:"notification_ids"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// body는 선택이므로 비어있어도(io.EOF) 모두 읽음 표시
+	reqBody := readReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	var err error
+	if len(reqBody.NotificationIDs) > 0 {
+		_, err = cfg.ptrDB.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    reqBody.NotificationIDs,
+		})
+	} else {
+		_, err = cfg.ptrDB.MarkAllNotificationsRead(r.Context(), userID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error marking notifications read in DB", fmt.Errorf("error marking notifications read in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/notifications/unread_count path GET handler : 안읽은 알림 수 (묶인 알림은 하나로 센다)
+func (cfg *apiConfig) handlerNotificationsUnreadCountGET(w http.ResponseWriter, r *http.Request) {
+	type unreadCountResBody struct {
+		UnreadCount int64 `json:"unread_count"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := cfg.ptrDB.GetUnreadNotificationCount(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting unread notifications in DB", fmt.Errorf("error counting unread notifications in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, unreadCountResBody{UnreadCount: count})
+}
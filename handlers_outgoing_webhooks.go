@@ -0,0 +1,443 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	// 유저(또는 앱) 하나가 등록할 수 있는 최대 endpoint 수
+	webhookMaxEndpoints = 10
+	// endpoint url 최대 길이
+	webhookMaxURLLength = 2048
+)
+
+type webhookEndpointReqBody struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	// PUT에서만 사용 : true면 다시 켜고(자동으로 꺼진 endpoint 포함) false면 끈다
+	Enabled *bool `json:"enabled"`
+}
+
+type webhookEndpointResBody struct {
+	ID     uuid.UUID `json:"id"`
+	URL    string    `json:"url"`
+	Events []string  `json:"events"`
+	// 서명 키 : 등록할 때 한번만 보여준다
+	Secret              string     `json:"secret,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func newWebhookEndpointResBody(endpoint database.WebhookEndpoint) webhookEndpointResBody {
+	resBody := webhookEndpointResBody{
+		ID:                  endpoint.ID,
+		URL:                 endpoint.Url,
+		Events:              endpoint.Events,
+		Enabled:             !endpoint.DisabledAt.Valid,
+		DisabledReason:      endpoint.DisabledReason.String,
+		ConsecutiveFailures: endpoint.ConsecutiveFailures,
+		CreatedAt:           endpoint.CreatedAt,
+		UpdatedAt:           endpoint.UpdatedAt,
+	}
+	if endpoint.DisabledAt.Valid {
+		resBody.DisabledAt = &endpoint.DisabledAt.Time
+	}
+	return resBody
+}
+
+type webhookDeliveryResBody struct {
+	ID      uuid.UUID       `json:"id"`
+	EventID uuid.UUID       `json:"event_id"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Status  string          `json:"status"`
+	// 지금까지 시도한 횟수
+	Attempts int32 `json:"attempts"`
+	// pending일 때 다음 시도 시간
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus *int32     `json:"response_status"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func newWebhookDeliveryResBody(delivery database.WebhookDelivery) webhookDeliveryResBody {
+	resBody := webhookDeliveryResBody{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+		Status:    delivery.Status,
+		Attempts:  delivery.Attempts,
+		LastError: delivery.LastError.String,
+		CreatedAt: delivery.CreatedAt,
+	}
+	if delivery.Status == "pending" {
+		resBody.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.LastAttemptAt.Valid {
+		resBody.LastAttemptAt = &delivery.LastAttemptAt.Time
+	}
+	if delivery.ResponseStatus.Valid {
+		resBody.ResponseStatus = &delivery.ResponseStatus.Int32
+	}
+	return resBody
+}
+
+// webhook endpoint를 관리하는 주체를 확인하는 함수
+// /admin/webhooks 아래 path는 앱 endpoint로 관리자 키를 확인하고 (반환값 NULL)
+// /api/webhooks 아래 path는 로그인한 유저의 endpoint
+// 확인에 실패하면 에러 response를 보내고 false 반환
+func (cfg *apiConfig) webhookOwner(w http.ResponseWriter, r *http.Request) (uuid.NullUUID, bool) {
+	if !strings.HasPrefix(r.URL.Path, "/admin/") {
+		userID, ok := cfg.authenticate(w, r)
+		return uuid.NullUUID{UUID: userID, Valid: ok}, ok
+	}
+	return uuid.NullUUID{}, cfg.authenticateAdmin(w, r)
+}
+
+// endpoint url 확인 : 절대 url이고 https여야 한다 (PLATFORM=dev에서는 로컬 테스트를 위해 http도 허용)
+func (cfg *apiConfig) validateWebhookURL(rawURL string) error {
+	if len(rawURL) > webhookMaxURLLength {
+		return fmt.Errorf("url is longer than %d characters", webhookMaxURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return errors.New("url must be absolute")
+	}
+	if u.User != nil {
+		return errors.New("url must not contain credentials")
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && cfg.platform == "dev") {
+		return errors.New("url must use https")
+	}
+	return nil
+}
+
+// 구독할 event 목록 확인 : 알려진 event만, 중복 제거 후 정렬해서 반환
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, errors.New("at least one event is required")
+	}
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+		if !slices.Contains(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// path의 webhookID에 해당하는 endpoint를 가져오는 함수 (다른 유저나 앱의 endpoint면 404)
+// 실패하면 에러 response를 보내고 false 반환
+func (cfg *apiConfig) webhookEndpointFromPath(w http.ResponseWriter, r *http.Request, owner uuid.NullUUID) (database.WebhookEndpoint, bool) {
+	endpointID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return database.WebhookEndpoint{}, false
+	}
+
+	endpoint, err := cfg.ptrDB.GetWebhookEndpoint(r.Context(), database.GetWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: owner,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find webhook endpoint", err)
+			// code 404
+			return database.WebhookEndpoint{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting webhook endpoint in DB", fmt.Errorf("error getting webhook endpoint in DB: %w", err))
+		return database.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+// /api/webhooks, /admin/webhooks path POST handler : webhook endpoint 등록
+// 서명 키(secret)는 이 response에서만 보여준다
+func (cfg *apiConfig) handlerWebhookEndpointsPOST(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	reqBody := webhookEndpointReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	if reqBody.URL == nil {
+		respondWithError(w, http.StatusBadRequest, "Error url is required", errors.New("error url is required"))
+		// code 400
+		return
+	}
+	if err := cfg.validateWebhookURL(*reqBody.URL); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid webhook url", fmt.Errorf("error invalid webhook url: %w", err))
+		// code 400
+		return
+	}
+	events, err := normalizeWebhookEvents(reqBody.Events)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid webhook events", fmt.Errorf("error invalid webhook events: %w", err))
+		// code 400
+		return
+	}
+
+	count, err := cfg.ptrDB.CountWebhookEndpoints(r.Context(), owner)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error counting webhook endpoints in DB", fmt.Errorf("error counting webhook endpoints in DB: %w", err))
+		return
+	}
+	if count >= webhookMaxEndpoints {
+		respondWithError(w, http.StatusBadRequest, "Error too many webhook endpoints", fmt.Errorf("error too many webhook endpoints: max %d", webhookMaxEndpoints))
+		// code 400
+		return
+	}
+
+	secret, err := auth.MakeWebhookSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error making webhook secret", fmt.Errorf("error making webhook secret: %w", err))
+		return
+	}
+
+	endpoint, err := cfg.ptrDB.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID: owner,
+		Url:    *reqBody.URL,
+		Secret: secret,
+		Events: events,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating webhook endpoint in DB", fmt.Errorf("error creating webhook endpoint in DB: %w", err))
+		return
+	}
+
+	resBody := newWebhookEndpointResBody(endpoint)
+	resBody.Secret = endpoint.Secret
+	respondWithJSON(w, http.StatusCreated, resBody)
+}
+
+// /api/webhooks, /admin/webhooks path GET handler : 등록한 webhook endpoint 목록
+func (cfg *apiConfig) handlerWebhookEndpointsGET(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	endpoints, err := cfg.ptrDB.GetWebhookEndpoints(r.Context(), owner)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting webhook endpoints in DB", fmt.Errorf("error getting webhook endpoints in DB: %w", err))
+		return
+	}
+
+	resBody := make([]webhookEndpointResBody, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		resBody = append(resBody, newWebhookEndpointResBody(endpoint))
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
+
+// /api/webhooks/{webhookID}, /admin/webhooks/{webhookID} path PUT handler : url, events 변경, 켜고 끄기
+// 주어진 필드만 바꾼다
+func (cfg *apiConfig) handlerWebhookEndpointsPUT(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, ok := cfg.webhookEndpointFromPath(w, r, owner)
+	if !ok {
+		return
+	}
+
+	reqBody := webhookEndpointReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.UpdateWebhookEndpointParams{
+		Url:    endpoint.Url,
+		Events: endpoint.Events,
+		ID:     endpoint.ID,
+		UserID: owner,
+	}
+	if reqBody.URL != nil {
+		if err := cfg.validateWebhookURL(*reqBody.URL); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error invalid webhook url", fmt.Errorf("error invalid webhook url: %w", err))
+			// code 400
+			return
+		}
+		params.Url = *reqBody.URL
+	}
+	if reqBody.Events != nil {
+		events, err := normalizeWebhookEvents(reqBody.Events)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error invalid webhook events", fmt.Errorf("error invalid webhook events: %w", err))
+			// code 400
+			return
+		}
+		params.Events = events
+	}
+	if reqBody.Enabled != nil {
+		params.Enabled = sql.NullBool{Bool: *reqBody.Enabled, Valid: true}
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	updated, err := qtx.UpdateWebhookEndpoint(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// 동시에 온 삭제 요청이 먼저 처리된 경우
+			respondWithError(w, http.StatusNotFound, "Couldn't find webhook endpoint", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error updating webhook endpoint in DB", fmt.Errorf("error updating webhook endpoint in DB: %w", err))
+		return
+	}
+
+	// 꺼져 있는 동안 보내지 않고 남겨둔 전송들을 다시 보낸다
+	if endpoint.DisabledAt.Valid && !updated.DisabledAt.Valid {
+		deliveryIDs, err := qtx.GetPendingWebhookDeliveryIDs(r.Context(), updated.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting pending webhook deliveries in DB", fmt.Errorf("error getting pending webhook deliveries in DB: %w", err))
+			return
+		}
+		if err := enqueueWebhookDeliveryJobs(r.Context(), qtx, deliveryIDs); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error enqueueing webhook deliveries in DB", fmt.Errorf("error enqueueing webhook deliveries in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newWebhookEndpointResBody(updated))
+}
+
+// /api/webhooks/{webhookID}, /admin/webhooks/{webhookID} path DELETE handler : endpoint 삭제 (보내지 않은 전송과 전송 기록도 같이 삭제)
+func (cfg *apiConfig) handlerWebhookEndpointsDELETE(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	deleted, err := cfg.ptrDB.DeleteWebhookEndpoint(r.Context(), database.DeleteWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: owner,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting webhook endpoint in DB", fmt.Errorf("error deleting webhook endpoint in DB: %w", err))
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find webhook endpoint", errors.New("couldn't find webhook endpoint"))
+		// code 404
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// /api/webhooks/{webhookID}/deliveries, /admin/webhooks/{webhookID}/deliveries path GET handler
+// endpoint의 전송 기록 (최근 순, ?status=pending|delivered|failed, ?limit=&cursor= 페이지)
+func (cfg *apiConfig) handlerWebhookDeliveriesGET(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cfg.webhookOwner(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, ok := cfg.webhookEndpointFromPath(w, r, owner)
+	if !ok {
+		return
+	}
+
+	limit, cursor, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error invalid pagination query", fmt.Errorf("error invalid pagination query: %w", err))
+		// code 400
+		return
+	}
+
+	params := database.GetWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Limit:      limit,
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != "pending" && status != "delivered" && status != "failed" {
+			respondWithError(w, http.StatusBadRequest, "Error invalid status query", fmt.Errorf("error invalid status query: %q", status))
+			// code 400
+			return
+		}
+		params.Status = sql.NullString{String: status, Valid: true}
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	deliveries, err := cfg.ptrDB.GetWebhookDeliveries(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting webhook deliveries in DB", fmt.Errorf("error getting webhook deliveries in DB: %w", err))
+		return
+	}
+
+	resBody := pageResBody[webhookDeliveryResBody]{
+		Items: make([]webhookDeliveryResBody, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resBody.Items = append(resBody.Items, newWebhookDeliveryResBody(delivery))
+	}
+
+	// 페이지가 꽉 찼으면 다음 페이지가 있을 수 있으므로 마지막 전송 위치를 cursor로 전달
+	if len(deliveries) == int(limit) {
+		last := deliveries[len(deliveries)-1]
+		resBody.NextCursor = pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+}
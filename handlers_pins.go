@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	// 일반 유저가 고정할 수 있는 chirp 수
+	maxPinnedChirps = 1
+	// chirpy red 유저가 고정할 수 있는 chirp 수
+	maxPinnedChirpsRed = 3
+)
+
+// /api/chirps/{chirpID}/pin path PUT handler : 내 chirp를 프로필에 고정
+// 이미 고정된 chirp면 아무것도 바뀌지 않는다
+func (cfg *apiConfig) handlerPinPUT(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// chirpID에 해당하는 chirp가 있는지 확인하고 가져오기
+	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+		// code 404
+		return
+	}
+
+	// chirp의 작성자와 지금 고정하려는 유저가 동일 유저인지 확인
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Error can't pin other user's chirp", errors.New("error can't pin other user's chirp"))
+		// code 403
+		return
+	}
+
+	// 게시된 chirp만 고정 가능 (삭제된 chirp도 불가)
+	if chirp.Status != chirpStatusPublished || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusBadRequest, "Error can't pin unpublished or deleted chirp", errors.New("error can't pin unpublished or deleted chirp"))
+		// code 400
+		return
+	}
+
+	if !chirp.PinnedAt.Valid {
+		// 고정 개수 확인과 고정을 한 트랜잭션 안에서 처리
+		// 유저 행을 잠가두므로 동시에 여러 chirp를 고정해도 제한을 넘지 않는다
+		tx, err := cfg.db.BeginTx(r.Context(), nil)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+			return
+		}
+		defer tx.Rollback()
+		qtx := cfg.txQueries(tx)
+
+		user, err := qtx.GetUserByIDForUpdate(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+			return
+		}
+
+		pinnedCount, err := qtx.CountPinnedChirps(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error counting pinned chirps in DB", fmt.Errorf("error counting pinned chirps in DB: %w", err))
+			return
+		}
+
+		limit := maxPinnedChirps
+		if user.IsChirpyRed {
+			limit = maxPinnedChirpsRed
+		}
+		if pinnedCount >= int64(limit) {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Error can't pin more than %d chirps", limit), errors.New("error pinned chirp limit reached"))
+			// code 409
+			return
+		}
+
+		chirp, err = qtx.PinChirp(r.Context(), database.PinChirpParams{
+			ID:     chirpID,
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error pinning chirp in DB", fmt.Errorf("error pinning chirp in DB: %w", err))
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+			return
+		}
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// /api/chirps/{chirpID}/pin path DELETE handler : chirp 고정 해제
+func (cfg *apiConfig) handlerPinDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	// chirpID에 해당하는 chirp가 있는지 확인하고 가져오기
+	chirp, err := cfg.ptrDB.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+		// code 404
+		return
+	}
+
+	// chirp의 작성자와 지금 고정 해제하려는 유저가 동일 유저인지 확인
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Error can't unpin other user's chirp", errors.New("error can't unpin other user's chirp"))
+		// code 403
+		return
+	}
+
+	if _, err := cfg.ptrDB.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error unpinning chirp in DB", fmt.Errorf("error unpinning chirp in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// 작성자의 타임라인에서 그 작성자가 고정한 chirp들을 최근 고정 순으로 맨 앞에 두고
+// 나머지는 원래 순서를 유지하는 함수 (다른 유저가 고정한 chirp의 rechirp는 앞으로 오지 않는다)
+func pinnedFirst(timeline []cResBodySuccess, authorID uuid.UUID) []cResBodySuccess {
+	var pinned, rest []cResBodySuccess
+	for _, c := range timeline {
+		if c.PinnedAt != nil && c.UserID == authorID {
+			pinned = append(pinned, c)
+			continue
+		}
+		rest = append(rest, c)
+	}
+
+	sort.SliceStable(pinned, func(i, j int) bool { return pinned[i].PinnedAt.After(*pinned[j].PinnedAt) })
+
+	return append(pinned, rest...)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPinnedFirst(t *testing.T) {
+	author, other := uuid.New(), uuid.New()
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// pinned가 0보다 크면 그 분에 고정된 chirp
+	chirp := func(id, userID uuid.UUID, pinned int) cResBodySuccess {
+		c := cResBodySuccess{ID: id, UserID: userID}
+		if pinned > 0 {
+			at := testTime(pinned)
+			c.PinnedAt = &at
+		}
+		return c
+	}
+
+	cases := []struct {
+		name     string
+		input    []cResBodySuccess
+		expected []uuid.UUID
+	}{
+		{
+			name:     "empty",
+			expected: nil,
+		},
+		{
+			name:     "nothing pinned keeps order",
+			input:    []cResBodySuccess{chirp(a, author, 0), chirp(b, author, 0)},
+			expected: []uuid.UUID{a, b},
+		},
+		{
+			name:     "pinned moves to front",
+			input:    []cResBodySuccess{chirp(a, author, 0), chirp(b, author, 0), chirp(c, author, 5)},
+			expected: []uuid.UUID{c, a, b},
+		},
+		{
+			name:     "most recently pinned first",
+			input:    []cResBodySuccess{chirp(a, author, 1), chirp(b, author, 0), chirp(c, author, 9), chirp(d, author, 5)},
+			expected: []uuid.UUID{c, d, a, b},
+		},
+		{
+			// 작성자가 rechirp한 다른 유저의 고정된 chirp는 작성자의 고정 chirp가 아니다
+			name:     "pinned by another author stays in place",
+			input:    []cResBodySuccess{chirp(a, author, 0), chirp(b, other, 5), chirp(c, author, 3)},
+			expected: []uuid.UUID{c, a, b},
+		},
+	}
+
+	for _, tc := range cases {
+		var actual []uuid.UUID
+		for _, c := range pinnedFirst(tc.input, author) {
+			actual = append(actual, c.ID)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: pinnedFirst = %v, expecting %v", tc.name, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/chirptext"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 4
+	// 선택지 하나의 최대 글자 수
+	maxPollOptionLength = 25
+	// 투표는 게시 후 최대 7일까지 열어둘 수 있다
+	maxPollDuration = 7 * 24 * time.Hour
+)
+
+// POST /api/chirps request body의 poll 필드
+type pollReqBody struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type pollOptionResBody struct {
+	ID   uuid.UUID `json:"id"`
+	Text string    `json:"text"`
+	// 결과를 볼 수 있을 때만 채워진다
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+type pollResBody struct {
+	ClosesAt time.Time           `json:"closes_at"`
+	Closed   bool                `json:"closed"`
+	Options  []pollOptionResBody `json:"options"`
+	// 보는 유저가 투표했거나 투표가 마감되었으면 true (그 전에는 득표 수를 숨긴다)
+	ResultsVisible bool       `json:"results_visible"`
+	TotalVotes     *int64     `json:"total_votes,omitempty"`
+	VotedOptionID  *uuid.UUID `json:"voted_option_id,omitempty"`
+}
+
+// 새 chirp에 붙일 투표를 검증하고 NFC 정규화, 공백 정리한 선택지들을 반환하는 함수
+// 예약 chirp면 게시 시간(startsAt) 이후에 마감되어야 한다
+func validatePoll(poll pollReqBody, startsAt time.Time) ([]string, error) {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return nil, fmt.Errorf("poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	options := make([]string, 0, len(poll.Options))
+	seen := make(map[string]struct{}, len(poll.Options))
+	for _, option := range poll.Options {
+		option = strings.TrimSpace(chirptext.Normalize(option))
+		if option == "" {
+			return nil, errors.New("poll option can't be empty")
+		}
+		if chirptext.Length(option) > maxPollOptionLength {
+			return nil, fmt.Errorf("poll option can't be longer than %d characters", maxPollOptionLength)
+		}
+		if _, ok := seen[option]; ok {
+			return nil, errors.New("poll options must be unique")
+		}
+		seen[option] = struct{}{}
+		options = append(options, option)
+	}
+
+	if err := checkPollWindow(poll.ClosesAt, startsAt); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// 투표가 chirp 게시 시간(startsAt) 이후, 최대 기간 안에 마감되는지 확인하는 함수
+// 임시저장, 예약 chirp는 실제로 게시(또는 예약 시간 변경)할 때 다시 확인한다
+func checkPollWindow(closesAt, startsAt time.Time) error {
+	if !closesAt.After(startsAt) {
+		return errors.New("poll closes_at must be after the chirp is published")
+	}
+	if closesAt.Sub(startsAt) > maxPollDuration {
+		return fmt.Errorf("poll can't stay open longer than %s", maxPollDuration)
+	}
+	return nil
+}
+
+// /api/chirps/{chirpID}/poll/vote path POST handler : 투표
+// 유저당 한 표만 가능하고 한번 투표하면 바꿀 수 없다. 성공하면 결과가 보이는 chirp 반환
+func (cfg *apiConfig) handlerPollVotePOST(w http.ResponseWriter, r *http.Request) {
+	type voteReqBody struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	reqBody := voteReqBody{}
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
+		// code 400
+		return
+	}
+
+	// 볼 수 없는 chirp와 게시되지 않은 chirp에는 투표할 수 없다
+	chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp in DB", fmt.Errorf("error getting chirp in DB: %w", err))
+		return
+	}
+	if chirp.Status != chirpStatusPublished || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", errors.New("chirp is not published or deleted"))
+		// code 404
+		return
+	}
+
+	poll, err := cfg.ptrDB.GetPollByChirpID(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find poll", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting poll in DB", fmt.Errorf("error getting poll in DB: %w", err))
+		return
+	}
+
+	if _, err := cfg.ptrDB.GetPollOptionByID(r.Context(), database.GetPollOptionByIDParams{
+		ID:      reqBody.OptionID,
+		ChirpID: chirpID,
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Error invalid poll option", err)
+			// code 400
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting poll option in DB", fmt.Errorf("error getting poll option in DB: %w", err))
+		return
+	}
+
+	// 마감 확인과 중복 투표 확인은 쿼리 안에서 처리 ==> 동시에 여러번 요청해도 한 표만 저장된다
+	voted, err := cfg.ptrDB.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		UserID:   userID,
+		OptionID: reqBody.OptionID,
+		ChirpID:  chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating poll vote in DB", fmt.Errorf("error creating poll vote in DB: %w", err))
+		return
+	}
+	if voted == 0 {
+		if !poll.ClosesAt.After(time.Now()) {
+			respondWithError(w, http.StatusConflict, "Error poll is closed", errors.New("error poll is closed"))
+			// code 409
+			return
+		}
+		respondWithError(w, http.StatusConflict, "Error already voted", errors.New("error already voted"))
+		// code 409
+		return
+	}
+
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// response용 chirp들에 투표와 (볼 수 있으면) 득표 수를 한번의 쿼리로 채우는 함수
+func (cfg *apiConfig) fillChirpPolls(ctx context.Context, resBody []cResBodySuccess, viewerID uuid.NullUUID) error {
+	if len(resBody) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(resBody))
+	for _, c := range resBody {
+		chirpIDs = append(chirpIDs, c.ID)
+	}
+
+	results, err := cfg.ptrDB.GetPollResults(ctx, database.GetPollResultsParams{
+		ViewerID: viewerID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 쿼리 결과가 chirp, 선택지 순서대로이므로 순서대로 append
+	now := time.Now()
+	polls := make(map[uuid.UUID]*pollResBody)
+	votes := make(map[uuid.UUID][]int64)
+	for _, result := range results {
+		poll, ok := polls[result.ChirpID]
+		if !ok {
+			poll = &pollResBody{
+				ClosesAt: result.ClosesAt,
+				Closed:   !result.ClosesAt.After(now),
+				Options:  []pollOptionResBody{},
+			}
+			polls[result.ChirpID] = poll
+		}
+		poll.Options = append(poll.Options, pollOptionResBody{ID: result.OptionID, Text: result.Text})
+		votes[result.ChirpID] = append(votes[result.ChirpID], result.Votes)
+		if result.VotedByMe {
+			poll.VotedOptionID = &result.OptionID
+		}
+	}
+
+	for chirpID, poll := range polls {
+		poll.ResultsVisible = poll.Closed || poll.VotedOptionID != nil
+		if !poll.ResultsVisible {
+			continue
+		}
+		var total int64
+		for i := range poll.Options {
+			poll.Options[i].Votes = &votes[chirpID][i]
+			total += votes[chirpID][i]
+		}
+		poll.TotalVotes = &total
+	}
+
+	for i := range resBody {
+		resBody[i].Poll = polls[resBody[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePoll(t *testing.T) {
+	start := testTime(0)
+	closes := start.Add(24 * time.Hour)
+
+	// 조합형 "한" (초성, 중성, 종성 자모를 따로 쓴 것)과 완성형 "한"
+	decomposed := string([]rune{0x1112, 0x1161, 0x11ab})
+
+	cases := []struct {
+		name     string
+		poll     pollReqBody
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "valid options are trimmed",
+			poll:     pollReqBody{Options: []string{" yes ", "no"}, ClosesAt: closes},
+			expected: []string{"yes", "no"},
+		},
+		{
+			name:     "options are normalized",
+			poll:     pollReqBody{Options: []string{decomposed, "no"}, ClosesAt: closes},
+			expected: []string{"한", "no"},
+		},
+		{
+			name:    "too few options",
+			poll:    pollReqBody{Options: []string{"yes"}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			name:    "too many options",
+			poll:    pollReqBody{Options: []string{"a", "b", "c", "d", "e"}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			name:    "blank option",
+			poll:    pollReqBody{Options: []string{"yes", "  "}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			// 한글 25자는 75 byte지만 25글자로 센다
+			name:     "max length in characters",
+			poll:     pollReqBody{Options: []string{strings.Repeat("한", maxPollOptionLength), "no"}, ClosesAt: closes},
+			expected: []string{strings.Repeat("한", maxPollOptionLength), "no"},
+		},
+		{
+			name:    "option too long",
+			poll:    pollReqBody{Options: []string{strings.Repeat("a", maxPollOptionLength+1), "no"}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate options",
+			poll:    pollReqBody{Options: []string{"yes", " yes"}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			// 정규화 후에 같은 글자면 중복
+			name:    "duplicate after normalization",
+			poll:    pollReqBody{Options: []string{decomposed, "한"}, ClosesAt: closes},
+			wantErr: true,
+		},
+		{
+			name:    "closes at publish time",
+			poll:    pollReqBody{Options: []string{"yes", "no"}, ClosesAt: start},
+			wantErr: true,
+		},
+		{
+			name:    "closes before publish time",
+			poll:    pollReqBody{Options: []string{"yes", "no"}, ClosesAt: start.Add(-time.Minute)},
+			wantErr: true,
+		},
+		{
+			name:     "open for max duration",
+			poll:     pollReqBody{Options: []string{"yes", "no"}, ClosesAt: start.Add(maxPollDuration)},
+			expected: []string{"yes", "no"},
+		},
+		{
+			name:    "open longer than max duration",
+			poll:    pollReqBody{Options: []string{"yes", "no"}, ClosesAt: start.Add(maxPollDuration + time.Minute)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		actual, err := validatePoll(tc.poll, start)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: validatePoll returned %v, expecting error", tc.name, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: validatePoll error: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: validatePoll = %q, expecting %q", tc.name, actual, tc.expected)
+		}
+	}
+}
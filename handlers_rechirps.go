@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// /api/chirps/{chirpID}/rechirp path POST handler : chirp rechirp
+// 이미 rechirp한 chirp면 아무것도 바뀌지 않는다
+func (cfg *apiConfig) handlerRechirpPOST(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+
+	// 볼 수 없는 chirp는 GET과 마찬가지로 404
+	chirp, err := cfg.ptrDB.GetVisibleChirpByID(r.Context(), database.GetVisibleChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", fmt.Errorf("error finding a chirp in DB: %w", err))
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting a chirp in DB", fmt.Errorf("error getting a chirp in DB: %w", err))
+		return
+	}
+	// 삭제된 chirp는 rechirp할 수 없다
+	if chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Error finding a chirp in DB", errors.New("error chirp is deleted"))
+		// code 404
+		return
+	}
+
+	// 공개 계정의 게시된 public chirp만 rechirp 가능
+	// ==> 팔로워 전용 chirp가 rechirp를 통해 다른 유저들에게 퍼지는 것을 막는다
+	author, err := cfg.ptrDB.GetUserByID(r.Context(), chirp.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+	if chirp.Status != chirpStatusPublished || chirp.Visibility != visibilityPublic || author.IsProtected {
+		respondWithError(w, http.StatusForbidden, "Error can't rechirp non-public chirp", errors.New("error can't rechirp non-public chirp"))
+		// code 403
+		return
+	}
+
+	if _, err := cfg.ptrDB.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating rechirp in DB", fmt.Errorf("error creating rechirp in DB: %w", err))
+		return
+	}
+
+	// 바뀐 rechirp 수를 포함해서 chirp 반환
+	resBody := []cResBodySuccess{newCResBodySuccess(chirp)}
+	if err := cfg.fillChirpStats(r.Context(), resBody, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody[0])
+}
+
+// /api/chirps/{chirpID}/rechirp path DELETE handler : rechirp 취소
+func (cfg *apiConfig) handlerRechirpDELETE(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+		// code 400
+		return
+	}
+
+	if err := cfg.ptrDB.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error deleting rechirp in DB", fmt.Errorf("error deleting rechirp in DB: %w", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	// code 204
+}
+
+// chirp 목록과 rechirp 목록을 합쳐서 타임라인을 만드는 함수
+// 같은 chirp가 원본과 rechirp로(또는 여러 유저의 rechirp로) 여러번 나오지 않도록
+// chirp당 가장 최근 활동(작성 또는 rechirp) 하나만 남기고 그 시간 기준 asc로 정렬해서 반환
+func mergeTimeline(chirps []database.Chirp, rechirps []database.GetVisibleRechirpsRow) []cResBodySuccess {
+	var timeline []cResBodySuccess
+	// chirp id => timeline 안의 index
+	indexByID := make(map[uuid.UUID]int, len(chirps))
+
+	for _, chirp := range chirps {
+		if _, ok := indexByID[chirp.ID]; ok {
+			continue
+		}
+		indexByID[chirp.ID] = len(timeline)
+		timeline = append(timeline, newCResBodySuccess(chirp))
+	}
+
+	for _, rechirp := range rechirps {
+		entry := newCResBodySuccess(rechirp.Chirp)
+		rechirpedBy := rechirp.RechirpedBy
+		rechirpedAt := rechirp.RechirpedAt
+		entry.RechirpedBy = &rechirpedBy
+		entry.RechirpedAt = &rechirpedAt
+
+		i, ok := indexByID[rechirp.Chirp.ID]
+		if !ok {
+			indexByID[rechirp.Chirp.ID] = len(timeline)
+			timeline = append(timeline, entry)
+			continue
+		}
+		// 이미 있는 chirp면 더 최근 활동일 때만 rechirp 표시로 교체
+		if entry.activityAt().After(timeline[i].activityAt()) {
+			timeline[i] = entry
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].activityAt().Before(timeline[j].activityAt()) })
+
+	return timeline
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// 테스트용 기준 시간에서 minutes분 뒤
+func testTime(minutes int) time.Time {
+	return time.Date(2024, 1, 1, 0, minutes, 0, 0, time.UTC)
+}
+
+// created분에 작성되고 published분에 게시된 테스트용 chirp
+func testChirp(id uuid.UUID, created, published int) database.Chirp {
+	return database.Chirp{
+		ID:          id,
+		CreatedAt:   testTime(created),
+		UpdatedAt:   testTime(published),
+		UserID:      uuid.New(),
+		Status:      chirpStatusPublished,
+		PublishedAt: sql.NullTime{Time: testTime(published), Valid: true},
+	}
+}
+
+func TestMergeTimeline(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	alice, bob := uuid.New(), uuid.New()
+
+	rechirp := func(chirp database.Chirp, by uuid.UUID, at int) database.GetVisibleRechirpsRow {
+		return database.GetVisibleRechirpsRow{Chirp: chirp, RechirpedBy: by, RechirpedAt: testTime(at)}
+	}
+
+	// 타임라인 항목 : chirp id와 rechirp한 유저 (원본이면 uuid.Nil)
+	type entry struct {
+		id uuid.UUID
+		by uuid.UUID
+	}
+
+	cases := []struct {
+		name     string
+		chirps   []database.Chirp
+		rechirps []database.GetVisibleRechirpsRow
+		expected []entry
+	}{
+		{
+			name:     "empty",
+			expected: nil,
+		},
+		{
+			name:     "sorted by publish time",
+			chirps:   []database.Chirp{testChirp(a, 0, 10), testChirp(b, 5, 5)},
+			expected: []entry{{id: b}, {id: a}},
+		},
+		{
+			// 먼저 작성했지만 나중에 게시된 임시저장 chirp는 게시 시간 위치에 나온다
+			name:     "draft published later",
+			chirps:   []database.Chirp{testChirp(a, 0, 20), testChirp(b, 10, 10)},
+			expected: []entry{{id: b}, {id: a}},
+		},
+		{
+			name:     "duplicate chirp",
+			chirps:   []database.Chirp{testChirp(a, 0, 0), testChirp(a, 0, 0)},
+			expected: []entry{{id: a}},
+		},
+		{
+			name:     "rechirp after publish replaces original",
+			chirps:   []database.Chirp{testChirp(a, 0, 0), testChirp(b, 5, 5)},
+			rechirps: []database.GetVisibleRechirpsRow{rechirp(testChirp(a, 0, 0), alice, 10)},
+			expected: []entry{{id: b}, {id: a, by: alice}},
+		},
+		{
+			name:     "latest of several rechirps",
+			rechirps: []database.GetVisibleRechirpsRow{rechirp(testChirp(a, 0, 0), alice, 10), rechirp(testChirp(a, 0, 0), bob, 5)},
+			expected: []entry{{id: a, by: alice}},
+		},
+		{
+			// 게시 시간 기준으로 비교하므로 작성 후 게시 전에 한 rechirp(예약 게시 직전 등)는 원본을 대신하지 않는다
+			name:     "rechirp before publish keeps original",
+			chirps:   []database.Chirp{testChirp(a, 0, 20)},
+			rechirps: []database.GetVisibleRechirpsRow{rechirp(testChirp(a, 0, 20), alice, 10)},
+			expected: []entry{{id: a}},
+		},
+		{
+			name:     "rechirp of chirp not in list",
+			chirps:   []database.Chirp{testChirp(a, 0, 0)},
+			rechirps: []database.GetVisibleRechirpsRow{rechirp(testChirp(c, 0, 0), bob, 3)},
+			expected: []entry{{id: a}, {id: c, by: bob}},
+		},
+	}
+
+	for _, tc := range cases {
+		timeline := mergeTimeline(tc.chirps, tc.rechirps)
+
+		var actual []entry
+		for _, item := range timeline {
+			e := entry{id: item.ID}
+			if item.RechirpedBy != nil {
+				e.by = *item.RechirpedBy
+			}
+			actual = append(actual, e)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: mergeTimeline = %v, expecting %v", tc.name, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
+)
+
+const (
+	// 연결이 끊겼을 때 client(EventSource)가 다시 접속하기까지 기다리는 시간
+	streamRetry = 3 * time.Second
+	// 연결 유지용 주석을 보내는 주기 (이때 놓친 event가 없는지도 db에서 다시 확인)
+	streamHeartbeatInterval = 15 * time.Second
+	// 한번 보낼 때 client가 받아가기를 기다리는 최대 시간 (서버 WriteTimeout 대신 보낼 때마다 연장)
+	streamWriteTimeout = 10 * time.Second
+	// 한번의 쿼리로 읽는 최대 event 수
+	streamBatchSize = 100
+)
+
+// chirp_deleted event data
+type streamChirpDeletedData struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// notification event data : client는 /api/notifications로 내용을 가져온다
+type streamNotificationData struct {
+	ID      uuid.UUID  `json:"id"`
+	ChirpID *uuid.UUID `json:"chirp_id"`
+}
+
+// /api/stream path GET handler : 새 chirp, chirp 삭제, 알림을 Server-Sent Events로 실시간 전송
+// ?author_id=면 그 작성자의 chirp만, ?timeline=true면 (로그인 필요) 본인과 팔로우한 유저의 chirp만 보낸다
+// 알림은 로그인한 유저 본인의 것만 보낸다
+// 다시 접속할 때 Last-Event-ID header(또는 ?last_event_id=)가 있으면 그 다음 event부터 이어서 보낸다
+func (cfg *apiConfig) handlerStreamGET(w http.ResponseWriter, r *http.Request) {
+	// 로그인 여부는 선택 (비로그인이면 공개 chirp만)
+	viewerID, ok := cfg.viewerID(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.GetStreamEventsParams{
+		ViewerID: viewerID,
+		Timeline: r.URL.Query().Get("timeline") == "true",
+		Limit:    streamBatchSize,
+	}
+
+	if r.URL.Query().Has("author_id") {
+		authorID, err := uuid.Parse(r.URL.Query().Get("author_id"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Error parsing string to uuid", fmt.Errorf("error parsing string to uuid: %w", err))
+			// code 400
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+
+	if params.Timeline && !viewerID.Valid {
+		respondWithError(w, http.StatusUnauthorized, "Error timeline stream requires login", errors.New("error timeline stream requires login"))
+		// code 401
+		return
+	}
+
+	// EventSource는 재접속할 때 Last-Event-ID header를 자동으로 보낸다
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" {
+		afterID, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || afterID < 0 {
+			respondWithError(w, http.StatusBadRequest, "Error invalid Last-Event-ID", fmt.Errorf("error invalid Last-Event-ID: %q", lastEventID))
+			// code 400
+			return
+		}
+		params.AfterID = afterID
+	} else {
+		// 처음 접속하면 지금 이후의 event만
+		afterID, err := cfg.ptrDB.GetLatestStreamEventID(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting latest stream event in DB", fmt.Errorf("error getting latest stream event in DB: %w", err))
+			return
+		}
+		params.AfterID = afterID
+	}
+
+	// 첫 event를 읽기 전에 구독해야 그 사이에 커밋된 event를 놓치지 않는다
+	sub, unsubscribe := cfg.streamHub.subscribe(false)
+	defer unsubscribe()
+
+	// http.ResponseController로 버퍼에 쌓인 event를 바로 client에 보낸다
+	rc := http.NewResponseController(w)
+	// 서버의 ReadTimeout이 지나면 요청 context가 취소되므로 오래 열려있는 stream은 읽기 deadline을 없앤다
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error setting up stream", fmt.Errorf("error clearing read deadline: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx 등 reverse proxy가 응답을 버퍼링하지 않도록
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			return
+		}
+		if err := cfg.writeStreamEvents(r.Context(), w, &params); err != nil {
+			if r.Context().Err() == nil {
+				reqlog.Logger(r.Context()).Error("Error writing stream events", "error", err)
+			}
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-cfg.streamHub.closed:
+			// 서버 종료 중 : client는 retry 후 다른 서버 인스턴스로 다시 접속한다
+			return
+		case <-sub.wake:
+		case <-heartbeat.C:
+			// LISTEN 연결이 끊겨 깨우지 못한 경우에도 heartbeat마다 다시 확인
+			fmt.Fprint(w, ": ping\n\n")
+		}
+	}
+}
+
+// params.AfterID 이후에 구독자가 받을 event들을 모두 써서 보내고 params.AfterID를 마지막으로 보낸 event id로 옮기는 함수
+func (cfg *apiConfig) writeStreamEvents(ctx context.Context, w io.Writer, params *database.GetStreamEventsParams) error {
+	for {
+		events, err := cfg.ptrDB.GetStreamEvents(ctx, *params)
+		if err != nil {
+			return err
+		}
+
+		chirps, err := cfg.streamEventChirps(ctx, events, params.ViewerID)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			var data any
+			switch event.Type {
+			case streamEventChirpCreated:
+				chirp, ok := chirps[event.ChirpID.UUID]
+				if !ok {
+					params.AfterID = event.ID
+					continue
+				}
+				data = chirp
+			case streamEventChirpDeleted:
+				data = streamChirpDeletedData{ID: event.ChirpID.UUID}
+			case streamEventNotification:
+				notification := streamNotificationData{ID: event.NotificationID.UUID}
+				if event.ChirpID.Valid {
+					notification.ChirpID = &event.ChirpID.UUID
+				}
+				data = notification
+			}
+
+			if err := writeStreamEvent(w, event.ID, event.Type, data); err != nil {
+				return err
+			}
+			params.AfterID = event.ID
+		}
+
+		if len(events) < int(params.Limit) {
+			return nil
+		}
+	}
+}
+
+// chirp_created event들의 chirp를 보는 유저 기준 response 형태로 만들어 chirp id로 찾을 수 있게 반환하는 함수
+func (cfg *apiConfig) streamEventChirps(ctx context.Context, events []database.StreamEvent, viewerID uuid.NullUUID) (map[uuid.UUID]cResBodySuccess, error) {
+	chirpIDs := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		if event.Type == streamEventChirpCreated {
+			chirpIDs = append(chirpIDs, event.ChirpID.UUID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil, nil
+	}
+
+	chirps, err := cfg.ptrDB.GetChirpsByIDs(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody := make([]cResBodySuccess, 0, len(chirps))
+	for _, chirp := range chirps {
+		resBody = append(resBody, newCResBodySuccess(chirp))
+	}
+	// 그 사이 삭제된 chirp는 tombstone으로 채워진다
+	if err := cfg.fillChirpStats(ctx, resBody, viewerID); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]cResBodySuccess, len(resBody))
+	for _, c := range resBody {
+		byID[c.ID] = c
+	}
+	return byID, nil
+}
+
+// SSE 형식으로 event 하나를 쓰는 함수
+func writeStreamEvent(w io.Writer, id int64, event string, data any) error {
+	dat, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, dat)
+	return err
+}
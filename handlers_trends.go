@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// limit 쿼리가 없을 때 반환하는 hashtag, chirp 수
+const defaultTrendsLimit = 10
+
+type trendingHashtagResBody struct {
+	Tag         string  `json:"tag"`
+	Score       float64 `json:"score"`
+	ChirpCount  int32   `json:"chirp_count"`
+	AuthorCount int32   `json:"author_count"`
+}
+
+type trendsResBody struct {
+	Window   string                   `json:"window"`
+	Hashtags []trendingHashtagResBody `json:"hashtags"`
+	Chirps   []cResBodySuccess        `json:"chirps"`
+}
+
+// /api/trends path GET handler : 구간(?window=1h 또는 24h, 기본 1h)별 인기 hashtag와 chirp
+// 집계 worker(cfg.runTrendAggregator)가 저장해둔 점수만 읽는다
+func (cfg *apiConfig) handlerTrendsGET(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.viewerID(w, r)
+	if !ok {
+		return
+	}
+
+	window := trendWindows[0].name
+	if r.URL.Query().Has("window") {
+		window = r.URL.Query().Get("window")
+		valid := false
+		for _, tw := range trendWindows {
+			valid = valid || tw.name == window
+		}
+		if !valid {
+			respondWithError(w, http.StatusBadRequest, "Error invalid window: "+window, fmt.Errorf("error invalid window: %s", window))
+			// code 400
+			return
+		}
+	}
+
+	limit := defaultTrendsLimit
+	if r.URL.Query().Has("limit") {
+		parsed, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || parsed < 1 {
+			respondWithError(w, http.StatusBadRequest, "Error invalid limit", fmt.Errorf("error invalid limit: %s", r.URL.Query().Get("limit")))
+			// code 400
+			return
+		}
+		limit = min(parsed, trendLimit)
+	}
+
+	hashtags, err := cfg.ptrDB.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		TimeWindow: window,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting trending hashtags in DB", fmt.Errorf("error getting trending hashtags in DB: %w", err))
+		return
+	}
+
+	chirps, err := cfg.ptrDB.GetTrendingChirps(r.Context(), database.GetTrendingChirpsParams{
+		TimeWindow: window,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting trending chirps in DB", fmt.Errorf("error getting trending chirps in DB: %w", err))
+		return
+	}
+
+	resBody := trendsResBody{
+		Window:   window,
+		Hashtags: make([]trendingHashtagResBody, 0, len(hashtags)),
+		Chirps:   make([]cResBodySuccess, 0, len(chirps)),
+	}
+	for _, h := range hashtags {
+		resBody.Hashtags = append(resBody.Hashtags, trendingHashtagResBody{
+			Tag:         h.Tag,
+			Score:       h.Score,
+			ChirpCount:  h.ChirpCount,
+			AuthorCount: h.AuthorCount,
+		})
+	}
+	for _, c := range chirps {
+		resBody.Chirps = append(resBody.Chirps, newCResBodySuccess(c))
+	}
+
+	if err := cfg.fillChirpStats(r.Context(), resBody.Chirps, viewerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp stats in DB", fmt.Errorf("error getting chirp stats in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resBody)
+	// code 200
+}
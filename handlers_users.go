@@ -7,6 +7,7 @@ import (
 
 	"github.com/paokimsiwoong/chirpy/internal/auth"
 	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
 )
 
 // /api/users path POST handler : 유저 생성 및 db 저장
@@ -24,7 +25,7 @@ func (cfg *apiConfig) handlerUsersPOST(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 		code = 500
 		respondWithError(w, code, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
-		// respondWithError는 입력된 error를 log.Println으로 출력하고 입력된 msg를 json에 담아 response하는 함수
+		// respondWithError는 입력된 error를 요청 logger로 기록하고 입력된 msg(와 요청 id)를 json에 담아 response하는 함수
 		return
 	}
 
@@ -44,19 +45,13 @@ func (cfg *apiConfig) handlerUsersPOST(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		code = 500
 		respondWithError(w, code, "Error creating user in DB", fmt.Errorf("error creating user in DB: %w", err))
-		// respondWithError는 입력된 error를 log.Println으로 출력하고 입력된 msg를 json에 담아 response하는 함수
+		// respondWithError는 입력된 error를 요청 logger로 기록하고 입력된 msg(와 요청 id)를 json에 담아 response하는 함수
 		return
 	}
 
 	// json에 저장할 데이터들 구조체에 저장
-	resBody := uResBodySuccess{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
-		// @@@ hash는 절대 response로 반환하면 안된다 => 보안문제
-	}
+	resBody := newUResBodySuccess(user)
+	// @@@ hash는 절대 response로 반환하면 안된다 => 보안문제
 
 	// HTTP 201 Created는 http.StatusCreated
 	code = http.StatusCreated
@@ -81,6 +76,8 @@ func (cfg *apiConfig) handlerUsersPUT(w http.ResponseWriter, r *http.Request) {
 		// code 401
 		return
 	}
+	// 요청 로그에 로그인한 유저 기록
+	reqlog.SetUserID(r.Context(), userID)
 
 	// request body의 json 데이터를 담을 구조체
 	reqBody := uReqBody{}
@@ -113,14 +110,8 @@ func (cfg *apiConfig) handlerUsersPUT(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// json에 저장할 데이터들 구조체에 저장
-	resBody := uResBodySuccess{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
-		// @@@ hash는 절대 response로 반환하면 안된다 => 보안문제
-	}
+	resBody := newUResBodySuccess(user)
+	// @@@ hash는 절대 response로 반환하면 안된다 => 보안문제
 
 	respondWithJSON(w, http.StatusOK, resBody)
 	// code 200
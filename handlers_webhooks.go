@@ -5,18 +5,37 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
+)
+
+const (
+	// webhook timestamp와 서버 시간의 최대 차이 (이보다 오래된 요청은 다시 보낸 것으로 보고 거절)
+	polkaSignatureTolerance = 5 * time.Minute
+	// webhook body 최대 크기
+	polkaMaxBodyBytes = 1 << 20
 )
 
 // /api/polka/webhooks path POST handler : polka webhooks 처리
+// ApiKey header와 body의 HMAC-SHA256 서명(X-Polka-Timestamp, X-Polka-Signature)을 모두 확인한다
+// 키 교체 중에는 POLKA_KEY와 POLKA_KEY_PREVIOUS 둘 다 유효
+// 같은 event id는 한번만 처리하고 다시 오면 처리하지 않고 204
 func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Request) {
 	type pReqBody struct {
+		// polka가 event마다 붙이는 고유 id (재전송되어도 같다)
+		ID    string `json:"id"`
 		Event string `json:"event"`
 		Data  struct {
 			UserID string `json:"user_id"`
+			// 구독 event에서만 사용 (없으면 기본 plan, 한 달 기간)
+			Plan             string     `json:"plan"`
+			CurrentPeriodEnd *time.Time `json:"current_period_end"`
 		} `json:"data"`
 	}
 
@@ -27,22 +46,73 @@ func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if apiKey != cfg.polkaKey {
+	// 상수 시간 비교
+	if !auth.MatchAPIKey(apiKey, cfg.polkaKeys) {
 		respondWithError(w, http.StatusUnauthorized, "Error invalid api key", errors.New("error invalid api key"))
 		// code 401
 		return
 	}
 
+	// 서명은 받은 그대로의 body로 확인해야 하므로 decoding 전에 읽어둔다
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, polkaMaxBodyBytes))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error reading request body", fmt.Errorf("error reading request body: %w", err))
+		// code 400
+		return
+	}
+
+	if err := auth.VerifyWebhookSignature(r.Header, body, cfg.polkaKeys, time.Now(), polkaSignatureTolerance); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error invalid webhook signature", fmt.Errorf("error invalid webhook signature: %w", err))
+		// code 401
+		return
+	}
+
 	reqBody := pReqBody{}
 	// request body decoding
-	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+	if err := json.Unmarshal(body, &reqBody); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error decoding resquest body json", fmt.Errorf("error decoding resquest body json: %w", err))
 		// code 500
 		return
 	}
 
-	// "user.upgraded" 이벤트가 아닐 경우 204 처리
-	if reqBody.Event != "user.upgraded" {
+	if reqBody.ID == "" {
+		respondWithError(w, http.StatusBadRequest, "Error missing event id", errors.New("error missing event id"))
+		// code 400
+		return
+	}
+
+	// event id 기록과 구독 변경, 알림을 한 트랜잭션으로 처리
+	// 처리 중 에러가 나면 id도 기록되지 않으므로 polka가 다시 보내면 다시 처리된다
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting transaction", fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	// 같은 id의 요청이 동시에 오면 나중 요청은 먼저 요청이 커밋될 때까지 기다린 뒤 0을 받는다
+	created, err := qtx.CreatePolkaEvent(r.Context(), database.CreatePolkaEventParams{
+		ID:    reqBody.ID,
+		Event: reqBody.Event,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error recording webhook event in DB", fmt.Errorf("error recording webhook event in DB: %w", err))
+		return
+	}
+	if created == 0 {
+		// 이미 처리한 event
+		w.WriteHeader(http.StatusNoContent)
+		// code 204
+		return
+	}
+
+	// 구독 이벤트가 아닐 경우 204 처리
+	if !isSubscriptionEvent(reqBody.Event) {
+		if err := tx.Commit(); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+			return
+		}
 		w.WriteHeader(http.StatusNoContent)
 		// code 204
 		return
@@ -56,17 +126,47 @@ func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	_, err = cfg.ptrDB.UpdateUserMembership(r.Context(), userID)
+	// 같은 유저의 event들이 동시에 와도 순서대로 처리되도록 유저 행을 잠근다
+	user, err := qtx.GetUserByIDForUpdate(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) { // @@@ 해답의 errors.Is 활용해보기
 			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
 			// code 404
 			return
 		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting user in DB", fmt.Errorf("error getting user in DB: %w", err))
+		return
+	}
+
+	if err := applySubscriptionEvent(r.Context(), qtx, reqBody.Event, userID, reqBody.Data.Plan, reqBody.Data.CurrentPeriodEnd); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Error updating subscription in DB", fmt.Errorf("error updating subscription in DB: %w", err))
+			return
+		}
+		// 지금 구독 상태에 해당하지 않는 event는 다시 보내도 마찬가지이므로 기록만 하고 무시
+		reqlog.Logger(r.Context()).Warn("Ignoring polka event: no matching subscription", "event_id", reqBody.ID, "event", reqBody.Event, "user_id", userID)
+	}
+
+	// is_chirpy_red는 구독 상태에서 다시 계산
+	updated, err := qtx.SyncUserMemberships(r.Context(), []uuid.UUID{userID})
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error updating user in DB", fmt.Errorf("error updating user in DB: %w", err))
 		// code 500
 		return
 	}
+
+	// 새로 chirpy red가 됐을 때만 알림 (갱신 등으로 계속 chirpy red인 경우는 알리지 않는다)
+	if !user.IsChirpyRed && len(updated) > 0 && updated[0].IsChirpyRed {
+		if err := createNotification(r.Context(), qtx, userID, notificationChirpyRed, uuid.NullUUID{}, uuid.NullUUID{}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error creating notification in DB", fmt.Errorf("error creating notification in DB: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error committing transaction", fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
 	// @@@ 해답 에러처리
 	// if err != nil {
 	// 	if errors.Is(err, sql.ErrNoRows) {
@@ -0,0 +1,843 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
+)
+
+const (
+	// Authorization header 없이 접속한 client가 auth 메시지를 보내야 하는 시간
+	wsAuthTimeout = 10 * time.Second
+	// 서버가 ping을 보내는 주기와 pong을 기다리는 시간
+	wsPingInterval = 30 * time.Second
+	wsPingTimeout  = 10 * time.Second
+	// 메시지 하나를 보내는 최대 시간 (넘으면 느린 client로 보고 연결을 끊는다)
+	wsWriteTimeout = 10 * time.Second
+	// client가 보내는 메시지 최대 크기
+	wsMaxMessageBytes = 4096
+	// 연결 하나가 구독할 수 있는 최대 채널 수
+	wsMaxSubscriptions = 50
+	// 서버 인스턴스 하나의 최대 websocket 연결 수
+	wsMaxConnections = 10000
+	// 같은 대화에 입력 중 신호를 다시 보낼 수 있는 최소 간격과 받는 쪽에서 입력 중 표시를 유지하는 시간
+	wsTypingInterval = 2 * time.Second
+	wsTypingTTL      = 5 * time.Second
+	// 이 시간 동안 갱신되지 않은 접속 상태 session은 끊긴 것으로 본다 (ping 주기보다 충분히 길게)
+	presenceSessionTTL = 3 * wsPingInterval
+	// 재접속 backoff : 처음 기다리는 시간부터 실패할 때마다 wsReconnectMultiplier배씩 최대 wsReconnectMaxDelay까지
+	wsReconnectInitialDelay = time.Second
+	wsReconnectMaxDelay     = 30 * time.Second
+	wsReconnectMultiplier   = 2
+	// 연결이 너무 많거나 서버 문제로 끊을 때 다시 접속하기 전에 기다릴 시간 (jitter 포함)
+	wsRetryAfter = 10 * time.Second
+)
+
+// 구독 채널 종류 : "timeline", "thread:{chirpID}", "conversation:{conversationID}"
+const (
+	wsChannelTimeline     = "timeline"
+	wsChannelThread       = "thread"
+	wsChannelConversation = "conversation"
+)
+
+// 인증 실패로 연결을 끊을 때의 close code (4000번대는 application용) : client는 재접속하지 말고 다시 로그인해야 한다
+const wsStatusUnauthorized websocket.StatusCode = 4001
+
+// 인증에 쓴 JWT가 만료되면 websocket.StatusPolicyViolation(1008)으로 연결을 끊는다
+// client는 토큰을 갱신한 뒤 last_event_id로 다시 접속하면 이어서 받을 수 있다
+const wsTokenExpiredReason = "token expired"
+
+// client가 보내는 메시지
+type wsClientMessage struct {
+	// auth, subscribe, unsubscribe, typing, presence
+	Type string `json:"type"`
+	// auth : JWT와 재접속할 때 마지막으로 받은 event_id
+	Token       string `json:"token"`
+	LastEventID *int64 `json:"last_event_id"`
+	// subscribe, unsubscribe, typing
+	Channel string `json:"channel"`
+	// presence : online 또는 away
+	Status string `json:"status"`
+}
+
+// 재접속 방법 : 연결이 끊기면 retry_after_ms(있으면)만큼 기다리고
+// 그 뒤로는 initial_delay_ms부터 multiplier배씩 max_delay_ms까지 늘려가며 (jitter를 더해서) 다시 접속한다
+type wsReconnectHint struct {
+	RetryAfterMs   int64 `json:"retry_after_ms,omitempty"`
+	InitialDelayMs int64 `json:"initial_delay_ms"`
+	MaxDelayMs     int64 `json:"max_delay_ms"`
+	Multiplier     int   `json:"multiplier"`
+}
+
+type wsPresence struct {
+	UserID uuid.UUID `json:"user_id"`
+	// online, away, offline
+	Status string `json:"status"`
+}
+
+// 서버가 보내는 메시지 : type에 따라 쓰는 필드가 다르다
+// ready, subscribed, unsubscribed, chirp_created, chirp_deleted, chirp_liked, chirp_unliked,
+// message_created, typing, presence, reconnect, error
+type wsServerMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	// 저장된 event의 id : 재접속할 때 마지막으로 받은 값을 last_event_id로 보내면 이어서 받는다
+	EventID   int64            `json:"event_id,omitempty"`
+	UserID    *uuid.UUID       `json:"user_id,omitempty"`
+	Chirp     *cResBodySuccess `json:"chirp,omitempty"`
+	ChirpID   *uuid.UUID       `json:"chirp_id,omitempty"`
+	LikeCount *int64           `json:"like_count,omitempty"`
+	Message   *messageResBody  `json:"message,omitempty"`
+	Status    string           `json:"status,omitempty"`
+	// 대화 채널을 구독할 때 다른 참여자들의 접속 상태
+	Presence []wsPresence `json:"presence,omitempty"`
+	// 입력 중 표시를 유지할 시간
+	ExpiresInMs int64            `json:"expires_in_ms,omitempty"`
+	Reconnect   *wsReconnectHint `json:"reconnect,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// websocket 연결 하나의 상태
+// 메시지 쓰기와 구독 상태 변경은 run의 loop에서만 하므로 lock이 필요 없다
+type wsClient struct {
+	cfg  *apiConfig
+	conn *websocket.Conn
+	// 요청 id, 유저 id가 붙은 logger (연결이 끝난 뒤의 정리 작업에서도 사용)
+	logger    *slog.Logger
+	userID    uuid.UUID
+	sessionID uuid.UUID
+	// 인증에 쓴 JWT의 만료 시간 (이 시간이 지나면 연결을 끊는다)
+	expiresAt time.Time
+	// 구독 중인 채널들과 그 채널들의 event를 읽는 쿼리 인자 (AfterID가 마지막으로 보낸 event id)
+	channels map[string]struct{}
+	params   database.GetRealtimeEventsParams
+	threads  map[uuid.UUID]struct{}
+	// 구독 중인 대화 id -> 참여자 (접속 상태 신호를 거를 때 사용)
+	conversations map[uuid.UUID]map[uuid.UUID]struct{}
+	// 이 유저가 차단한 유저 (입력 중, 접속 상태를 보내지 않는다)
+	blocked map[uuid.UUID]struct{}
+	// 대화별 마지막으로 입력 중 신호를 보낸 시간
+	lastTyping map[uuid.UUID]time.Time
+}
+
+// /api/ws path GET handler : 실시간 timeline, thread, 대화 websocket
+// 브라우저 WebSocket은 header를 보낼 수 없으므로 Authorization header가 없으면
+// 연결 후 첫 메시지로 {"type":"auth","token":"...","last_event_id":...}를 보내야 한다
+// header로 인증하면 ?last_event_id=로 이어받을 수 있다
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	if _, websocketCount := cfg.streamHub.counts(); websocketCount >= wsMaxConnections {
+		w.Header().Set("Retry-After", strconv.Itoa(int(withJitter(wsRetryAfter).Seconds())+1))
+		respondWithError(w, http.StatusServiceUnavailable, "Error too many websocket connections", errors.New("error too many websocket connections"))
+		// code 503
+		return
+	}
+
+	var userID uuid.UUID
+	var tokenExpiresAt time.Time
+	authenticated := false
+	if r.Header.Get("Authorization") != "" {
+		// cfg.authenticate와 같지만 연결을 끊을 시간을 알기 위해 토큰 만료 시간도 받는다
+		tokenString, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Error parsing header", fmt.Errorf("error parsing header: %w", err))
+			// code 401
+			return
+		}
+		id, expiresAt, err := auth.ValidateJWTWithExpiry(tokenString, cfg.tokenSecret)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Error invalid token", fmt.Errorf("error invalid token: %w", err))
+			// code 401
+			return
+		}
+		reqlog.SetUserID(r.Context(), id)
+		userID, tokenExpiresAt, authenticated = id, expiresAt, true
+	}
+
+	var afterID *int64
+	if r.URL.Query().Has("last_event_id") {
+		id, err := strconv.ParseInt(r.URL.Query().Get("last_event_id"), 10, 64)
+		if err != nil || id < 0 {
+			respondWithError(w, http.StatusBadRequest, "Error invalid last_event_id", fmt.Errorf("error invalid last_event_id: %q", r.URL.Query().Get("last_event_id")))
+			// code 400
+			return
+		}
+		afterID = &id
+	}
+
+	// Accept는 실패하면 직접 에러 response를 보낸다
+	// 기본 설정으로 다른 origin의 브라우저 연결은 거절된다
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsMaxMessageBytes)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	if !authenticated {
+		msg := wsClientMessage{}
+		authCtx, authCancel := context.WithTimeout(ctx, wsAuthTimeout)
+		err := wsjson.Read(authCtx, conn, &msg)
+		authCancel()
+		if err != nil || msg.Type != "auth" {
+			conn.Close(wsStatusUnauthorized, "authentication required")
+			return
+		}
+		id, expiresAt, err := auth.ValidateJWTWithExpiry(msg.Token, cfg.tokenSecret)
+		if err != nil {
+			conn.Close(wsStatusUnauthorized, "invalid token")
+			return
+		}
+		userID, tokenExpiresAt = id, expiresAt
+		reqlog.SetUserID(ctx, userID)
+		if msg.LastEventID != nil {
+			afterID = msg.LastEventID
+		}
+	}
+
+	client := &wsClient{
+		cfg:           cfg,
+		conn:          conn,
+		logger:        reqlog.Logger(ctx),
+		userID:        userID,
+		expiresAt:     tokenExpiresAt,
+		sessionID:     uuid.New(),
+		channels:      make(map[string]struct{}),
+		threads:       make(map[uuid.UUID]struct{}),
+		conversations: make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		blocked:       make(map[uuid.UUID]struct{}),
+		lastTyping:    make(map[uuid.UUID]time.Time),
+	}
+	client.params = database.GetRealtimeEventsParams{
+		UserID: userID,
+		Limit:  streamBatchSize,
+	}
+
+	if err := client.run(ctx, cancel, afterID); err != nil && ctx.Err() == nil {
+		client.logger.Error("Error in websocket connection", "error", err)
+		// 서버 문제로 끊는 것이므로 잠시 후 다시 접속하도록 알린다
+		client.closeWithReconnect(websocket.StatusTryAgainLater, "internal error")
+	}
+}
+
+// 연결이 끊길 때까지 client 메시지, 새 event, 실시간 신호를 처리하는 함수
+func (c *wsClient) run(ctx context.Context, cancel context.CancelFunc, afterID *int64) error {
+	// 첫 event를 읽기 전에 구독해야 그 사이에 커밋된 event를 놓치지 않는다
+	sub, unsubscribe := c.cfg.streamHub.subscribe(true)
+	defer unsubscribe()
+
+	if afterID != nil {
+		c.params.AfterID = *afterID
+	} else {
+		latest, err := c.cfg.ptrDB.GetLatestStreamEventID(ctx)
+		if err != nil {
+			return err
+		}
+		c.params.AfterID = latest
+	}
+
+	if err := c.loadBlocked(ctx); err != nil {
+		return err
+	}
+
+	if err := c.setPresence(ctx, "online"); err != nil {
+		return err
+	}
+	defer c.endPresence()
+
+	if err := c.write(ctx, wsServerMessage{
+		Type:      "ready",
+		UserID:    &c.userID,
+		EventID:   c.params.AfterID,
+		Reconnect: newReconnectHint(0),
+	}); err != nil {
+		return nil
+	}
+
+	incoming := make(chan wsClientMessage)
+	go c.readLoop(ctx, cancel, incoming)
+	go c.pingLoop(ctx, cancel)
+
+	// 토큰이 만료되면 더 이상 인증된 연결로 볼 수 없으므로 끊는다
+	expired := time.NewTimer(time.Until(c.expiresAt))
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-expired.C:
+			c.conn.Close(websocket.StatusPolicyViolation, wsTokenExpiredReason)
+			return nil
+		case <-c.cfg.streamHub.closed:
+			// 서버 종료 중 : 다른 서버 인스턴스로 다시 접속하도록 알린다
+			c.closeWithReconnect(websocket.StatusGoingAway, "server shutting down")
+			return nil
+		case msg := <-incoming:
+			if err := c.handleMessage(ctx, msg); err != nil {
+				return err
+			}
+		case <-sub.wake:
+			if err := c.writeEvents(ctx); err != nil {
+				return err
+			}
+		case signal := <-sub.signals:
+			if err := c.handleSignal(ctx, signal); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 이 유저가 차단한 유저들을 다시 읽는 함수 (연결할 때와 차단 목록이 바뀌었다는 신호를 받을 때)
+func (c *wsClient) loadBlocked(ctx context.Context) error {
+	blocked, err := c.cfg.ptrDB.GetBlockedUserIDs(ctx, c.userID)
+	if err != nil {
+		return err
+	}
+	c.blocked = make(map[uuid.UUID]struct{}, len(blocked))
+	for _, id := range blocked {
+		c.blocked[id] = struct{}{}
+	}
+	return nil
+}
+
+// client 메시지를 읽어서 run loop로 넘기는 goroutine
+// 연결이 끊기거나 잘못된 메시지가 오면 ctx를 취소해 연결을 끝낸다
+func (c *wsClient) readLoop(ctx context.Context, cancel context.CancelFunc, incoming chan<- wsClientMessage) {
+	defer cancel()
+	for {
+		msg := wsClientMessage{}
+		if err := wsjson.Read(ctx, c.conn, &msg); err != nil {
+			return
+		}
+		select {
+		case incoming <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 주기적으로 ping을 보내 연결을 확인하고 접속 상태 session을 갱신하는 goroutine
+// pong이 오지 않으면 ctx를 취소해 연결을 끝낸다
+func (c *wsClient) pingLoop(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, pingCancel := context.WithTimeout(ctx, wsPingTimeout)
+		err := c.conn.Ping(pingCtx)
+		pingCancel()
+		if err != nil {
+			cancel()
+			return
+		}
+
+		if err := c.cfg.ptrDB.TouchPresenceSession(ctx, c.sessionID); err != nil && ctx.Err() == nil {
+			c.logger.Error("Error updating presence session", "error", err)
+		}
+	}
+}
+
+func (c *wsClient) write(ctx context.Context, msg wsServerMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, c.conn, msg)
+}
+
+// client 잘못으로 처리하지 못한 메시지는 연결을 끊지 않고 error 메시지로 알린다
+func (c *wsClient) writeError(ctx context.Context, channel, message string) error {
+	return c.write(ctx, wsServerMessage{
+		Type:    "error",
+		Channel: channel,
+		Error:   message,
+	})
+}
+
+// 재접속 hint를 보내고 연결을 끊는 함수
+func (c *wsClient) closeWithReconnect(code websocket.StatusCode, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+	c.write(ctx, wsServerMessage{
+		Type:      "reconnect",
+		Reconnect: newReconnectHint(withJitter(wsRetryAfter)),
+	})
+	c.conn.Close(code, reason)
+}
+
+func (c *wsClient) handleMessage(ctx context.Context, msg wsClientMessage) error {
+	switch msg.Type {
+	case "subscribe":
+		return c.subscribe(ctx, msg.Channel)
+	case "unsubscribe":
+		return c.unsubscribe(ctx, msg.Channel)
+	case "typing":
+		return c.typing(ctx, msg.Channel)
+	case "presence":
+		if msg.Status != "online" && msg.Status != "away" {
+			return c.writeError(ctx, "", "status must be online or away")
+		}
+		return c.setPresence(ctx, msg.Status)
+	case "auth":
+		return c.writeError(ctx, "", "already authenticated")
+	}
+	return c.writeError(ctx, "", fmt.Sprintf("unknown message type %q", msg.Type))
+}
+
+// 채널 이름을 종류와 id로 나누는 함수 (timeline은 id 없음)
+func parseWSChannel(channel string) (string, uuid.UUID, error) {
+	if channel == wsChannelTimeline {
+		return wsChannelTimeline, uuid.Nil, nil
+	}
+	kind, rawID, found := strings.Cut(channel, ":")
+	if !found || (kind != wsChannelThread && kind != wsChannelConversation) {
+		return "", uuid.Nil, fmt.Errorf("unknown channel %q", channel)
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return "", uuid.Nil, fmt.Errorf("invalid channel id %q", rawID)
+	}
+	return kind, id, nil
+}
+
+// 채널 구독 : thread는 볼 수 있는 chirp, 대화는 참여 중인 대화만 구독할 수 있다
+// 대화 채널은 구독할 때 다른 참여자들의 접속 상태도 함께 보낸다
+func (c *wsClient) subscribe(ctx context.Context, channel string) error {
+	kind, id, err := parseWSChannel(channel)
+	if err != nil {
+		return c.writeError(ctx, channel, err.Error())
+	}
+	if _, ok := c.channels[channel]; !ok && len(c.channels) >= wsMaxSubscriptions {
+		return c.writeError(ctx, channel, fmt.Sprintf("can't subscribe to more than %d channels", wsMaxSubscriptions))
+	}
+
+	resBody := wsServerMessage{
+		Type:    "subscribed",
+		Channel: channel,
+	}
+
+	switch kind {
+	case wsChannelTimeline:
+		c.params.Timeline = true
+	case wsChannelThread:
+		// 존재하지 않는 chirp와 볼 수 없는 chirp 모두 같은 에러
+		if _, err := c.cfg.ptrDB.GetVisibleChirpByID(ctx, database.GetVisibleChirpByIDParams{
+			ID:       id,
+			ViewerID: uuid.NullUUID{UUID: c.userID, Valid: true},
+		}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.writeError(ctx, channel, "channel not found")
+			}
+			return err
+		}
+		c.threads[id] = struct{}{}
+	case wsChannelConversation:
+		if _, err := c.cfg.ptrDB.GetConversationForParticipant(ctx, database.GetConversationForParticipantParams{
+			ID:     id,
+			UserID: c.userID,
+		}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.writeError(ctx, channel, "channel not found")
+			}
+			return err
+		}
+		participants, err := c.cfg.ptrDB.GetConversationParticipants(ctx, []uuid.UUID{id})
+		if err != nil {
+			return err
+		}
+		members := make(map[uuid.UUID]struct{}, len(participants))
+		others := make([]uuid.UUID, 0, len(participants))
+		for _, p := range participants {
+			members[p.UserID] = struct{}{}
+			if _, blocked := c.blocked[p.UserID]; p.UserID != c.userID && !blocked {
+				others = append(others, p.UserID)
+			}
+		}
+		c.conversations[id] = members
+
+		resBody.Presence, err = c.cfg.presenceOf(ctx, others)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.channels[channel] = struct{}{}
+	c.syncParams()
+	if err := c.write(ctx, resBody); err != nil {
+		return err
+	}
+	// 재접속한 경우 끊긴 동안 쌓인 이 채널의 event를 바로 보낸다
+	return c.writeEvents(ctx)
+}
+
+func (c *wsClient) unsubscribe(ctx context.Context, channel string) error {
+	kind, id, err := parseWSChannel(channel)
+	if err != nil {
+		return c.writeError(ctx, channel, err.Error())
+	}
+
+	switch kind {
+	case wsChannelTimeline:
+		c.params.Timeline = false
+	case wsChannelThread:
+		delete(c.threads, id)
+	case wsChannelConversation:
+		delete(c.conversations, id)
+	}
+	delete(c.channels, channel)
+	c.syncParams()
+
+	return c.write(ctx, wsServerMessage{
+		Type:    "unsubscribed",
+		Channel: channel,
+	})
+}
+
+// 참여하지 않게 된 대화 채널의 구독을 끝내고 client에 알리는 함수
+func (c *wsClient) leaveConversation(ctx context.Context, conversationID uuid.UUID) error {
+	channel := wsChannelConversation + ":" + conversationID.String()
+	delete(c.conversations, conversationID)
+	delete(c.channels, channel)
+	c.syncParams()
+
+	return c.write(ctx, wsServerMessage{
+		Type:    "unsubscribed",
+		Channel: channel,
+	})
+}
+
+// 구독 중인 thread, 대화 id를 event 쿼리 인자에 반영
+func (c *wsClient) syncParams() {
+	c.params.ThreadIds = make([]uuid.UUID, 0, len(c.threads))
+	for id := range c.threads {
+		c.params.ThreadIds = append(c.params.ThreadIds, id)
+	}
+	c.params.ConversationIds = make([]uuid.UUID, 0, len(c.conversations))
+	for id := range c.conversations {
+		c.params.ConversationIds = append(c.params.ConversationIds, id)
+	}
+}
+
+// 입력 중 신호 : 구독 중인 대화에만 보낼 수 있고 너무 자주 보내면 무시한다
+func (c *wsClient) typing(ctx context.Context, channel string) error {
+	kind, id, err := parseWSChannel(channel)
+	if err != nil || kind != wsChannelConversation {
+		return c.writeError(ctx, channel, "typing is only allowed in conversation channels")
+	}
+	if _, ok := c.conversations[id]; !ok {
+		return c.writeError(ctx, channel, "not subscribed to channel")
+	}
+
+	if time.Since(c.lastTyping[id]) < wsTypingInterval {
+		return nil
+	}
+	c.lastTyping[id] = time.Now()
+
+	return c.cfg.sendRealtimeSignal(ctx, realtimeSignal{
+		Type:           realtimeSignalTyping,
+		ConversationID: id,
+		UserID:         c.userID,
+	})
+}
+
+// 이 연결의 접속 상태를 바꾸고 다른 유저들에게 알리는 함수
+func (c *wsClient) setPresence(ctx context.Context, status string) error {
+	if err := c.cfg.ptrDB.UpsertPresenceSession(ctx, database.UpsertPresenceSessionParams{
+		ID:     c.sessionID,
+		UserID: c.userID,
+		Status: status,
+	}); err != nil {
+		return err
+	}
+	return c.broadcastPresence(ctx)
+}
+
+// 연결이 끊길 때 session을 지우고 (다른 연결이 없으면 offline으로) 알리는 함수
+// 요청 ctx는 이미 취소됐을 수 있으므로 별도의 ctx 사용
+func (c *wsClient) endPresence() {
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+
+	if err := c.cfg.ptrDB.DeletePresenceSession(ctx, c.sessionID); err != nil {
+		c.logger.Error("Error deleting presence session", "error", err)
+		return
+	}
+	if err := c.broadcastPresence(ctx); err != nil {
+		c.logger.Error("Error sending presence", "error", err)
+	}
+}
+
+// 유저의 모든 연결을 합친 접속 상태를 알리는 함수 (다른 탭에서 접속 중이면 offline이 아니다)
+func (c *wsClient) broadcastPresence(ctx context.Context) error {
+	presence, err := c.cfg.presenceOf(ctx, []uuid.UUID{c.userID})
+	if err != nil {
+		return err
+	}
+	return c.cfg.sendRealtimeSignal(ctx, realtimeSignal{
+		Type:   realtimeSignalPresence,
+		UserID: c.userID,
+		Status: presence[0].Status,
+	})
+}
+
+// 유저들의 접속 상태를 userIDs 순서대로 반환 (살아있는 session이 없으면 offline)
+func (cfg *apiConfig) presenceOf(ctx context.Context, userIDs []uuid.UUID) ([]wsPresence, error) {
+	rows, err := cfg.ptrDB.GetUsersPresence(ctx, database.GetUsersPresenceParams{
+		UserIds:   userIDs,
+		SeenAfter: time.Now().Add(-presenceSessionTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statusByID := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		statusByID[row.UserID] = row.Status
+	}
+
+	presence := make([]wsPresence, 0, len(userIDs))
+	for _, id := range userIDs {
+		status, ok := statusByID[id]
+		if !ok {
+			status = "offline"
+		}
+		presence = append(presence, wsPresence{UserID: id, Status: status})
+	}
+	return presence, nil
+}
+
+// 다른 서버 인스턴스를 포함해 어디선가 보낸 입력 중, 접속 상태 신호 중 이 연결이 받을 것만 보내는 함수
+func (c *wsClient) handleSignal(ctx context.Context, signal realtimeSignal) error {
+	// 다른 연결(다른 탭, 다른 서버 인스턴스의 요청)에서 차단 목록을 바꿨으면 다시 읽는다
+	if signal.Type == realtimeSignalBlocks {
+		if signal.UserID != c.userID {
+			return nil
+		}
+		return c.loadBlocked(ctx)
+	}
+	if signal.UserID == c.userID {
+		return nil
+	}
+	if _, blocked := c.blocked[signal.UserID]; blocked {
+		return nil
+	}
+
+	switch signal.Type {
+	case realtimeSignalTyping:
+		if _, ok := c.conversations[signal.ConversationID]; !ok {
+			return nil
+		}
+		return c.write(ctx, wsServerMessage{
+			Type:        "typing",
+			Channel:     wsChannelConversation + ":" + signal.ConversationID.String(),
+			UserID:      &signal.UserID,
+			ExpiresInMs: wsTypingTTL.Milliseconds(),
+		})
+	case realtimeSignalPresence:
+		// 그 유저가 참여한 구독 중인 대화마다 보낸다
+		for conversationID, members := range c.conversations {
+			if _, ok := members[signal.UserID]; !ok {
+				continue
+			}
+			if err := c.write(ctx, wsServerMessage{
+				Type:    "presence",
+				Channel: wsChannelConversation + ":" + conversationID.String(),
+				UserID:  &signal.UserID,
+				Status:  signal.Status,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// c.params.AfterID 이후에 구독 중인 채널의 event들을 모두 보내는 함수
+// 한 event가 여러 채널에 해당하면 (ex: timeline과 thread) 채널마다 보낸다
+func (c *wsClient) writeEvents(ctx context.Context) error {
+	if len(c.channels) == 0 {
+		return nil
+	}
+
+	viewerID := uuid.NullUUID{UUID: c.userID, Valid: true}
+
+	for {
+		rows, err := c.cfg.ptrDB.GetRealtimeEvents(ctx, c.params)
+		if err != nil {
+			return err
+		}
+
+		events := make([]database.StreamEvent, 0, len(rows))
+		likedChirpIDs := []uuid.UUID{}
+		conversationIDs := []uuid.UUID{}
+		for _, row := range rows {
+			events = append(events, row.StreamEvent)
+			switch row.StreamEvent.Type {
+			case streamEventChirpLiked, streamEventChirpUnliked:
+				likedChirpIDs = append(likedChirpIDs, row.StreamEvent.ChirpID.UUID)
+			case streamEventMessageCreated:
+				conversationIDs = append(conversationIDs, row.StreamEvent.ConversationID.UUID)
+			}
+		}
+
+		chirps, err := c.cfg.streamEventChirps(ctx, events, viewerID)
+		if err != nil {
+			return err
+		}
+
+		likeCounts := make(map[uuid.UUID]int64)
+		if len(likedChirpIDs) > 0 {
+			stats, err := c.cfg.ptrDB.GetChirpStats(ctx, database.GetChirpStatsParams{
+				ViewerID: viewerID,
+				ChirpIds: likedChirpIDs,
+			})
+			if err != nil {
+				return err
+			}
+			for _, stat := range stats {
+				likeCounts[stat.ID] = stat.LikeCount
+			}
+		}
+
+		participants := make(map[uuid.UUID][]database.ConversationParticipant)
+		if len(conversationIDs) > 0 {
+			ps, err := c.cfg.ptrDB.GetConversationParticipants(ctx, conversationIDs)
+			if err != nil {
+				return err
+			}
+			for _, p := range ps {
+				participants[p.ConversationID] = append(participants[p.ConversationID], p)
+			}
+			// 구독할 때 읽은 참여자 목록을 갱신한다 (접속 상태 신호를 거를 때 사용)
+			for conversationID, ps := range participants {
+				if _, ok := c.conversations[conversationID]; !ok {
+					continue
+				}
+				members := make(map[uuid.UUID]struct{}, len(ps))
+				for _, p := range ps {
+					members[p.UserID] = struct{}{}
+				}
+				c.conversations[conversationID] = members
+			}
+		}
+
+		for _, row := range rows {
+			event := row.StreamEvent
+			msg := wsServerMessage{
+				Type:    event.Type,
+				EventID: event.ID,
+			}
+
+			switch event.Type {
+			case streamEventChirpCreated:
+				chirp, ok := chirps[event.ChirpID.UUID]
+				if !ok {
+					c.params.AfterID = event.ID
+					continue
+				}
+				msg.Chirp = &chirp
+			case streamEventChirpDeleted:
+				msg.ChirpID = &event.ChirpID.UUID
+			case streamEventChirpLiked, streamEventChirpUnliked:
+				likeCount := likeCounts[event.ChirpID.UUID]
+				msg.ChirpID = &event.ChirpID.UUID
+				msg.UserID = &event.ActorID.UUID
+				msg.LikeCount = &likeCount
+			case streamEventMessageCreated:
+				// 구독한 뒤 대화에서 빠졌으면 보내지 않고 구독을 끝낸다
+				conversationID := event.ConversationID.UUID
+				if _, ok := c.conversations[conversationID]; ok {
+					if _, member := c.conversations[conversationID][c.userID]; !member {
+						c.params.AfterID = event.ID
+						if err := c.leaveConversation(ctx, conversationID); err != nil {
+							return err
+						}
+						continue
+					}
+				}
+				message, err := c.cfg.ptrDB.GetMessageByID(ctx, event.MessageID.UUID)
+				if err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						c.params.AfterID = event.ID
+						continue
+					}
+					return err
+				}
+				resBody := newMessageResBody(message, participants[message.ConversationID])
+				msg.Message = &resBody
+			}
+
+			for _, channel := range c.eventChannels(row) {
+				msg.Channel = channel
+				if err := c.write(ctx, msg); err != nil {
+					return err
+				}
+			}
+			c.params.AfterID = event.ID
+		}
+
+		if len(rows) < int(c.params.Limit) {
+			return nil
+		}
+	}
+}
+
+// event가 해당하는 구독 중인 채널들
+func (c *wsClient) eventChannels(row database.GetRealtimeEventsRow) []string {
+	event := row.StreamEvent
+	if event.Type == streamEventMessageCreated {
+		return []string{wsChannelConversation + ":" + event.ConversationID.UUID.String()}
+	}
+
+	channels := []string{}
+	if row.InTimeline {
+		channels = append(channels, wsChannelTimeline)
+	}
+	if _, ok := c.threads[event.ChirpID.UUID]; ok {
+		channels = append(channels, wsChannelThread+":"+event.ChirpID.UUID.String())
+	}
+	if row.InReplyToChirpID.Valid {
+		if _, ok := c.threads[row.InReplyToChirpID.UUID]; ok {
+			channels = append(channels, wsChannelThread+":"+row.InReplyToChirpID.UUID.String())
+		}
+	}
+	return channels
+}
+
+// retryAfter만큼 기다린 뒤 기본 backoff로 재접속하라는 hint
+func newReconnectHint(retryAfter time.Duration) *wsReconnectHint {
+	return &wsReconnectHint{
+		RetryAfterMs:   retryAfter.Milliseconds(),
+		InitialDelayMs: wsReconnectInitialDelay.Milliseconds(),
+		MaxDelayMs:     wsReconnectMaxDelay.Milliseconds(),
+		Multiplier:     wsReconnectMultiplier,
+	}
+}
+
+// 여러 client가 동시에 재접속하지 않도록 d/2 ~ 3d/2 사이의 임의의 시간을 반환
+func withJitter(d time.Duration) time.Duration {
+	return d/2 + rand.N(d)
+}
@@ -60,6 +60,13 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 
 // JWT 검증함수
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	id, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return id, err
+}
+
+// JWT를 검증하고 유저 id와 함께 토큰 만료 시간도 반환하는 함수
+// websocket처럼 연결이 토큰 만료 뒤에도 이어지는 경우 만료 시간에 연결을 끊는 데 사용
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	// MakeJWT 함수에서 사용한 jwt.Claims 구현 타입을 그대로 사용
 	claims := jwt.RegisteredClaims{}
 	// ??? jwt.NewWithClaims로 생성된 token은 Claims 필드에 함수 인자로 제공된 claim이 저장되고
@@ -82,13 +89,13 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	// 3번째 인자 keyFunc는 tokenSecret 처리에 쓰이는 함수로
 	// 그냥 원본 그대로 사용시에는 함수 시그니처 만족하면서 []byte(tokenSecret) 반환하는 함수를 인자로 입력하면 된다.
 	if err != nil { // 토큰이 invalid하거나 expired일 경우 err != nil
-		return uuid.UUID{}, fmt.Errorf("error token is invalid or expired: %w", err)
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("error token is invalid or expired: %w", err)
 		// @@@ uuid.Nil 사용 가능
 	}
 
 	idString, err := token.Claims.GetSubject()
 	if err != nil { // 토큰이 invalid하거나 expired일 경우 err != nil
-		return uuid.UUID{}, fmt.Errorf("error getting string uuid: %w", err)
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("error getting string uuid: %w", err)
 	}
 	// jwt.Claims 인터페이스 구현 조건에는 GetSubject() (string, error) 가 존재
 
@@ -97,19 +104,28 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	// @@@ 부정토큰(토큰 정규발급자가 아닌 자가 위조한 토큰)을 걸러내기
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, time.Time{}, err
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return uuid.Nil, time.Time{}, errors.New("invalid issuer")
 	}
 	// @@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
 
 	id, err := uuid.Parse(idString)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("error parsing string uuid: %w", err)
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("error parsing string uuid: %w", err)
+	}
+
+	// MakeJWT로 만든 토큰은 항상 만료 시간이 있다
+	expiresAt, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("error getting expiration time: %w", err)
+	}
+	if expiresAt == nil {
+		return uuid.Nil, time.Time{}, errors.New("token has no expiration time")
 	}
 
-	return id, nil
+	return id, expiresAt.Time, nil
 }
 
 // Authorization header에 들어있는 인증 정보에서 tokenString만 추출해서 반환하는 함수
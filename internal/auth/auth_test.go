@@ -176,6 +176,42 @@ func TestJWT(t *testing.T) {
 	}
 }
 
+func TestValidateJWTWithExpiry(t *testing.T) {
+	userID := uuid.New()
+	tokenSecret := "testSceret"
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+	}{
+		{name: "one minute", expiresIn: time.Minute},
+		{name: "one hour", expiresIn: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			tokenString, err := MakeJWT(userID, tokenSecret, tt.expiresIn)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+
+			id, expiresAt, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+			if err != nil {
+				t.Fatalf("ValidateJWTWithExpiry() error = %v", err)
+			}
+			if id != userID {
+				t.Errorf("ValidateJWTWithExpiry() id = %v, want %v", id, userID)
+			}
+			// JWT의 시간은 초 단위로 저장된다
+			want := before.Add(tt.expiresIn).Truncate(time.Second)
+			if expiresAt.Before(want) || expiresAt.After(want.Add(time.Second)) {
+				t.Errorf("ValidateJWTWithExpiry() expiresAt = %v, want %v", expiresAt, want)
+			}
+		})
+	}
+}
+
 // @@@ 해답 GetBearerToken 테스트 함수
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// webhook을 보낸 시간 (unix seconds)
+	PolkaTimestampHeader = "X-Polka-Timestamp"
+	// "v1=<hex>" 형태의 서명. 보내는 쪽이 키를 교체하는 중이면 "v1=<hex>,v1=<hex>"처럼 여러개 올 수 있다
+	PolkaSignatureHeader = "X-Polka-Signature"
+	// 서명 방식 버전
+	signatureVersion = "v1"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrTimestampTooOld  = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+	ErrNoActiveKeys     = errors.New("no active webhook keys")
+)
+
+// 두 키를 상수 시간에 비교하는 함수 (== 비교는 앞에서부터 다른 글자가 나오면 바로 끝나서 비교 시간으로 키를 추측할 수 있다)
+// 키 교체 중에는 여러 키가 유효하므로 모든 키와 비교한다
+func MatchAPIKey(apiKey string, activeKeys []string) bool {
+	matched := 0
+	for _, key := range activeKeys {
+		if key == "" {
+			continue
+		}
+		matched |= subtle.ConstantTimeCompare([]byte(apiKey), []byte(key))
+	}
+	return matched == 1
+}
+
+// 외부로 보내는 webhook의 서명 키 ("whsec_" + 32 bytes hex)
+func MakeWebhookSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("error generating random data: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+// "{timestamp}.{body}"의 HMAC-SHA256 서명을 hex로 반환
+// timestamp를 서명에 포함해서 timestamp만 바꿔 오래된 요청을 다시 보낼 수 없도록 한다
+func SignWebhook(key string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhook 요청에 붙일 timestamp, 서명 header 값을 설정하는 함수 (polka 시뮬레이터, 테스트에서 사용)
+func SetWebhookSignature(headers http.Header, key string, timestamp time.Time, body []byte) {
+	ts := timestamp.Unix()
+	headers.Set(PolkaTimestampHeader, strconv.FormatInt(ts, 10))
+	headers.Set(PolkaSignatureHeader, signatureVersion+"="+SignWebhook(key, ts, body))
+}
+
+// webhook 요청의 timestamp가 now 기준 tolerance 안이고
+// 서명 중 하나가 activeKeys 중 하나로 만든 body 서명과 일치하는지 확인하는 함수
+func VerifyWebhookSignature(headers http.Header, body []byte, activeKeys []string, now time.Time, tolerance time.Duration) error {
+	rawTimestamp := headers.Get(PolkaTimestampHeader)
+	rawSignature := headers.Get(PolkaSignatureHeader)
+	if rawTimestamp == "" || rawSignature == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	// 시계가 조금 빠른 경우도 있으므로 미래 쪽도 같은 tolerance
+	if diff := now.Sub(time.Unix(timestamp, 0)); diff > tolerance || diff < -tolerance {
+		return ErrTimestampTooOld
+	}
+
+	expected := make([][]byte, 0, len(activeKeys))
+	for _, key := range activeKeys {
+		if key == "" {
+			continue
+		}
+		expected = append(expected, []byte(SignWebhook(key, timestamp, body)))
+	}
+	if len(expected) == 0 {
+		return ErrNoActiveKeys
+	}
+
+	for _, part := range strings.Split(rawSignature, ",") {
+		version, signature, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || version != signatureVersion {
+			continue
+		}
+		for _, e := range expected {
+			if hmac.Equal([]byte(signature), e) {
+				return nil
+			}
+		}
+	}
+
+	return ErrInvalidSignature
+}
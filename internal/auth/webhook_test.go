@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMatchAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiKey     string
+		activeKeys []string
+		want       bool
+	}{
+		{name: "current key", apiKey: "new", activeKeys: []string{"new", "old"}, want: true},
+		{name: "previous key during rotation", apiKey: "old", activeKeys: []string{"new", "old"}, want: true},
+		{name: "wrong key", apiKey: "wrong", activeKeys: []string{"new", "old"}, want: false},
+		{name: "empty key doesn't match unset previous key", apiKey: "", activeKeys: []string{"new", ""}, want: false},
+		{name: "prefix of key", apiKey: "ne", activeKeys: []string{"new"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchAPIKey(tt.apiKey, tt.activeKeys); got != tt.want {
+				t.Errorf("MatchAPIKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1","event":"user.upgraded","data":{"user_id":"3311741c-680c-4546-99f3-fc9efac2036c"}}`)
+	now := time.Unix(1700000000, 0)
+	tolerance := 5 * time.Minute
+
+	signed := func(key string, at time.Time) http.Header {
+		headers := http.Header{}
+		SetWebhookSignature(headers, key, at, body)
+		return headers
+	}
+
+	tests := []struct {
+		name       string
+		headers    http.Header
+		body       []byte
+		activeKeys []string
+		wantErr    error
+	}{
+		{
+			name:       "valid signature",
+			headers:    signed("new", now),
+			body:       body,
+			activeKeys: []string{"new", "old"},
+		},
+		{
+			name:       "signed with previous key during rotation",
+			headers:    signed("old", now),
+			body:       body,
+			activeKeys: []string{"new", "old"},
+		},
+		{
+			name:       "signed with retired key",
+			headers:    signed("old", now),
+			body:       body,
+			activeKeys: []string{"new", ""},
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "tampered body",
+			headers:    signed("new", now),
+			body:       []byte(`{"id":"evt_1","event":"user.upgraded","data":{"user_id":"00000000-0000-0000-0000-000000000000"}}`),
+			activeKeys: []string{"new"},
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "replayed after tolerance",
+			headers:    signed("new", now.Add(-tolerance-time.Second)),
+			body:       body,
+			activeKeys: []string{"new"},
+			wantErr:    ErrTimestampTooOld,
+		},
+		{
+			name:       "timestamp too far in the future",
+			headers:    signed("new", now.Add(tolerance+time.Second)),
+			body:       body,
+			activeKeys: []string{"new"},
+			wantErr:    ErrTimestampTooOld,
+		},
+		{
+			name:       "missing headers",
+			headers:    http.Header{},
+			body:       body,
+			activeKeys: []string{"new"},
+			wantErr:    ErrMissingSignature,
+		},
+		{
+			name: "invalid timestamp",
+			headers: http.Header{
+				PolkaTimestampHeader: []string{"yesterday"},
+				PolkaSignatureHeader: []string{"v1=abc"},
+			},
+			body:       body,
+			activeKeys: []string{"new"},
+			wantErr:    ErrInvalidTimestamp,
+		},
+		{
+			name: "one of several signatures matches",
+			headers: http.Header{
+				PolkaTimestampHeader: []string{"1700000000"},
+				PolkaSignatureHeader: []string{"v1=deadbeef, v1=" + SignWebhook("new", now.Unix(), body)},
+			},
+			body:       body,
+			activeKeys: []string{"new"},
+		},
+		{
+			name: "unknown signature version",
+			headers: http.Header{
+				PolkaTimestampHeader: []string{"1700000000"},
+				PolkaSignatureHeader: []string{"v0=" + SignWebhook("new", now.Unix(), body)},
+			},
+			body:       body,
+			activeKeys: []string{"new"},
+			wantErr:    ErrInvalidSignature,
+		},
+		{
+			name:       "no active keys",
+			headers:    signed("", now),
+			body:       body,
+			activeKeys: []string{"", ""},
+			wantErr:    ErrNoActiveKeys,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.headers, tt.body, tt.activeKeys, now, tolerance)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhookSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// 요청한 key에 해당하는 blob이 없을 때 반환되는 에러
+	ErrNotFound = errors.New("blob not found")
+	// 허용되지 않는 key 형식일 때 반환되는 에러
+	errInvalidKey = errors.New("invalid blob key")
+)
+
+// 업로드된 파일(media 등)을 저장하는 저장소 인터페이스
+// 로컬 파일시스템(LocalStore)과 S3 호환 저장소(S3Store) 구현이 있다
+type BlobStore interface {
+	// key 위치에 r의 내용을 저장 (이미 있으면 덮어쓰기)
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// key 위치의 blob을 반환. 없으면 ErrNotFound
+	// 반환된 io.ReadCloser는 호출한 쪽에서 닫아야 한다
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// key 위치의 blob 삭제. 없는 key여도 에러를 반환하지 않는다
+	Delete(ctx context.Context, key string) error
+}
+
+// key는 "media/<id>" 같은 / 구분 상대 경로만 허용
+// 빈 구간, ., .. 이 들어간 key는 로컬 저장소에서 root 밖을 가리킬 수 있으므로 거부
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package chirptext
+
+import (
+	"github.com/paokimsiwoong/chirpy/internal/links"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 같은 글자가 여러 방식으로 인코딩될 수 있으므로(ex: 한글 자모 조합) 저장, 길이 계산 전에 NFC로 정규화하는 함수
+func Normalize(body string) string {
+	return norm.NFC.String(body)
+}
+
+// chirp 길이 : byte나 rune이 아니라 grapheme cluster(화면에 보이는 글자) 수
+// ==> 한글 한 글자(3 byte), 여러 code point로 된 이모지(👨‍👩‍👧 등)도 1글자로 센다
+// url은 실제 길이와 상관없이 하나당 links.URLLength 글자로 센다
+func Length(body string) int {
+	length := uniseg.GraphemeClusterCount(body)
+	for _, link := range links.Find(body) {
+		length += links.URLLength - uniseg.GraphemeClusterCount(link.URL)
+	}
+	return length
+}
@@ -0,0 +1,74 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paokimsiwoong/chirpy/internal/links"
+)
+
+// 기본 chirp 최대 길이 (main.go의 defaultChirpMaxLength)
+const limit = 140
+
+// 조합형 한글 "한글" (초성, 중성, 종성 자모를 따로 쓴 것)과 완성형 "한글"
+const (
+	decomposedHangul = "\u1112\u1161\u11ab\u1100\u1173\u11af"
+	composedHangul   = "한글"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "hello", expected: "hello"},
+		{input: decomposedHangul, expected: composedHangul},
+		{input: composedHangul, expected: composedHangul},
+		{input: "Cafe\u0301", expected: "Caf\u00e9"},
+	}
+
+	for _, c := range cases {
+		actual := Normalize(c.input)
+		if actual != c.expected {
+			t.Errorf("Normalize(%q) = %q, expecting %q", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	// URLLength보다 긴 url
+	longURL := "https://example.com/" + strings.Repeat("a", 100)
+	// limit에서 url 하나와 공백 하나를 뺀 만큼의 한글
+	filler := strings.Repeat("가", limit-links.URLLength-1)
+
+	cases := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{name: "empty", input: "", expected: 0},
+		{name: "ascii", input: "hello", expected: 5},
+		{name: "korean", input: "안녕하세요 세계", expected: 8},
+		{name: "zwj emoji", input: "👨‍👩‍👧", expected: 1},
+		{name: "zwj emoji in text", input: "가족 👨‍👩‍👧!", expected: 5},
+		{name: "flag emoji", input: "🇰🇷", expected: 1},
+		{name: "decomposed hangul", input: decomposedHangul, expected: 2},
+		{name: "normalized decomposed hangul", input: Normalize(decomposedHangul), expected: 2},
+		{name: "composed hangul", input: composedHangul, expected: 2},
+		{name: "combining accent", input: Normalize("Cafe\u0301"), expected: 4},
+		{name: "long url", input: longURL, expected: links.URLLength},
+		{name: "short url", input: "a https://a.io", expected: 2 + links.URLLength},
+		{name: "two urls", input: longURL + " " + longURL, expected: 2*links.URLLength + 1},
+		{name: "url at limit", input: filler + " " + longURL, expected: limit},
+		{name: "url over limit", input: "가" + filler + " " + longURL, expected: limit + 1},
+		{name: "emoji at limit", input: strings.Repeat("👨‍👩‍👧", limit), expected: limit},
+		{name: "emoji over limit", input: strings.Repeat("👨‍👩‍👧", limit+1), expected: limit + 1},
+	}
+
+	for _, c := range cases {
+		actual := Length(c.input)
+		if actual != c.expected {
+			t.Errorf("%s: Length(%q) = %d, expecting %d", c.name, c.input, actual, c.expected)
+		}
+	}
+}
@@ -7,29 +7,63 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPinnedChirps = `-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1
+AND pinned_at IS NOT NULL
+`
+
+func (q *Queries) CountPinnedChirps(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPinnedChirps, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, status, publish_at, content_warning, sensitive, content_warning_set_by, in_reply_to_chirp_id, published_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    CASE WHEN $5 = 'published' THEN NOW() END
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body                string
+	UserID              uuid.UUID
+	Visibility          string
+	QuotedChirpID       uuid.NullUUID
+	Status              string
+	PublishAt           sql.NullTime
+	ContentWarning      sql.NullString
+	Sensitive           bool
+	ContentWarningSetBy uuid.NullUUID
+	InReplyToChirpID    uuid.NullUUID
 }
 
+// 바로 게시되는 chirp면 published_at도 작성 시간으로 채운다
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.Visibility, arg.QuotedChirpID, arg.Status, arg.PublishAt, arg.ContentWarning, arg.Sensitive, arg.ContentWarningSetBy, arg.InReplyToChirpID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,28 +71,42 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
-const deleteChirpByID = `-- name: DeleteChirpByID :exec
-DELETE FROM chirps
-WHERE id = $1 
-AND user_id = $2
+const createChirpMention = `-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT $1::uuid, users.id FROM users
+WHERE users.id = $2
+ON CONFLICT DO NOTHING
 `
 
-type DeleteChirpByIDParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
+type CreateChirpMentionParams struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
 }
 
-func (q *Queries) DeleteChirpByID(ctx context.Context, arg DeleteChirpByIDParams) error {
-	_, err := q.db.ExecContext(ctx, deleteChirpByID, arg.ID, arg.UserID)
+func (q *Queries) CreateChirpMention(ctx context.Context, arg CreateChirpMentionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpMention, arg.ChirpID, arg.UserID)
 	return err
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at FROM chirps
 WHERE id = $1
 `
 
@@ -71,12 +119,121 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
+const getChirpMentionedUserIDs = `-- name: GetChirpMentionedUserIDs :many
+SELECT user_id FROM chirp_mentions
+WHERE chirp_id = $1
+`
+
+func (q *Queries) GetChirpMentionedUserIDs(ctx context.Context, chirpID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpMentionedUserIDs, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		items = append(items, userID)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpStats = `-- name: GetChirpStats :many
+SELECT chirps.id,
+    (SELECT COUNT(*) FROM rechirps WHERE rechirps.chirp_id = chirps.id) AS rechirp_count,
+    EXISTS (
+        SELECT 1 FROM rechirps
+        WHERE rechirps.chirp_id = chirps.id
+        AND rechirps.user_id = $1
+    ) AS rechirped_by_me,
+    (SELECT COUNT(*) FROM chirp_likes WHERE chirp_likes.chirp_id = chirps.id) AS like_count,
+    EXISTS (
+        SELECT 1 FROM chirp_likes
+        WHERE chirp_likes.chirp_id = chirps.id
+        AND chirp_likes.user_id = $1
+    ) AS liked_by_me,
+    EXISTS (
+        SELECT 1 FROM bookmarks
+        WHERE bookmarks.chirp_id = chirps.id
+        AND bookmarks.user_id = $1
+    ) AS bookmarked_by_me
+FROM chirps
+WHERE chirps.id = ANY($2::uuid[])
+`
+
+type GetChirpStatsParams struct {
+	ViewerID uuid.NullUUID
+	ChirpIds []uuid.UUID
+}
+
+type GetChirpStatsRow struct {
+	ID             uuid.UUID
+	RechirpCount   int64
+	RechirpedByMe  bool
+	LikeCount      int64
+	LikedByMe      bool
+	BookmarkedByMe bool
+}
+
+// 여러 chirp의 rechirp, 좋아요 수와 보는 유저의 rechirp, 좋아요, 북마크 여부를 한번에 반환
+func (q *Queries) GetChirpStats(ctx context.Context, arg GetChirpStatsParams) ([]GetChirpStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpStats, arg.ViewerID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpStatsRow
+	for rows.Next() {
+		var i GetChirpStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RechirpCount,
+			&i.RechirpedByMe,
+			&i.LikeCount,
+			&i.LikedByMe,
+			&i.BookmarkedByMe,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at FROM chirps
 ORDER BY created_at
 `
 
@@ -95,6 +252,19 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -110,7 +280,7 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByAuthorID = `-- name: GetChirpsByAuthorID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at FROM chirps
 WHERE user_id = $1
 ORDER BY created_at
 `
@@ -130,6 +300,19 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -143,3 +326,701 @@ func (q *Queries) GetChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]
 	}
 	return items, nil
 }
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at FROM chirps
+WHERE id = ANY($1::uuid[])
+ORDER BY created_at
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredDeletedChirpIDs = `-- name: GetExpiredDeletedChirpIDs :many
+SELECT id FROM chirps
+WHERE deleted_at < $1::timestamptz
+ORDER BY deleted_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type GetExpiredDeletedChirpIDsParams struct {
+	DeletedBefore time.Time
+	Limit         int32
+}
+
+// deleted_before 이전에 삭제된 chirp id들 (retention job용)
+// 트랜잭션 안에서 실행하면 삭제 전까지 행이 잠겨서 그 사이에 복구되지 않는다
+func (q *Queries) GetExpiredDeletedChirpIDs(ctx context.Context, arg GetExpiredDeletedChirpIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredDeletedChirpIDs, arg.DeletedBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnpublishedChirpsByAuthorID = `-- name: GetUnpublishedChirpsByAuthorID :many
+SELECT id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at FROM chirps
+WHERE user_id = $1
+AND status <> 'published'
+AND deleted_at IS NULL
+ORDER BY created_at
+`
+
+// 작성자 본인에게만 보여주는 임시저장, 예약 chirp 목록
+func (q *Queries) GetUnpublishedChirpsByAuthorID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getUnpublishedChirpsByAuthorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirpByID = `-- name: GetVisibleChirpByID :one
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.visibility, chirps.quoted_chirp_id, chirps.pinned_at, chirps.status, chirps.publish_at, chirps.content_warning, chirps.sensitive, chirps.content_warning_set_by, chirps.deleted_at, chirps.deleted_by, chirps.deletion_reason, chirps.in_reply_to_chirp_id, chirps.published_at FROM chirps
+WHERE chirps.id = $1
+AND (chirps.status = 'published' OR chirps.user_id = $2)
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id OR chirps.user_id = $2)
+AND chirp_visible_to(chirps.id, $2::uuid)
+`
+
+type GetVisibleChirpByIDParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// 아직 게시되지 않은 chirp(임시저장, 예약)와 작성자가 지운 chirp는 작성자만 볼 수 있다
+func (q *Queries) GetVisibleChirpByID(ctx context.Context, arg GetVisibleChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirpByID, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const getVisibleChirps = `-- name: GetVisibleChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.visibility, chirps.quoted_chirp_id, chirps.pinned_at, chirps.status, chirps.publish_at, chirps.content_warning, chirps.sensitive, chirps.content_warning_set_by, chirps.deleted_at, chirps.deleted_by, chirps.deletion_reason, chirps.in_reply_to_chirp_id, chirps.published_at FROM chirps
+WHERE chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND chirp_visible_to(chirps.id, $1::uuid)
+ORDER BY chirps.published_at
+`
+
+// 보는 유저(viewer_id, 비로그인이면 NULL)가 볼 수 있는 게시된 chirp만 반환
+// 작성자가 지운 chirp는 빠지고 moderator가 지운 chirp는 tombstone으로 남는다
+// 공개 범위 확인은 chirp_visible_to 함수 (모든 chirp 조회가 같은 규칙을 쓴다)
+func (q *Queries) GetVisibleChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirpsByAuthorID = `-- name: GetVisibleChirpsByAuthorID :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.visibility, chirps.quoted_chirp_id, chirps.pinned_at, chirps.status, chirps.publish_at, chirps.content_warning, chirps.sensitive, chirps.content_warning_set_by, chirps.deleted_at, chirps.deleted_by, chirps.deletion_reason, chirps.in_reply_to_chirp_id, chirps.published_at FROM chirps
+WHERE chirps.user_id = $1
+AND chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND chirp_visible_to(chirps.id, $2::uuid)
+ORDER BY chirps.pinned_at IS NULL, chirps.pinned_at DESC, chirps.published_at
+`
+
+type GetVisibleChirpsByAuthorIDParams struct {
+	AuthorID uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// 고정(pin)된 chirp가 최근 고정 순으로 먼저 나오고 나머지는 게시 시간 순
+func (q *Queries) GetVisibleChirpsByAuthorID(ctx context.Context, arg GetVisibleChirpsByAuthorIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByAuthorID, arg.AuthorID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hardDeleteChirps = `-- name: HardDeleteChirps :execrows
+DELETE FROM chirps
+WHERE id = ANY($1::uuid[])
+AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) HardDeleteChirps(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, hardDeleteChirps, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pinChirp = `-- name: PinChirp :one
+UPDATE chirps
+SET pinned_at = COALESCE(pinned_at, NOW())
+WHERE id = $1
+AND user_id = $2
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type PinChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// 이미 고정된 chirp면 원래 고정 시간을 유지
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, pinChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const publishChirp = `-- name: PublishChirp :one
+UPDATE chirps
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type PublishChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// 임시저장, 예약 chirp를 지금 바로 게시
+// 타임라인은 게시 시간 기준으로 정렬되므로 published_at을 채운다 (created_at은 그대로)
+func (q *Queries) PublishChirp(ctx context.Context, arg PublishChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled'
+    AND publish_at <= NOW()
+    AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM polls
+        WHERE polls.chirp_id = chirps.id
+        AND polls.closes_at <= NOW()
+    )
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+AND status = 'scheduled'
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+// 예약 시간이 지난 chirp들을 게시하고 이번에 게시한 chirp들을 반환
+// FOR UPDATE SKIP LOCKED로 여러 서버가 동시에 실행해도 같은 chirp를 두번 게시하지 않는다
+func (q *Queries) PublishDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Visibility,
+			&i.QuotedChirpID,
+			&i.PinnedAt,
+			&i.Status,
+			&i.PublishAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.ContentWarningSetBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.DeletionReason,
+			&i.InReplyToChirpID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL, deleted_by = NULL, deletion_reason = NULL, updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND deleted_by = user_id
+AND deleted_at > $3::timestamptz
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type RestoreChirpParams struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	RestorableSince time.Time
+}
+
+// 작성자가 직접 지운 chirp만 restorable_since 이후에 지운 경우 복구 가능
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.RestorableSince)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const scheduleChirp = `-- name: ScheduleChirp :one
+UPDATE chirps
+SET status = 'scheduled', publish_at = $1, updated_at = NOW()
+WHERE id = $2
+AND user_id = $3
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type ScheduleChirpParams struct {
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+	UserID    uuid.UUID
+}
+
+// 임시저장, 예약 chirp의 게시 예약 시간 설정 (이미 예약된 chirp면 시간 변경)
+func (q *Queries) ScheduleChirp(ctx context.Context, arg ScheduleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, scheduleChirp, arg.PublishAt, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const setChirpContentWarning = `-- name: SetChirpContentWarning :one
+UPDATE chirps
+SET content_warning = $1, sensitive = $2, content_warning_set_by = $3, updated_at = NOW()
+WHERE id = $4
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type SetChirpContentWarningParams struct {
+	ContentWarning      sql.NullString
+	Sensitive           bool
+	ContentWarningSetBy uuid.NullUUID
+	ID                  uuid.UUID
+}
+
+// 경고 문구, 민감 여부 변경 (content_warning이 NULL이고 sensitive가 false면 경고 해제)
+func (q *Queries) SetChirpContentWarning(ctx context.Context, arg SetChirpContentWarningParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpContentWarning, arg.ContentWarning, arg.Sensitive, arg.ContentWarningSetBy, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :one
+UPDATE chirps
+SET deleted_at = NOW(), deleted_by = $1, deletion_reason = $2, pinned_at = NULL, updated_at = NOW()
+WHERE id = $3
+AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type SoftDeleteChirpParams struct {
+	DeletedBy      uuid.NullUUID
+	DeletionReason sql.NullString
+	ID             uuid.UUID
+}
+
+// 실제로 지우지 않고 삭제 표시 (고정도 해제)
+func (q *Queries) SoftDeleteChirp(ctx context.Context, arg SoftDeleteChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteChirp, arg.DeletedBy, arg.DeletionReason, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const unpinChirp = `-- name: UnpinChirp :one
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+RETURNING id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, pinned_at, status, publish_at, content_warning, sensitive, content_warning_set_by, deleted_at, deleted_by, deletion_reason, in_reply_to_chirp_id, published_at
+`
+
+type UnpinChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, unpinChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Visibility,
+		&i.QuotedChirpID,
+		&i.PinnedAt,
+		&i.Status,
+		&i.PublishAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.ContentWarningSetBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.DeletionReason,
+		&i.InReplyToChirpID,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const unscheduleClosedPollChirps = `-- name: UnscheduleClosedPollChirps :many
+UPDATE chirps
+SET status = 'draft', publish_at = NULL, updated_at = NOW()
+WHERE id IN (
+    SELECT chirps.id FROM chirps
+    JOIN polls ON polls.chirp_id = chirps.id
+    WHERE chirps.status = 'scheduled'
+    AND chirps.publish_at <= NOW()
+    AND chirps.deleted_at IS NULL
+    AND polls.closes_at <= NOW()
+    LIMIT $1
+    FOR UPDATE OF chirps SKIP LOCKED
+)
+AND status = 'scheduled'
+RETURNING id
+`
+
+// 예약 시간이 지났지만 투표가 이미 마감된 chirp들은 게시하지 않고 임시저장으로 되돌린다
+// (스케줄러가 늦게 돌아 예약 시간과 마감 시간 사이를 놓친 경우)
+func (q *Queries) UnscheduleClosedPollChirps(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, unscheduleClosedPollChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
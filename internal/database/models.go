@@ -6,17 +6,187 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Body                string
+	UserID              uuid.UUID
+	Visibility          string
+	QuotedChirpID       uuid.NullUUID
+	PinnedAt            sql.NullTime
+	Status              string
+	PublishAt           sql.NullTime
+	ContentWarning      sql.NullString
+	Sensitive           bool
+	ContentWarningSetBy uuid.NullUUID
+	DeletedAt           sql.NullTime
+	DeletedBy           uuid.NullUUID
+	DeletionReason      sql.NullString
+	InReplyToChirpID    uuid.NullUUID
+	PublishedAt         sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID uuid.UUID
+	Tag     string
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLink struct {
+	ChirpID    uuid.UUID
+	Position   int32
+	Url        string
+	StartIndex int32
+	EndIndex   int32
+}
+
+type ChirpMention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	CreatedBy uuid.NullUUID
+	IsGroup   bool
+	DirectKey sql.NullString
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	AcceptedAt sql.NullTime
+}
+
+type Job struct {
+	ID          uuid.UUID
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       time.Time
+	LockedUntil sql.NullTime
+	LastError   sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type LinkPreview struct {
+	Url         string
+	Status      string
+	Title       string
+	Description string
+	ImageUrl    string
+	CreatedAt   time.Time
+	FetchedAt   sql.NullTime
+}
+
+type Medium struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ChirpID              uuid.NullUUID
+	Position             sql.NullInt32
+	ContentType          string
+	SizeBytes            int32
+	Width                int32
+	Height               int32
+	ThumbnailContentType string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	GroupKey  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type PolkaEvent struct {
+	ID         string
+	Event      string
+	ReceivedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type PresenceSession struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Status     string
+	LastSeenAt time.Time
+}
+
+type Rechirp struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +198,122 @@ type RefreshToken struct {
 	UserID    uuid.UUID
 }
 
+type StreamEvent struct {
+	ID             int64
+	Type           string
+	ChirpID        uuid.NullUUID
+	AuthorID       uuid.NullUUID
+	RecipientID    uuid.NullUUID
+	NotificationID uuid.NullUUID
+	CreatedAt      time.Time
+	ActorID        uuid.NullUUID
+	ConversationID uuid.NullUUID
+	MessageID      uuid.NullUUID
+}
+
+type StreamEventOutbox struct {
+	ID             int64
+	Type           string
+	ChirpID        uuid.NullUUID
+	AuthorID       uuid.NullUUID
+	RecipientID    uuid.NullUUID
+	NotificationID uuid.NullUUID
+	ActorID        uuid.NullUUID
+	ConversationID uuid.NullUUID
+	MessageID      uuid.NullUUID
+	CreatedAt      time.Time
+}
+
+type Subscription struct {
+	ID                 uuid.UUID
+	UserID             uuid.UUID
+	Plan               string
+	Status             string
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+	CancelAtPeriodEnd  bool
+	CanceledAt         sql.NullTime
+	PaymentFailedAt    sql.NullTime
+	GraceUntil         sql.NullTime
+	EndedAt            sql.NullTime
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+type TrendChirpBucket struct {
+	BucketStart time.Time
+	ChirpID     uuid.UUID
+	Engagements int32
+}
+
+type TrendHashtagBucket struct {
+	BucketStart time.Time
+	Tag         string
+	Chirps      int32
+	Engagements int32
+	ChirpID     uuid.UUID
+}
+
+type TrendingChirp struct {
+	TimeWindow string
+	ChirpID    uuid.UUID
+	Score      float64
+	ComputedAt time.Time
+}
+
+type TrendingHashtag struct {
+	TimeWindow  string
+	Tag         string
+	Score       float64
+	ChirpCount  int32
+	AuthorCount int32
+	ComputedAt  time.Time
+}
+
 type User struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      string
+	IsChirpyRed         bool
+	IsProtected         bool
+	AutoExpandSensitive bool
+	IsModerator         bool
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
 	ID             uuid.UUID
+	EndpointID     uuid.UUID
+	EventID        uuid.UUID
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastAttemptAt  sql.NullTime
+	ResponseStatus sql.NullInt32
+	LastError      sql.NullString
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+}
+
+type WebhookEndpoint struct {
+	ID                  uuid.UUID
+	UserID              uuid.NullUUID
+	Url                 string
+	Secret              string
+	Events              []string
+	ConsecutiveFailures int32
+	FailingSince        sql.NullTime
+	DisabledAt          sql.NullTime
+	DisabledReason      sql.NullString
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 }
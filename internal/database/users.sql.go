@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -20,7 +21,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator
 `
 
 type CreateUserParams struct {
@@ -38,12 +39,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator FROM users
 WHERE email = $1
 `
 
@@ -57,24 +61,122 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
 	)
 	return i, err
 }
 
-const resetUsers = `-- name: ResetUsers :exec
-DELETE FROM users
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator FROM users
+WHERE id = $1
+FOR UPDATE
 `
 
-func (q *Queries) ResetUsers(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, resetUsers)
+// 트랜잭션이 끝날 때까지 유저 행을 잠가서 같은 유저의 동시 요청들을 순서대로 처리
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
+	)
+	return i, err
+}
+
+const resetDatabase = `-- name: ResetDatabase :exec
+TRUNCATE users, jobs, stream_events, stream_event_outbox, trend_hashtag_buckets, trending_hashtags, polka_events CASCADE
+`
+
+// /admin/reset (dev 전용) : users와 users를 참조하는 모든 테이블(CASCADE로 chirps, 대화, webhook 등),
+// users를 참조하지 않아 유저를 지워도 남는 job, stream event, outbox, 해시태그 trend rollup, polka event 기록을 비운다
+// link_previews는 유저 데이터가 아닌 url별 미리보기 cache라서 남겨둔다
+// stream_events id sequence는 재시작하지 않는다 (접속 중인 client의 last_event_id보다 작은 id가 다시 나오지 않도록)
+func (q *Queries) ResetDatabase(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetDatabase)
 	return err
 }
 
+const syncUserMemberships = `-- name: SyncUserMemberships :many
+UPDATE users
+SET is_chirpy_red = EXISTS (
+    SELECT 1 FROM subscriptions
+    WHERE subscriptions.user_id = users.id
+    AND subscriptions.status IN ('active', 'past_due')
+), updated_at = NOW()
+WHERE id = ANY($1::uuid[])
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator
+`
+
+// is_chirpy_red를 구독 상태에서 다시 계산 (active, past_due 구독이 있으면 true)
+func (q *Queries) SyncUserMemberships(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, syncUserMemberships, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.IsProtected,
+			&i.AutoExpandSensitive,
+			&i.IsModerator,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET email = $1, hashed_password = $2, updated_at = NOW()
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator
 `
 
 type UpdateUserParams struct {
@@ -93,19 +195,56 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
 	)
 	return i, err
 }
 
-const updateUserMembership = `-- name: UpdateUserMembership :one
+const updateUserAutoExpandSensitive = `-- name: UpdateUserAutoExpandSensitive :one
 UPDATE users
-SET is_chirpy_red = true, updated_at = NOW()
-WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SET auto_expand_sensitive = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator
 `
 
-func (q *Queries) UpdateUserMembership(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserMembership, id)
+type UpdateUserAutoExpandSensitiveParams struct {
+	AutoExpandSensitive bool
+	ID                  uuid.UUID
+}
+
+func (q *Queries) UpdateUserAutoExpandSensitive(ctx context.Context, arg UpdateUserAutoExpandSensitiveParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserAutoExpandSensitive, arg.AutoExpandSensitive, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
+	)
+	return i, err
+}
+
+const updateUserProtected = `-- name: UpdateUserProtected :one
+UPDATE users
+SET is_protected = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_protected, auto_expand_sensitive, is_moderator
+`
+
+type UpdateUserProtectedParams struct {
+	IsProtected bool
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProtected(ctx context.Context, arg UpdateUserProtectedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProtected, arg.IsProtected, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -114,6 +253,9 @@ func (q *Queries) UpdateUserMembership(ctx context.Context, id uuid.UUID) (User,
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsProtected,
+		&i.AutoExpandSensitive,
+		&i.IsModerator,
 	)
 	return i, err
 }
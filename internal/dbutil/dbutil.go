@@ -0,0 +1,33 @@
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// sqlc 쿼리가 아닐 때의 쿼리 이름
+const OtherQuery = "other"
+
+// database.DBTX와 같은 method들 (쿼리를 감싸는 패키지들이 database 패키지를 import하지 않기 위해 따로 정의)
+// *sql.DB, *sql.Tx와 이를 감싼 DBTX 모두 구현한다
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// sqlc가 만든 쿼리 앞의 "-- name: GetJobs :many" 주석에서 쿼리 이름을 꺼내는 함수
+// sqlc 쿼리가 아니면 OtherQuery
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return OtherQuery
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return OtherQuery
+	}
+	return name
+}
@@ -0,0 +1,17 @@
+package dbutil
+
+import "testing"
+
+func TestQueryName(t *testing.T) {
+	cases := map[string]string{
+		"-- name: GetJobs :many\nSELECT 1": "GetJobs",
+		"-- name: CompleteJob :execrows\n": "CompleteJob",
+		"SELECT 1":                         "other",
+		"-- name: ":                        "other",
+	}
+	for query, expected := range cases {
+		if actual := QueryName(query); actual != expected {
+			t.Errorf("QueryName(%q) = %q, expecting %q", query, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package hashtags
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// hashtag 최대 길이 (# 제외, rune 단위) : 이보다 길면 hashtag로 보지 않는다
+const MaxLength = 50
+
+// 본문 처음이나 공백, 여는 괄호/따옴표 뒤의 #으로 시작하는 글자, 숫자, _ 묶음
+// ==> url 속 fragment(https://example.com/#top)나 단어 중간의 #은 hashtag가 아니다
+var tagPattern = regexp.MustCompile(`(?:^|[\s(\[{"'“‘])#([\p{L}\p{M}\p{N}_]+)`)
+
+// 본문에서 hashtag들을 찾아 정규화(NFC, 소문자)하고 중복 없이 처음 나온 순서대로 반환하는 함수
+// 반환하는 tag에는 #이 붙지 않는다
+func Find(text string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, m := range tagPattern.FindAllStringSubmatch(text, -1) {
+		tag := Normalize(m[1])
+		if !valid(tag) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		found = append(found, tag)
+	}
+	return found
+}
+
+// 대소문자, 유니코드 조합 방식이 달라도 같은 hashtag로 모이도록 정규화하는 함수
+func Normalize(tag string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimPrefix(tag, "#")))
+}
+
+// 숫자로만 된 tag(#1)는 제외하고 길이 제한 확인
+func valid(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > MaxLength {
+		return false
+	}
+	return strings.IndexFunc(tag, unicode.IsLetter) >= 0
+}
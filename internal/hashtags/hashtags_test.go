@@ -0,0 +1,30 @@
+package hashtags
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "no tags here", expected: nil},
+		{input: "#Go is fun #go #GO", expected: []string{"go"}},
+		{input: "새 글 #고양이, (#cats) 그리고 #dog_pics!", expected: []string{"고양이", "cats", "dog_pics"}},
+		{input: "see https://example.com/#anchor and a#b", expected: nil},
+		{input: "#2024 #year2024 #", expected: []string{"year2024"}},
+		{input: "#" + strings.Repeat("a", MaxLength+1) + " #ok", expected: []string{"ok"}},
+		// 조합형(e + ◌́)과 완성형(é)은 같은 tag
+		{input: "#Cafe\u0301 #CAFÉ", expected: []string{"café"}},
+	}
+
+	for _, c := range cases {
+		actual := Find(c.input)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Find(%q) = %q, expecting %q", c.input, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package links
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// chirp 길이 계산에서 url 하나를 몇 글자로 셀지 (url 길이와 상관없이 고정)
+const URLLength = 23
+
+// 본문에서 찾은 url 하나
+// Start, End는 본문에서의 위치로 byte가 아니라 rune(code point) 단위 [Start, End)
+type Link struct {
+	URL   string
+	Start int
+	End   int
+}
+
+// http://, https:// 로 시작하고 공백이나 <, >, " 가 나오기 전까지를 url로 본다
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// url 끝에 붙어 있지만 보통은 문장 부호인 문자들
+const trailingPunctuation = ".,:;!?'"
+
+// 본문에서 url들을 찾아 순서대로 반환하는 함수
+func Find(text string) []Link {
+	var found []Link
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		start := loc[0]
+		url := trimURL(text[start:loc[1]])
+		// scheme만 있는 경우는 url이 아니다
+		if strings.HasSuffix(url, "://") {
+			continue
+		}
+
+		runeStart := utf8.RuneCountInString(text[:start])
+		found = append(found, Link{
+			URL:   url,
+			Start: runeStart,
+			End:   runeStart + utf8.RuneCountInString(url),
+		})
+	}
+	return found
+}
+
+// url 끝의 문장 부호와 짝이 맞지 않는 닫는 괄호 제거
+// ex: "(https://example.com/a_(b))." ==> "https://example.com/a_(b)"
+func trimURL(url string) string {
+	for len(url) > 0 {
+		last := url[len(url)-1]
+		if strings.IndexByte(trailingPunctuation, last) >= 0 {
+			url = url[:len(url)-1]
+			continue
+		}
+		if last == ')' && strings.Count(url, "(") < strings.Count(url, ")") {
+			url = url[:len(url)-1]
+			continue
+		}
+		break
+	}
+	return url
+}
@@ -0,0 +1,144 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []Link
+	}{
+		{input: "no links here", expected: nil},
+		{
+			input:    "read https://example.com/a?b=c.",
+			expected: []Link{{URL: "https://example.com/a?b=c", Start: 5, End: 30}},
+		},
+		{
+			input:    "링크(https://example.com/a_(b)) 봐",
+			expected: []Link{{URL: "https://example.com/a_(b)", Start: 3, End: 28}},
+		},
+		{
+			input: "http://a.io, HTTPS://B.io! https://",
+			expected: []Link{
+				{URL: "http://a.io", Start: 0, End: 11},
+				{URL: "HTTPS://B.io", Start: 13, End: 25},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual := Find(c.input)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Find(%q) = %+v, expecting %+v", c.input, actual, c.expected)
+		}
+	}
+}
+
+// 테스트용 fetcher : httptest 서버가 loopback 주소이므로 loopback만 허용
+func testFetcher(maxBytes int64) *Fetcher {
+	f := NewFetcher(0, maxBytes)
+	f.allowAddr = func(addr netip.Addr) bool { return addr.IsLoopback() }
+	return f
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!doctype html><html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="  Chirpy   launch ">
+<meta property="og:description" content="A toy messenger">
+<meta property="og:image" content="/img/card.png">
+</head><body><meta property="og:title" content="ignored"></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Plain page</title><meta name="description" content="desc"></head></html>`))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/og", http.StatusFound)
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head>" + strings.Repeat("<!-- padding -->", 1000) + `<meta property="og:title" content="too late"></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := testFetcher(1024)
+	ctx := context.Background()
+
+	preview, err := f.Fetch(ctx, server.URL+"/redirect")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	expected := Preview{Title: "Chirpy launch", Description: "A toy messenger", ImageURL: server.URL + "/img/card.png"}
+	if preview != expected {
+		t.Errorf("Fetch = %+v, expecting %+v", preview, expected)
+	}
+
+	preview, err = f.Fetch(ctx, server.URL+"/plain")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if preview.Title != "Plain page" || preview.Description != "desc" {
+		t.Errorf("fallback preview = %+v", preview)
+	}
+
+	if _, err := f.Fetch(ctx, server.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Fetch(json) returned %v, expecting ErrNotHTML", err)
+	}
+
+	// 크기 제한 이후의 태그는 읽지 않는다
+	preview, err = f.Fetch(ctx, server.URL+"/huge")
+	if err != nil {
+		t.Fatalf("Fetch(huge) error: %v", err)
+	}
+	if preview.Title != "" {
+		t.Errorf("Fetch(huge) read past size cap: %+v", preview)
+	}
+
+	if _, err := f.Fetch(ctx, "file:///etc/passwd"); err == nil {
+		t.Errorf("Fetch(file://) succeeded, expecting error")
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request reached loopback server")
+	}))
+	defer server.Close()
+
+	// 기본 fetcher는 loopback 주소로 연결하지 않는다
+	f := NewFetcher(0, 0)
+	if _, err := f.Fetch(context.Background(), server.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Fetch(loopback) returned %v, expecting ErrForbiddenAddress", err)
+	}
+
+	// NewPublicClient도 마찬가지
+	if _, err := NewPublicClient(0).Post(server.URL, "application/json", nil); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("NewPublicClient().Post(loopback) returned %v, expecting ErrForbiddenAddress", err)
+	}
+
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.0.1", "169.254.169.254", "100.64.0.1", "::1", "fe80::1", "fc00::1", "0.0.0.0"} {
+		if isPublicAddr(netip.MustParseAddr(addr)) {
+			t.Errorf("isPublicAddr(%s) = true, expecting false", addr)
+		}
+	}
+	if !isPublicAddr(netip.MustParseAddr("93.184.216.34")) {
+		t.Errorf("isPublicAddr(93.184.216.34) = false, expecting true")
+	}
+}
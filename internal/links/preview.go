@@ -0,0 +1,288 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// 페이지 하나를 가져오는 최대 시간 (redirect 포함)
+	DefaultTimeout = 5 * time.Second
+	// 읽을 html의 최대 크기. og 태그는 <head>에 있으므로 앞부분만 읽어도 충분하다
+	DefaultMaxBytes = 512 << 10
+	// 따라갈 최대 redirect 수
+	maxRedirects = 5
+	// title, description 최대 길이 (rune 단위)
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+)
+
+var (
+	// 내부망, loopback 등 접근이 금지된 주소일 때 반환되는 에러
+	ErrForbiddenAddress = errors.New("forbidden address")
+	// html이 아닌 응답일 때 반환되는 에러
+	ErrNotHTML = errors.New("response is not html")
+)
+
+// 링크 미리보기 카드에 쓰이는 OpenGraph 정보
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// url의 html을 가져와서 Preview를 만드는 구조체
+// 유저가 입력한 url을 서버가 대신 요청하므로 SSRF(서버를 통해 내부망에 접근) 방지를 위해
+// 실제로 연결하는 ip 주소를 확인해서 공인 주소가 아니면 연결을 끊는다
+type Fetcher struct {
+	client   *http.Client
+	maxBytes int64
+	// 연결을 허용할 주소인지 판단하는 함수 (테스트에서 loopback httptest 서버를 허용하기 위해 교체)
+	allowAddr func(netip.Addr) bool
+}
+
+// timeout, maxBytes가 0 이하이면 기본값 사용
+func NewFetcher(timeout time.Duration, maxBytes int64) *Fetcher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	f := &Fetcher{maxBytes: maxBytes, allowAddr: isPublicAddr}
+
+	f.client = &http.Client{
+		Timeout: timeout,
+		// 테스트에서 allowAddr를 바꿀 수 있도록 호출할 때마다 f.allowAddr를 읽는다
+		Transport: newPublicTransport(timeout, func(addr netip.Addr) bool { return f.allowAddr(addr) }),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+
+	return f
+}
+
+// 공인 주소에만 연결하는 http client (유저가 입력한 url로 요청을 보낼 때 사용 ex: webhook)
+// redirect는 따라가지 않고 3xx 응답을 그대로 반환한다
+func NewPublicClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: newPublicTransport(timeout, isPublicAddr),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// 실제로 연결하는 ip 주소가 allowAddr를 통과할 때만 연결하는 transport
+func newPublicTransport(timeout time.Duration, allowAddr func(netip.Addr) bool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// DNS 조회가 끝난 뒤 실제 연결 직전에 호출되므로 DNS rebinding으로도 우회할 수 없다
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			if !allowAddr(addrPort.Addr().Unmap()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		},
+	}
+
+	return &http.Transport{
+		// 환경변수의 proxy를 쓰면 proxy 주소만 검사하게 되므로 proxy 사용 안함
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+}
+
+// 공인 unicast 주소인지 확인
+func isPublicAddr(addr netip.Addr) bool {
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!isSharedAddr(addr)
+}
+
+// 100.64.0.0/10 (carrier-grade NAT) 처럼 IsPrivate에 포함되지 않는 내부용 대역
+var sharedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+func isSharedAddr(addr netip.Addr) bool {
+	for _, prefix := range sharedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawURL의 html을 가져와 OpenGraph 태그(없으면 <title>, description meta 태그)로 Preview를 만드는 함수
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, fmt.Errorf("error parsing url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Preview{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Preview{}, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ChirpyBot/1.0 (+link preview)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, fmt.Errorf("error fetching url: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	// maxBytes 이후는 읽지 않는다 (잘린 html도 파싱은 가능)
+	preview, err := parsePreview(io.LimitReader(res.Body, f.maxBytes))
+	if err != nil {
+		return Preview{}, err
+	}
+
+	// 상대 경로 이미지는 redirect 이후 최종 url 기준으로 절대 경로로 변환
+	if preview.ImageURL != "" {
+		image, err := res.Request.URL.Parse(preview.ImageURL)
+		if err != nil || (image.Scheme != "http" && image.Scheme != "https") {
+			preview.ImageURL = ""
+		} else {
+			preview.ImageURL = image.String()
+		}
+	}
+
+	return preview, nil
+}
+
+// html에서 미리보기 정보를 찾는 함수
+func parsePreview(r io.Reader) (Preview, error) {
+	var preview, fallback Preview
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != nil && !errors.Is(err, io.EOF) {
+				return Preview{}, fmt.Errorf("error parsing html: %w", err)
+			}
+			return finishPreview(preview, fallback), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				key, content := metaAttrs(token)
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image", "og:image:url":
+					if preview.ImageURL == "" {
+						preview.ImageURL = content
+					}
+				case "description":
+					fallback.Description = content
+				}
+			case "body":
+				// og 태그는 <head>에만 있으므로 body부터는 읽지 않는다
+				return finishPreview(preview, fallback), nil
+			}
+		case html.TextToken:
+			if inTitle && fallback.Title == "" {
+				fallback.Title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+// <meta property="og:title" content="..."> 또는 <meta name="description" content="...">의 key, content
+func metaAttrs(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(attr.Val))
+			}
+		case "content":
+			content = attr.Val
+		}
+	}
+	return key, content
+}
+
+func finishPreview(preview, fallback Preview) Preview {
+	if preview.Title == "" {
+		preview.Title = fallback.Title
+	}
+	if preview.Description == "" {
+		preview.Description = fallback.Description
+	}
+	preview.Title = truncate(strings.Join(strings.Fields(preview.Title), " "), maxTitleLength)
+	preview.Description = truncate(strings.Join(strings.Fields(preview.Description), " "), maxDescriptionLength)
+	preview.ImageURL = strings.TrimSpace(preview.ImageURL)
+	return preview
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
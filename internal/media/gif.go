@@ -0,0 +1,79 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedGIF = errors.New("malformed gif")
+
+// 픽셀 데이터를 풀지 않고 gif의 block 구조만 읽어서 프레임 수와 프레임 크기 합(디코딩하면 할당될 픽셀 수)을 세는 함수
+// gif.DecodeAll은 프레임마다 이미지를 할당하므로 작은 파일에 전체 화면 프레임이 수천 개 있으면 메모리가 폭발한다
+// limit을 넘으면 바로 멈춘다 (total > limit)
+func gifFramePixels(data []byte, limit int) (frames, total int, err error) {
+	// header(6) + logical screen descriptor(7)
+	if len(data) < 13 {
+		return 0, 0, errMalformedGIF
+	}
+	i := 13
+	// global color table
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << ((flags & 0x07) + 1)
+	}
+
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension : label 뒤 sub-block들
+			if i+2 > len(data) {
+				return frames, total, errMalformedGIF
+			}
+			i, err = skipSubBlocks(data, i+2)
+			if err != nil {
+				return frames, total, err
+			}
+		case 0x2C: // image descriptor : left, top, width, height, flags
+			if i+10 > len(data) {
+				return frames, total, errMalformedGIF
+			}
+			width := int(binary.LittleEndian.Uint16(data[i+5:]))
+			height := int(binary.LittleEndian.Uint16(data[i+7:]))
+			flags := data[i+9]
+			frames++
+			total += width * height
+			if total > limit {
+				return frames, total, nil
+			}
+			i += 10
+			// local color table
+			if flags&0x80 != 0 {
+				i += 3 << ((flags & 0x07) + 1)
+			}
+			// LZW 최소 code 크기 뒤 압축된 픽셀 sub-block들
+			i, err = skipSubBlocks(data, i+1)
+			if err != nil {
+				return frames, total, err
+			}
+		case 0x3B: // trailer
+			return frames, total, nil
+		default:
+			return frames, total, errMalformedGIF
+		}
+	}
+	// trailer가 없어도 gif.DecodeAll은 읽은 프레임까지 디코딩하므로 센 값을 그대로 반환
+	return frames, total, nil
+}
+
+// 길이 byte로 시작하는 sub-block들을 길이 0인 terminator까지 건너뛰고 다음 위치를 반환
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return i, errMalformedGIF
+		}
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i, nil
+		}
+		i += n
+	}
+}
@@ -0,0 +1,202 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// 디코딩할 수 있는 최대 픽셀 수 (작은 파일이 거대한 이미지로 풀리는 decompression bomb 방지)
+	MaxPixels = 24_000_000
+	// 움직이는 gif의 최대 프레임 수
+	MaxGIFFrames = 500
+	// 움직이는 gif의 모든 프레임 픽셀 수 합의 최대값 (프레임마다 이미지가 할당된다)
+	MaxGIFTotalPixels = 2 * MaxPixels
+	// 썸네일의 긴 변 길이
+	ThumbnailSize = 320
+	// 재인코딩할 때 쓰는 jpeg 품질
+	jpegQuality = 90
+)
+
+var (
+	// 지원하지 않는 파일 형식일 때 반환되는 에러
+	ErrUnsupportedType = errors.New("unsupported media type")
+	// 이미지 크기(픽셀 수)가 MaxPixels를 넘거나 gif의 프레임 수, 프레임 픽셀 수 합이 제한을 넘을 때 반환되는 에러
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// 업로드 가능한 이미지 형식
+var supportedTypes = map[string]struct{}{
+	"image/jpeg": {},
+	"image/png":  {},
+	"image/gif":  {},
+}
+
+// 인코딩된 이미지 하나
+type Image struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+// 업로드된 이미지를 처리한 결과 : 메타데이터를 제거한 원본과 썸네일
+type Processed struct {
+	Original  Image
+	Thumbnail Image
+}
+
+// 업로드된 파일 내용으로 형식을 판별(클라이언트가 보낸 Content-Type은 믿지 않는다)하고
+// 디코딩 후 다시 인코딩해서 EXIF(GPS 위치 등) 같은 메타데이터를 제거한 원본과 썸네일을 만드는 함수
+// jpeg는 제거되는 EXIF의 회전 정보(orientation)를 먼저 픽셀에 적용해서 보이는 방향이 바뀌지 않게 한다
+func Process(data []byte) (Processed, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := supportedTypes[contentType]; !ok {
+		return Processed{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	// 픽셀 데이터를 디코딩하기 전에 header만 읽어서 크기 확인
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("error decoding image config: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Processed{}, ErrTooManyPixels
+	}
+
+	var original Image
+	var first image.Image
+
+	switch contentType {
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("error decoding jpeg: %w", err)
+		}
+		first = applyOrientation(img, jpegOrientation(data))
+		original, err = encode(first, contentType)
+		if err != nil {
+			return Processed{}, err
+		}
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("error decoding png: %w", err)
+		}
+		first = img
+		original, err = encode(first, contentType)
+		if err != nil {
+			return Processed{}, err
+		}
+	case "image/gif":
+		// logical screen 크기만으로는 프레임 수를 알 수 없으므로 디코딩 전에 프레임들을 세어본다
+		frames, total, err := gifFramePixels(data, MaxGIFTotalPixels)
+		if err != nil {
+			return Processed{}, fmt.Errorf("error decoding gif: %w", err)
+		}
+		if frames > MaxGIFFrames || total > MaxGIFTotalPixels {
+			return Processed{}, ErrTooManyPixels
+		}
+		// 움직이는 gif의 프레임은 유지하고 comment 등 확장 블록만 버린다
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("error decoding gif: %w", err)
+		}
+		if len(g.Image) == 0 {
+			return Processed{}, errors.New("error decoding gif: no frames")
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			return Processed{}, fmt.Errorf("error encoding gif: %w", err)
+		}
+		first = g.Image[0]
+		original = Image{ContentType: contentType, Data: buf.Bytes(), Width: g.Config.Width, Height: g.Config.Height}
+	}
+
+	// 썸네일은 jpeg면 jpeg, 투명도가 있을 수 있는 png, gif는 png로 저장
+	thumbType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbType = "image/jpeg"
+	}
+	thumbnail, err := encode(thumbnail(first, ThumbnailSize), thumbType)
+	if err != nil {
+		return Processed{}, err
+	}
+
+	return Processed{Original: original, Thumbnail: thumbnail}, nil
+}
+
+func encode(img image.Image, contentType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		err = png.Encode(&buf, img)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	return Image{ContentType: contentType, Data: buf.Bytes(), Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// 긴 변이 size 이하가 되도록 비율을 유지하며 줄인 이미지를 반환 (이미 작으면 그대로)
+// 각 결과 픽셀은 대응하는 원본 영역 픽셀들의 평균 (box filter)
+func thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw <= size && sh <= size {
+		return src
+	}
+
+	dw, dh := size, sh*size/sw
+	if sh > sw {
+		dw, dh = sw*size/sh, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// 픽셀 접근이 빠르도록 RGBA로 변환
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := rgba.Pix[y*rgba.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,181 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// 왼쪽 절반은 빨강, 오른쪽 절반은 파랑인 이미지
+func halfImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// orientation 태그와 다른 정보가 담긴 EXIF APP1 segment를 SOI 바로 뒤에 끼워 넣는 함수
+func withExif(t *testing.T, jpg []byte, orientation uint16, extra string) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	// IFD0 : entry 1개 (orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(&tiff, binary.BigEndian, uint16(3))
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString(extra)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessStripsExifAndAppliesOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, halfImage(40, 20), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("jpeg.Encode error: %v", err)
+	}
+	data := withExif(t, buf.Bytes(), 6, "GPS 37.5665N 126.9780E")
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("jpegOrientation = %d, expecting 6", jpegOrientation(data))
+	}
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+
+	if processed.Original.ContentType != "image/jpeg" {
+		t.Errorf("ContentType = %s, expecting image/jpeg", processed.Original.ContentType)
+	}
+	if bytes.Contains(processed.Original.Data, []byte("Exif")) || bytes.Contains(processed.Original.Data, []byte("GPS")) {
+		t.Errorf("processed image still contains EXIF data")
+	}
+
+	// 시계 방향 90도 회전 ==> 20x40, 위쪽이 원본의 왼쪽(빨강)
+	if processed.Original.Width != 20 || processed.Original.Height != 40 {
+		t.Fatalf("size = %dx%d, expecting 20x40", processed.Original.Width, processed.Original.Height)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(processed.Original.Data))
+	if err != nil {
+		t.Fatalf("decoding processed image error: %v", err)
+	}
+	if r, _, b, _ := img.At(10, 5).RGBA(); r < b {
+		t.Errorf("top of rotated image is not red")
+	}
+	if r, _, b, _ := img.At(10, 35).RGBA(); b < r {
+		t.Errorf("bottom of rotated image is not blue")
+	}
+}
+
+func TestProcessThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halfImage(1000, 500)); err != nil {
+		t.Fatalf("png.Encode error: %v", err)
+	}
+
+	processed, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+
+	thumb := processed.Thumbnail
+	if thumb.ContentType != "image/png" || thumb.Width != ThumbnailSize || thumb.Height != ThumbnailSize/2 {
+		t.Errorf("thumbnail = %s %dx%d, expecting image/png %dx%d", thumb.ContentType, thumb.Width, thumb.Height, ThumbnailSize, ThumbnailSize/2)
+	}
+	if processed.Original.Width != 1000 || processed.Original.Height != 500 {
+		t.Errorf("original size changed to %dx%d", processed.Original.Width, processed.Original.Height)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	if _, err := Process([]byte("<html><body>not an image</body></html>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Process(html) returned %v, expecting ErrUnsupportedType", err)
+	}
+
+	// 1x1 png의 IHDR 크기만 10000x10000으로 바꿔서 디코딩 전에 거부되는지 확인
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("png.Encode error: %v", err)
+	}
+	data := buf.Bytes()
+	// 8 byte 시그니처 + 4 byte 길이 + "IHDR" 뒤에 width, height
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := Process(data); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Process(huge png) returned %v, expecting ErrTooManyPixels", err)
+	}
+}
+
+// 크기가 w x h인 프레임 n개로 된 움직이는 gif
+func animatedGIF(t *testing.T, w, h, n int) []byte {
+	t.Helper()
+	frame := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+	g := &gif.GIF{}
+	for i := 0; i < n; i++ {
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 1)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("gif.EncodeAll error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessGIFLimits(t *testing.T) {
+	processed, err := Process(animatedGIF(t, 40, 30, 3))
+	if err != nil {
+		t.Fatalf("Process(small gif) error: %v", err)
+	}
+	if g, err := gif.DecodeAll(bytes.NewReader(processed.Original.Data)); err != nil || len(g.Image) != 3 {
+		t.Errorf("expecting the 3 frames to be kept, got %v", err)
+	}
+
+	if _, err := Process(animatedGIF(t, 4, 4, MaxGIFFrames+1)); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Process(too many frames) returned %v, expecting ErrTooManyPixels", err)
+	}
+
+	// 화면 크기는 MaxPixels 이하지만 전체 화면 프레임을 반복해서 프레임 픽셀 수 합이 제한을 넘는 gif
+	// 프레임 block을 복사해서 만든다 (인코딩할 때 큰 이미지를 여러 장 만들지 않도록)
+	data := animatedGIF(t, 4000, 4000, 1)
+	start := bytes.IndexByte(data[13+3*256:], 0x2C) + 13 + 3*256
+	block := data[start : len(data)-1]
+	bomb := append([]byte{}, data[:start]...)
+	for i := 0; i < MaxGIFTotalPixels/(4000*4000)+1; i++ {
+		bomb = append(bomb, block...)
+	}
+	bomb = append(bomb, 0x3B)
+	if _, err := Process(bomb); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Process(gif bomb) returned %v, expecting ErrTooManyPixels", err)
+	}
+}
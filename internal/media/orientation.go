@@ -0,0 +1,122 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// jpeg의 EXIF(APP1 segment)에서 orientation 태그(0x0112) 값을 읽는 함수
+// EXIF가 없거나 읽을 수 없으면 기본값 1(회전 없음) 반환
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// SOS(0xDA) 이후로는 압축된 이미지 데이터이므로 EXIF가 없다
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// EXIF의 TIFF 구조에서 IFD0의 orientation 값을 찾는 함수
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// tag 0x0112, type 3(SHORT)
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// EXIF orientation 값대로 이미지를 회전, 반전시켜 보이는 방향 그대로의 이미지를 반환
+// https://exiftool.org/TagNames/EXIF.html (0x0112 Orientation)
+func applyOrientation(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	// 5~8은 90도 회전이 들어가므로 가로 세로가 바뀐다
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// 결과 픽셀 (x, y)에 대응하는 원본 픽셀 (sx, sy)
+			var sx, sy int
+			switch orientation {
+			case 2: // 좌우 반전
+				sx, sy = w-1-x, y
+			case 3: // 180도 회전
+				sx, sy = w-1-x, h-1-y
+			case 4: // 상하 반전
+				sx, sy = x, h-1-y
+			case 5: // 좌상-우하 대각선 기준 반전
+				sx, sy = y, x
+			case 6: // 시계 방향 90도 회전
+				sx, sy = y, h-1-x
+			case 7: // 우상-좌하 대각선 기준 반전
+				sx, sy = w-1-y, h-1-x
+			case 8: // 반시계 방향 90도 회전
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], rgba.Pix[rgba.PixOffset(sx, sy):rgba.PixOffset(sx, sy)+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,343 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paokimsiwoong/chirpy/internal/dbutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// 모든 metric 이름 앞에 붙는 namespace
+const namespace = "chirpy"
+
+// ServeMux에 등록된 pattern이 없는 요청(404 등)의 route label
+// 요청 path를 그대로 쓰면 label 값이 무한히 늘어나므로 하나로 묶는다
+const unmatchedRoute = "unmatched"
+
+// 서버의 모든 metric을 담는 registry와 collector들
+// /metrics(Prometheus text format)와 /admin/metrics(html) 둘 다 Registry를 읽는다
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+
+	// 만들어진 chirp 수 (status label : draft, scheduled, published)
+	ChirpsCreated *prometheus.CounterVec
+	// 로그인 성공, 실패 수
+	Logins        prometheus.Counter
+	LoginFailures prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by sqlc query name.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"query"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Database queries that returned an error, by sqlc query name.",
+		}, []string{"query"}),
+		ChirpsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chirps_created_total",
+			Help:      "Chirps created, by initial status.",
+		}, []string{"status"}),
+		Logins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Successful logins.",
+		}),
+		LoginFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Logins rejected because of a wrong email or password.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.queryErrors,
+		m.ChirpsCreated,
+		m.Logins,
+		m.LoginFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// /metrics handler : Registry를 Prometheus text format으로 보낸다
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// database/sql 연결 pool 상태 (열린 연결 수, 대기 시간 등)를 등록하는 함수
+func (m *Metrics) RegisterDBStats(db *sql.DB) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// 실시간 연결 수를 등록하는 함수 : counts는 scrape할 때마다 호출된다
+func (m *Metrics) RegisterStreamConnections(counts func() (sse, websocket int)) {
+	for _, transport := range []string{"sse", "websocket"} {
+		m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "stream_connections",
+			Help:        "Open realtime connections by transport.",
+			ConstLabels: prometheus.Labels{"transport": transport},
+		}, func() float64 {
+			sse, websocket := counts()
+			if transport == "sse" {
+				return float64(sse)
+			}
+			return float64(websocket)
+		}))
+	}
+}
+
+// 요청 수와 처리 시간을 기록하는 middleware (ServeMux 전체를 감싼다)
+// route label은 요청 path가 아니라 ServeMux가 고른 pattern (ex: /api/chirps/{chirpID})
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r)
+
+		// ServeMux는 받은 *http.Request에 고른 pattern을 저장하므로 handler가 끝난 뒤 읽을 수 있다
+		labels := prometheus.Labels{
+			"method": r.Method,
+			"route":  routeLabel(r.Pattern),
+			"status": strconv.Itoa(sw.status()),
+		}
+		m.requests.With(labels).Inc()
+		m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// 요청 metric을 모두 지우는 함수 (PLATFORM=dev의 /admin/reset 용)
+func (m *Metrics) ResetRequests() {
+	m.requests.Reset()
+	m.requestDuration.Reset()
+}
+
+// route의 요청 수 합계 (method, status 무관)
+func (m *Metrics) RouteRequests(route string) (float64, error) {
+	families, err := m.Registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != namespace+"_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if labelValue(metric, "route") == route {
+				total += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return total, nil
+}
+
+// html 등으로 보여주기 위해 단순화한 metric 하나
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Labels는 `key="value",...` 형태 (label이 없으면 빈 문자열)
+type Sample struct {
+	Name   string
+	Labels string
+	Value  float64
+}
+
+// Registry의 현재 값들을 이름 순으로 반환하는 함수
+// histogram, summary는 _count와 _sum만 포함한다
+func (m *Metrics) Families() ([]Family, error) {
+	families, err := m.Registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Family, 0, len(families))
+	for _, family := range families {
+		f := Family{
+			Name: family.GetName(),
+			Help: family.GetHelp(),
+			Type: strings.ToLower(family.GetType().String()),
+		}
+		for _, metric := range family.GetMetric() {
+			labels := formatLabels(metric.GetLabel())
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				f.Samples = append(f.Samples, Sample{Name: f.Name, Labels: labels, Value: metric.GetCounter().GetValue()})
+			case dto.MetricType_GAUGE:
+				f.Samples = append(f.Samples, Sample{Name: f.Name, Labels: labels, Value: metric.GetGauge().GetValue()})
+			case dto.MetricType_HISTOGRAM:
+				h := metric.GetHistogram()
+				f.Samples = append(f.Samples,
+					Sample{Name: f.Name + "_count", Labels: labels, Value: float64(h.GetSampleCount())},
+					Sample{Name: f.Name + "_sum", Labels: labels, Value: h.GetSampleSum()},
+				)
+			case dto.MetricType_SUMMARY:
+				s := metric.GetSummary()
+				f.Samples = append(f.Samples,
+					Sample{Name: f.Name + "_count", Labels: labels, Value: float64(s.GetSampleCount())},
+					Sample{Name: f.Name + "_sum", Labels: labels, Value: s.GetSampleSum()},
+				)
+			case dto.MetricType_UNTYPED:
+				f.Samples = append(f.Samples, Sample{Name: f.Name, Labels: labels, Value: metric.GetUntyped().GetValue()})
+			}
+		}
+		result = append(result, f)
+	}
+	slices.SortFunc(result, func(a, b Family) int { return strings.Compare(a.Name, b.Name) })
+	return result, nil
+}
+
+// 쿼리 시간과 에러 수를 기록하는 DBTX를 반환하는 함수
+// database.New(m.WrapDB(db)), database.New(m.WrapDB(tx))처럼 사용
+// 쿼리 label은 dbutil.QueryName (sqlc 쿼리가 아니면 "other")
+func (m *Metrics) WrapDB(db dbutil.DBTX) dbutil.DBTX {
+	return instrumentedDB{db: db, m: m}
+}
+
+type instrumentedDB struct {
+	db dbutil.DBTX
+	m  *Metrics
+}
+
+func (d instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := d.db.ExecContext(ctx, query, args...)
+	d.m.observeQuery(query, start, err)
+	return res, err
+}
+
+func (d instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, query)
+}
+
+// rows를 읽는 시간은 포함되지 않는다 (쿼리 실행 후 첫 응답까지)
+func (d instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	d.m.observeQuery(query, start, err)
+	return rows, err
+}
+
+// *sql.Row의 에러는 Scan에서야 알 수 있으므로 시간만 기록
+func (d instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := d.db.QueryRowContext(ctx, query, args...)
+	d.m.observeQuery(query, start, nil)
+	return row
+}
+
+func (m *Metrics) observeQuery(query string, start time.Time, err error) {
+	name := dbutil.QueryName(query)
+	m.queryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.queryErrors.WithLabelValues(name).Inc()
+	}
+}
+
+// ServeMux pattern("GET /api/chirps/{chirpID}")에서 method를 뺀 route label
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func formatLabels(labels []*dto.LabelPair) string {
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+	}
+	return strings.Join(parts, ",")
+}
+
+// handler가 보낸 status code를 기록하는 ResponseWriter
+// Unwrap으로 원래 ResponseWriter를 돌려주므로 http.ResponseController(SSE flush)와
+// websocket hijack은 그대로 동작한다
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	// 103 Early Hints 같은 1xx는 최종 status가 아니다 (101 Switching Protocols는 websocket의 최종 응답)
+	if w.code == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// handler가 아무것도 쓰지 않았으면 net/http가 200을 보낸다
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
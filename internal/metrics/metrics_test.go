@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/paokimsiwoong/chirpy/internal/dbutil"
+)
+
+func TestMiddlewareRecordsRoutePattern(t *testing.T) {
+	m := New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	handler := m.Middleware(mux)
+
+	for _, target := range []string{"/api/chirps/1", "/api/chirps/2", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/chirps", nil))
+
+	cases := map[string]float64{
+		"/api/chirps/{chirpID}": 2,
+		"/api/chirps":           1,
+		"unmatched":             1,
+		"/api/chirps/1":         0,
+	}
+	for route, expected := range cases {
+		actual, err := m.RouteRequests(route)
+		if err != nil {
+			t.Fatalf("RouteRequests: %v", err)
+		}
+		if actual != expected {
+			t.Errorf("RouteRequests(%q) = %v, expecting %v", route, actual, expected)
+		}
+	}
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		`chirpy_http_requests_total{method="GET",route="/api/chirps/{chirpID}",status="404"} 2`,
+		`chirpy_http_requests_total{method="POST",route="/api/chirps",status="200"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("scrape is missing %q", line)
+		}
+	}
+
+	m.ResetRequests()
+	if actual, _ := m.RouteRequests("/api/chirps"); actual != 0 {
+		t.Errorf("RouteRequests after reset = %v, expecting 0", actual)
+	}
+}
+
+func TestMiddlewareKeepsResponseController(t *testing.T) {
+	m := New()
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("event"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !rec.Flushed {
+		t.Error("expecting the underlying writer to be flushed")
+	}
+}
+
+type fakeDB struct {
+	dbutil.DBTX
+	err error
+}
+
+func (d fakeDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, d.err
+}
+
+func TestWrapDB(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+
+	m.WrapDB(fakeDB{}).ExecContext(ctx, "-- name: CreateUser :one\n")
+	m.WrapDB(fakeDB{err: errors.New("boom")}).ExecContext(ctx, "-- name: CreateUser :one\n")
+	m.WrapDB(fakeDB{err: sql.ErrNoRows}).ExecContext(ctx, "-- name: GetUser :one\n")
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		`chirpy_db_query_duration_seconds_count{query="CreateUser"} 2`,
+		`chirpy_db_query_duration_seconds_count{query="GetUser"} 1`,
+		`chirpy_db_query_errors_total{query="CreateUser"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("scrape is missing %q", line)
+		}
+	}
+	if strings.Contains(body, `chirpy_db_query_errors_total{query="GetUser"}`) {
+		t.Error("sql.ErrNoRows should not count as a query error")
+	}
+}
+
+func TestFamilies(t *testing.T) {
+	m := New()
+	m.RegisterStreamConnections(func() (int, int) { return 3, 5 })
+	m.ChirpsCreated.WithLabelValues("published").Inc()
+
+	families, err := m.Families()
+	if err != nil {
+		t.Fatalf("Families: %v", err)
+	}
+
+	values := map[string]float64{}
+	for i, f := range families {
+		if i > 0 && families[i-1].Name > f.Name {
+			t.Errorf("families are not sorted: %q before %q", families[i-1].Name, f.Name)
+		}
+		for _, s := range f.Samples {
+			values[s.Name+"{"+s.Labels+"}"] = s.Value
+		}
+	}
+
+	cases := map[string]float64{
+		`chirpy_stream_connections{transport="sse"}`:       3,
+		`chirpy_stream_connections{transport="websocket"}`: 5,
+		`chirpy_chirps_created_total{status="published"}`:  1,
+	}
+	for key, expected := range cases {
+		if actual, ok := values[key]; !ok || actual != expected {
+			t.Errorf("%s = %v (found %v), expecting %v", key, actual, ok, expected)
+		}
+	}
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("reading scrape: %v", err)
+	}
+	return string(body)
+}
@@ -0,0 +1,286 @@
+// polka webhook을 흉내내서 chirpy 서버로 보내는 패키지
+// cmd/polka-sim과 handler 테스트에서 같은 시나리오를 사용한다
+package polkasim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+)
+
+// polka 구독 event
+const (
+	EventUpgraded      = "user.upgraded"
+	EventRenewed       = "user.renewed"
+	EventPaymentFailed = "user.payment_failed"
+	EventDowngraded    = "user.downgraded"
+	EventRefunded      = "user.refunded"
+)
+
+// 요청에 서명을 붙이는 방식
+type Signing int
+
+const (
+	// 올바른 키와 현재 시간으로 서명
+	SignValid Signing = iota
+	// 서명 header 없음
+	SignMissing
+	// 다른 키로 서명
+	SignWrongKey
+	// 올바른 키로 서명했지만 서명한 뒤 body가 바뀜
+	SignTampered
+	// 올바른 키로 서명했지만 timestamp가 오래됨 (다시 보낸 요청)
+	SignStale
+)
+
+// 서버가 받아주지 않아야 하는 timestamp (서버 허용 범위는 5분)
+const staleAge = time.Hour
+
+// webhook body
+type Event struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  Data   `json:"data"`
+}
+
+type Data struct {
+	UserID           string     `json:"user_id"`
+	Plan             string     `json:"plan,omitempty"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end,omitempty"`
+}
+
+// 시나리오의 요청 하나와 기대하는 응답 코드
+type Step struct {
+	Name    string
+	Event   Event
+	Signing Signing
+	// true면 ApiKey header에 틀린 키
+	WrongAPIKey bool
+	WantStatus  int
+}
+
+// 순서대로 보내는 요청들
+type Scenario struct {
+	Name        string
+	Description string
+	Steps       []Step
+}
+
+// 한 요청의 결과
+type Result struct {
+	Step   Step
+	Status int
+	Body   string
+}
+
+func (r Result) OK() bool {
+	return r.Status == r.Step.WantStatus
+}
+
+// 새 event id ("evt_" + uuid)
+func NewEventID() string {
+	return "evt_" + uuid.NewString()
+}
+
+func newEvent(event string, userID uuid.UUID, periodEnd *time.Time) Event {
+	return Event{
+		ID:    NewEventID(),
+		Event: event,
+		Data: Data{
+			UserID:           userID.String(),
+			CurrentPeriodEnd: periodEnd,
+		},
+	}
+}
+
+// userID 유저를 대상으로 하는 시나리오 목록
+// 각 시나리오는 앞 시나리오가 남긴 구독 상태와 상관없이 같은 결과가 나오도록 다운그레이드, 업그레이드부터 시작한다
+// (서버는 처리할 수 없는 event도 다시 보내지 않도록 204로 응답하므로 기대 코드는 대부분 204)
+func Scenarios(userID uuid.UUID, now time.Time) []Scenario {
+	nextMonth := now.AddDate(0, 1, 0)
+	twoMonths := now.AddDate(0, 2, 0)
+
+	upgrade := newEvent(EventUpgraded, userID, &nextMonth)
+	ok := http.StatusNoContent
+	unauthorized := http.StatusUnauthorized
+
+	return []Scenario{
+		{
+			Name:        "lifecycle",
+			Description: "upgrade, renew, payment failure, renew, downgrade",
+			Steps: []Step{
+				{Name: "upgrade", Event: newEvent(EventUpgraded, userID, &nextMonth), WantStatus: ok},
+				{Name: "renew", Event: newEvent(EventRenewed, userID, &twoMonths), WantStatus: ok},
+				{Name: "payment failed", Event: newEvent(EventPaymentFailed, userID, nil), WantStatus: ok},
+				{Name: "renew after retry", Event: newEvent(EventRenewed, userID, &twoMonths), WantStatus: ok},
+				{Name: "downgrade", Event: newEvent(EventDowngraded, userID, nil), WantStatus: ok},
+			},
+		},
+		{
+			Name:        "refund",
+			Description: "upgrade then refund (membership ends immediately)",
+			Steps: []Step{
+				{Name: "upgrade", Event: newEvent(EventUpgraded, userID, &nextMonth), WantStatus: ok},
+				{Name: "refund", Event: newEvent(EventRefunded, userID, nil), WantStatus: ok},
+			},
+		},
+		{
+			Name:        "duplicates",
+			Description: "the same upgrade event delivered three times is processed once",
+			Steps: []Step{
+				{Name: "upgrade", Event: upgrade, WantStatus: ok},
+				{Name: "upgrade (retry)", Event: upgrade, WantStatus: ok},
+				{Name: "upgrade (retry)", Event: upgrade, WantStatus: ok},
+			},
+		},
+		{
+			Name:        "out-of-order",
+			Description: "renew and downgrade arriving before the upgrade they follow",
+			Steps: []Step{
+				{Name: "refund previous", Event: newEvent(EventRefunded, userID, nil), WantStatus: ok},
+				{Name: "renew before upgrade", Event: newEvent(EventRenewed, userID, &twoMonths), WantStatus: ok},
+				{Name: "downgrade before upgrade", Event: newEvent(EventDowngraded, userID, nil), WantStatus: ok},
+				{Name: "upgrade", Event: newEvent(EventUpgraded, userID, &nextMonth), WantStatus: ok},
+			},
+		},
+		{
+			Name:        "bad-signatures",
+			Description: "requests the server must reject without processing",
+			Steps: []Step{
+				{Name: "wrong api key", Event: newEvent(EventUpgraded, userID, nil), WrongAPIKey: true, WantStatus: unauthorized},
+				{Name: "missing signature", Event: newEvent(EventUpgraded, userID, nil), Signing: SignMissing, WantStatus: unauthorized},
+				{Name: "wrong signing key", Event: newEvent(EventUpgraded, userID, nil), Signing: SignWrongKey, WantStatus: unauthorized},
+				{Name: "tampered body", Event: newEvent(EventUpgraded, userID, nil), Signing: SignTampered, WantStatus: unauthorized},
+				{Name: "stale timestamp", Event: newEvent(EventUpgraded, userID, nil), Signing: SignStale, WantStatus: unauthorized},
+			},
+		},
+		{
+			Name:        "unknown-user",
+			Description: "upgrade for a user that doesn't exist",
+			Steps: []Step{
+				{Name: "upgrade", Event: newEvent(EventUpgraded, uuid.New(), nil), WantStatus: http.StatusNotFound},
+			},
+		},
+		{
+			Name:        "ignored-event",
+			Description: "events chirpy doesn't handle are acknowledged",
+			Steps: []Step{
+				{Name: "user.created", Event: newEvent("user.created", userID, nil), WantStatus: ok},
+			},
+		},
+	}
+}
+
+// 이름으로 시나리오를 찾는 함수
+func Find(scenarios []Scenario, name string) (Scenario, bool) {
+	for _, s := range scenarios {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// webhook을 보내는 client
+type Client struct {
+	// webhook endpoint 전체 url (ex: http://localhost:8080/api/polka/webhooks)
+	URL string
+	// ApiKey header 값이자 서명 키
+	Key  string
+	HTTP *http.Client
+	// 서명 timestamp (nil이면 time.Now)
+	Now func() time.Time
+}
+
+func NewClient(url, key string) *Client {
+	return &Client{
+		URL:  url,
+		Key:  key,
+		HTTP: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// step 하나를 보내고 응답 코드와 body를 반환하는 함수
+func (c *Client) Send(ctx context.Context, step Step) (Result, error) {
+	body, err := json.Marshal(step.Event)
+	if err != nil {
+		return Result{}, fmt.Errorf("error encoding event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	apiKey := c.Key
+	if step.WrongAPIKey {
+		apiKey = "wrong-" + c.Key
+	}
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+
+	switch step.Signing {
+	case SignValid:
+		auth.SetWebhookSignature(req.Header, c.Key, c.now(), body)
+	case SignMissing:
+	case SignWrongKey:
+		auth.SetWebhookSignature(req.Header, "wrong-"+c.Key, c.now(), body)
+	case SignTampered:
+		auth.SetWebhookSignature(req.Header, c.Key, c.now(), body)
+		body = bytes.Replace(body, []byte(step.Event.Data.UserID), []byte(uuid.NewString()), 1)
+	case SignStale:
+		auth.SetWebhookSignature(req.Header, c.Key, c.now().Add(-staleAge), body)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return Result{}, fmt.Errorf("error reading response: %w", err)
+	}
+
+	return Result{
+		Step:   step,
+		Status: resp.StatusCode,
+		Body:   strings.TrimSpace(string(resBody)),
+	}, nil
+}
+
+// 시나리오의 step들을 순서대로 보내는 함수
+// 요청을 보내지 못하면 그때까지의 결과와 error를 반환
+func (c *Client) Run(ctx context.Context, scenario Scenario) ([]Result, error) {
+	results := make([]Result, 0, len(scenario.Steps))
+	for _, step := range scenario.Steps {
+		result, err := c.Send(ctx, step)
+		if err != nil {
+			return results, fmt.Errorf("%s: %s: %w", scenario.Name, step.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
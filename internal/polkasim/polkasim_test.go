@@ -0,0 +1,117 @@
+package polkasim
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+)
+
+// 서명만 확인하는 테스트용 webhook 서버 : 받은 event들을 순서대로 기록
+func testServer(t *testing.T, key string, received *[]Event) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, err := auth.GetAPIKey(r.Header)
+		if err != nil || !auth.MatchAPIKey(apiKey, []string{key}) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := auth.VerifyWebhookSignature(r.Header, body, []string{key}, time.Now(), 5*time.Minute); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		event := Event{}
+		if err := json.Unmarshal(body, &event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*received = append(*received, event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestSendSigning(t *testing.T) {
+	const key = "test-polka-key"
+	var received []Event
+	server := testServer(t, key, &received)
+	defer server.Close()
+
+	client := NewClient(server.URL, key)
+	userID := uuid.New()
+
+	scenario, ok := Find(Scenarios(userID, time.Now()), "bad-signatures")
+	if !ok {
+		t.Fatalf("bad-signatures scenario not found")
+	}
+	results, err := client.Run(context.Background(), scenario)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	for _, result := range results {
+		if !result.OK() {
+			t.Errorf("%s: status %d, expecting %d", result.Step.Name, result.Status, result.Step.WantStatus)
+		}
+	}
+	if len(received) != 0 {
+		t.Errorf("server accepted %d events with bad signatures", len(received))
+	}
+
+	result, err := client.Send(context.Background(), Step{Name: "valid", Event: newEvent(EventUpgraded, userID, nil)})
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if result.Status != http.StatusNoContent {
+		t.Errorf("valid signature: status %d, expecting %d", result.Status, http.StatusNoContent)
+	}
+	if len(received) != 1 || received[0].Data.UserID != userID.String() {
+		t.Errorf("received %+v, expecting one event for user %s", received, userID)
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	userID := uuid.New()
+	scenarios := Scenarios(userID, time.Now())
+
+	names := make(map[string]bool)
+	for _, s := range scenarios {
+		if names[s.Name] {
+			t.Errorf("duplicate scenario name %q", s.Name)
+		}
+		names[s.Name] = true
+		if len(s.Steps) == 0 {
+			t.Errorf("scenario %q has no steps", s.Name)
+		}
+	}
+
+	duplicates, ok := Find(scenarios, "duplicates")
+	if !ok {
+		t.Fatalf("duplicates scenario not found")
+	}
+	for _, step := range duplicates.Steps[1:] {
+		if step.Event.ID != duplicates.Steps[0].Event.ID {
+			t.Errorf("duplicates: step %q has event id %s, expecting %s", step.Name, step.Event.ID, duplicates.Steps[0].Event.ID)
+		}
+	}
+
+	// 시나리오마다 event id가 달라야 서버가 새 event로 처리한다
+	seen := make(map[string]string)
+	for _, s := range scenarios {
+		for _, step := range s.Steps {
+			if other, ok := seen[step.Event.ID]; ok && other != s.Name {
+				t.Errorf("event id %s reused in %q and %q", step.Event.ID, other, s.Name)
+			}
+			seen[step.Event.ID] = s.Name
+		}
+	}
+}
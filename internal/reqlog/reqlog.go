@@ -0,0 +1,184 @@
+package reqlog
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 요청 id를 주고받는 header
+// 요청에 있으면(프록시, 다른 서비스가 붙인 id) 그대로 쓰고 없으면 새로 만들어서 response header에 넣는다
+const Header = "X-Request-ID"
+
+// 받아들일 요청 id 최대 길이 (더 길거나 이상한 문자가 있으면 새로 만든다)
+const maxIDLength = 128
+
+type contextKey struct{}
+
+// 요청 하나의 logger와 로그인한 유저 (handler가 SetUserID로 채운다)
+type requestState struct {
+	mu     sync.Mutex
+	id     string
+	logger *slog.Logger
+	userID uuid.NullUUID
+}
+
+// 요청 id를 정하고, 요청 id가 붙은 logger를 context에 넣고, 요청이 끝나면 한 줄로 기록하는 middleware
+// route는 ServeMux가 고른 pattern이므로 ServeMux를 감싸야 한다
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(Header)
+		if !validID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(Header, id)
+
+		state := &requestState{id: id, logger: logger.With("request_id", id)}
+		// tracing middleware 안에서 실행되면 trace id도 붙여서 로그에서 trace를 찾을 수 있게 한다
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			state.logger = state.logger.With("trace_id", sc.TraceID().String())
+		}
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, state))
+		sw := &statusWriter{ResponseWriter: w, state: state}
+
+		next.ServeHTTP(sw, r)
+
+		status := sw.status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", r.Pattern),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes", sw.bytes),
+		}
+		if userID := state.user(); userID.Valid {
+			attrs = append(attrs, slog.String("user_id", userID.UUID.String()))
+		}
+		state.logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// context의 요청 logger (요청 id와 로그인한 유저 id 포함)
+// Middleware 밖의 context(background worker 등)이면 slog.Default()
+func Logger(ctx context.Context) *slog.Logger {
+	state, ok := ctx.Value(contextKey{}).(*requestState)
+	if !ok {
+		return slog.Default()
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.logger
+}
+
+// ResponseWriter로 요청 logger를 찾는 함수 (context를 받지 않는 respondWithError 등에서 사용)
+// 다른 middleware가 감싼 ResponseWriter는 Unwrap으로 따라간다
+func LoggerFromResponse(w http.ResponseWriter) *slog.Logger {
+	for {
+		switch rw := w.(type) {
+		case *statusWriter:
+			rw.state.mu.Lock()
+			defer rw.state.mu.Unlock()
+			return rw.state.logger
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return slog.Default()
+		}
+	}
+}
+
+// context의 요청 id (Middleware 밖이면 빈 문자열)
+func ID(ctx context.Context) string {
+	state, ok := ctx.Value(contextKey{}).(*requestState)
+	if !ok {
+		return ""
+	}
+	return state.id
+}
+
+// 인증이 끝난 handler가 로그인한 유저를 알려주는 함수
+// 이후의 Logger와 요청 기록에 user_id가 붙는다
+func SetUserID(ctx context.Context, userID uuid.UUID) {
+	state, ok := ctx.Value(contextKey{}).(*requestState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.userID.Valid && state.userID.UUID == userID {
+		return
+	}
+	state.userID = uuid.NullUUID{UUID: userID, Valid: true}
+	state.logger = state.logger.With("user_id", userID.String())
+}
+
+func (s *requestState) user() uuid.NullUUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userID
+}
+
+// 로그와 header에 그대로 들어가므로 보이는 ASCII 문자 일부만 허용
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// status code와 보낸 byte 수를 기록하는 ResponseWriter
+// Unwrap으로 원래 ResponseWriter를 돌려주므로 http.ResponseController와 websocket hijack은 그대로 동작한다
+type statusWriter struct {
+	http.ResponseWriter
+	state *requestState
+	code  int
+	bytes int64
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	// 1xx는 최종 status가 아니다 (101 Switching Protocols는 websocket의 최종 응답)
+	if w.code == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
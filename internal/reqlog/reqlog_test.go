@@ -0,0 +1,144 @@
+package reqlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 요청 하나를 보내고 기록된 JSON 로그 줄들을 반환
+func serve(t *testing.T, handler func(http.ResponseWriter, *http.Request), req *http.Request) (*httptest.ResponseRecorder, []map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", handler)
+
+	rec := httptest.NewRecorder()
+	Middleware(logger, mux).ServeHTTP(rec, req)
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		lines = append(lines, entry)
+	}
+	return rec, lines
+}
+
+func TestMiddlewareAssignsRequestID(t *testing.T) {
+	var fromContext string
+	rec, lines := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		fromContext = ID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}, httptest.NewRequest(http.MethodGet, "/api/chirps/1", nil))
+
+	id := rec.Header().Get(Header)
+	if _, err := uuid.Parse(id); err != nil {
+		t.Fatalf("expecting a generated uuid request id, got %q", id)
+	}
+	if fromContext != id {
+		t.Errorf("ID(ctx) = %q, expecting %q", fromContext, id)
+	}
+
+	if len(lines) != 1 {
+		t.Fatalf("expecting 1 log line, got %d", len(lines))
+	}
+	cases := map[string]any{
+		"msg":        "request",
+		"request_id": id,
+		"method":     "GET",
+		"route":      "GET /api/chirps/{chirpID}",
+		"path":       "/api/chirps/1",
+		"status":     float64(http.StatusTeapot),
+	}
+	for key, expected := range cases {
+		if lines[0][key] != expected {
+			t.Errorf("log %s = %v, expecting %v", key, lines[0][key], expected)
+		}
+	}
+	if _, ok := lines[0]["user_id"]; ok {
+		t.Error("expecting no user_id for an anonymous request")
+	}
+}
+
+func TestMiddlewarePropagatesRequestID(t *testing.T) {
+	cases := map[string]bool{
+		"abc-123_x.y:z":             true,
+		"":                          false,
+		"has space":                 false,
+		"line\nbreak":               false,
+		strings.Repeat("a", 129):    false,
+		strings.Repeat("a", 128):    true,
+		"<script>alert(1)</script>": false,
+	}
+	for incoming, kept := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/chirps/1", nil)
+		req.Header.Set(Header, incoming)
+		rec, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {}, req)
+
+		id := rec.Header().Get(Header)
+		if (id == incoming) != kept {
+			t.Errorf("incoming %q: response id %q, expecting kept=%v", incoming, id, kept)
+		}
+	}
+}
+
+func TestSetUserID(t *testing.T) {
+	userID := uuid.New()
+	_, lines := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), userID)
+		Logger(r.Context()).Info("inside handler")
+		LoggerFromResponse(w).Warn("from response writer")
+	}, httptest.NewRequest(http.MethodGet, "/api/chirps/1", nil))
+
+	if len(lines) != 3 {
+		t.Fatalf("expecting 3 log lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line["user_id"] != userID.String() {
+			t.Errorf("log %q user_id = %v, expecting %v", line["msg"], line["user_id"], userID)
+		}
+		if line["request_id"] == nil {
+			t.Errorf("log %q has no request_id", line["msg"])
+		}
+	}
+}
+
+func TestMiddlewareAddsTraceID(t *testing.T) {
+	traceID := trace.TraceID{1, 2, 3}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{4}})
+	req := httptest.NewRequest(http.MethodGet, "/api/chirps/1", nil)
+	req = req.WithContext(trace.ContextWithSpanContext(req.Context(), sc))
+
+	_, lines := serve(t, func(w http.ResponseWriter, r *http.Request) {}, req)
+	if len(lines) != 1 || lines[0]["trace_id"] != traceID.String() {
+		t.Errorf("expecting trace_id %s in %v", traceID, lines)
+	}
+}
+
+func TestOutsideMiddleware(t *testing.T) {
+	ctx := context.Background()
+	if Logger(ctx) != slog.Default() {
+		t.Error("expecting slog.Default() outside of a request")
+	}
+	if LoggerFromResponse(httptest.NewRecorder()) != slog.Default() {
+		t.Error("expecting slog.Default() for an unwrapped ResponseWriter")
+	}
+	if ID(ctx) != "" {
+		t.Error("expecting no request id outside of a request")
+	}
+	// 요청 밖에서 호출해도 panic이 나면 안 된다
+	SetUserID(ctx, uuid.New())
+}
@@ -0,0 +1,222 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/paokimsiwoong/chirpy/internal/dbutil"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// span을 만드는 instrumentation 이름
+const instrumentationName = "github.com/paokimsiwoong/chirpy/internal/tracing"
+
+// 기본 service.name (OTEL_SERVICE_NAME 환경변수가 있으면 그 값)
+const serviceName = "chirpy"
+
+// span 내보내기 방식
+const (
+	// OTLP/HTTP collector로 보낸다 (주소 등은 OTEL_EXPORTER_OTLP_ENDPOINT 등 표준 환경변수)
+	ExporterOTLP = "otlp"
+	// 표준 출력에 span마다 JSON 한 줄 (로컬 실행용)
+	ExporterStdout = "stdout"
+	// 파일에 span마다 JSON 한 줄 (로컬 실행용)
+	ExporterFile = "file"
+	// 보내지 않는다 (trace context 전달만 한다)
+	ExporterNone = "none"
+)
+
+// ExporterFile의 기본 파일
+const DefaultFile = "traces.jsonl"
+
+type Config struct {
+	// ExporterOTLP, ExporterStdout, ExporterFile, ExporterNone 중 하나 (비어있으면 ExporterNone)
+	Exporter string
+	// ExporterFile일 때 span을 추가할 파일 (비어있으면 DefaultFile)
+	File string
+}
+
+var tracer = otel.Tracer(instrumentationName)
+
+// 전역 TracerProvider와 W3C trace context propagator를 설정하는 함수
+// 반환된 shutdown은 아직 보내지 않은 span을 보내고 exporter를 닫는다 (서버 종료 때 호출)
+// 샘플링은 OTEL_TRACES_SAMPLER, OTEL_TRACES_SAMPLER_ARG 환경변수로 바꿀 수 있다 (기본값: 모두 기록)
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	// exporter가 없어도 traceparent, baggage header는 받아서 다음 요청에 넘긴다
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	closeFile := func() error { return nil }
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		path := cfg.File
+		if path == "" {
+			path = DefaultFile
+		}
+		f, openErr := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return nil, fmt.Errorf("error opening trace file: %w", openErr)
+		}
+		closeFile = f.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		closeFile()
+		return nil, fmt.Errorf("error creating trace exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		closeFile()
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeFile())
+	}, nil
+}
+
+// 요청마다 server span을 만드는 middleware (요청의 traceparent header가 있으면 그 trace를 잇는다)
+// span 이름은 mux가 고를 pattern (ex: "GET /api/chirps/{chirpID}"), 없으면 method만
+// next는 mux를 포함한 handler 전체 (mux는 pattern을 찾는 데만 쓴다)
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	route := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(routePath(pattern)))
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	return otelhttp.NewHandler(route, "",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				return pattern
+			}
+			return r.Method
+		}),
+	)
+}
+
+// 나가는 요청에 client span을 만들고 traceparent header를 붙이는 RoundTripper
+// base가 nil이면 http.DefaultTransport
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}
+
+// 요청 밖의 작업(background job 등)을 위한 span을 시작하는 함수
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// span에 에러를 기록하는 함수 (err가 nil이면 아무것도 하지 않는다)
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// sqlc 쿼리마다 그 이름("-- name: GetChirps :many" ==> GetChirps)으로 client span을 만드는 DBTX를 반환하는 함수
+// database.New(tracing.WrapDB(db))처럼 사용
+func WrapDB(db dbutil.DBTX) dbutil.DBTX {
+	return tracedDB{db: db}
+}
+
+type tracedDB struct {
+	db dbutil.DBTX
+}
+
+func (d tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	res, err := d.db.ExecContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return res, err
+}
+
+func (d tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, query)
+}
+
+// span은 쿼리 실행 후 첫 응답까지 (rows를 읽는 시간은 포함되지 않는다)
+func (d tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return rows, err
+}
+
+// *sql.Row의 에러는 Scan에서야 알 수 있으므로 시간만 기록
+func (d tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+	return d.db.QueryRowContext(ctx, query, args...)
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := dbutil.QueryName(query)
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(name),
+			// 쿼리 인자는 넣지 않는다 ($1 등 placeholder만 있는 sqlc 쿼리문)
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// 결과가 없는 것(sql.ErrNoRows)은 에러로 기록하지 않는다
+func recordQueryError(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	RecordError(span, err)
+}
+
+// ServeMux pattern("GET /api/chirps/{chirpID}")에서 method를 뺀 http.route
+func routePath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
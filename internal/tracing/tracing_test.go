@@ -0,0 +1,180 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paokimsiwoong/chirpy/internal/dbutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 전역 TracerProvider는 처음 설정된 것으로 위임이 고정되므로 테스트 전체에서 recorder 하나를 쓴다
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	if _, err := Setup(context.Background(), Config{}); err != nil {
+		panic(err)
+	}
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	os.Exit(m.Run())
+}
+
+func endedSpans(t *testing.T) map[string]sdktrace.ReadOnlySpan {
+	t.Helper()
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	recorder.Reset()
+	return spans
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	mux := http.NewServeMux()
+	var handlerSpan trace.SpanContext
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+	})
+	handler := Middleware(mux, mux)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/chirps/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if handlerSpan.TraceID().String() != traceID {
+		t.Errorf("handler trace id = %s, expecting %s", handlerSpan.TraceID(), traceID)
+	}
+
+	spans := endedSpans(t)
+	span, ok := spans["GET /api/chirps/{chirpID}"]
+	if !ok {
+		t.Fatalf("expecting a span named after the route pattern, got %v", spans)
+	}
+	if actual := attr(span, "http.route"); actual != "/api/chirps/{chirpID}" {
+		t.Errorf("http.route = %q, expecting %q", actual, "/api/chirps/{chirpID}")
+	}
+	if span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("parent span id = %s, expecting the incoming one", span.Parent().SpanID())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/nowhere", nil))
+	if _, ok := endedSpans(t)["POST"]; !ok {
+		t.Error("expecting an unmatched request span named after its method")
+	}
+}
+
+func TestTransportInjectsTraceparent(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "job")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent = %q, expecting trace id %s", traceparent, span.SpanContext().TraceID())
+	}
+	recorder.Reset()
+}
+
+type fakeDB struct {
+	dbutil.DBTX
+	err error
+}
+
+func (d fakeDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, d.err
+}
+
+func TestWrapDB(t *testing.T) {
+	ctx, parent := Start(context.Background(), "request")
+	WrapDB(fakeDB{}).ExecContext(ctx, "-- name: CreateChirp :one\nINSERT INTO chirps")
+	WrapDB(fakeDB{err: errors.New("boom")}).ExecContext(ctx, "-- name: DeleteChirp :exec\nDELETE FROM chirps")
+	WrapDB(fakeDB{err: sql.ErrNoRows}).ExecContext(ctx, "-- name: GetChirp :one\nSELECT")
+	parent.End()
+
+	spans := endedSpans(t)
+	for _, name := range []string{"CreateChirp", "DeleteChirp", "GetChirp"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("expecting a %s span, got %v", name, spans)
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the request span", name)
+		}
+		if span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("%s kind = %v, expecting client", name, span.SpanKind())
+		}
+		if actual := attr(span, "db.operation.name"); actual != name {
+			t.Errorf("%s db.operation.name = %q", name, actual)
+		}
+	}
+	if spans["DeleteChirp"].Status().Code != codes.Error {
+		t.Error("expecting DeleteChirp to be marked as an error")
+	}
+	if spans["GetChirp"].Status().Code == codes.Error {
+		t.Error("sql.ErrNoRows should not mark the span as an error")
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Error("expecting an error for an unknown exporter")
+	}
+}
+
+func TestSetupFileExporter(t *testing.T) {
+	// 다른 테스트가 쓰는 recorder provider로 되돌린다
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, File: path})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	// Setup이 전역 provider를 바꾸므로 span은 이 provider로 직접 만든다
+	_, span := otel.GetTracerProvider().Tracer("test").Start(context.Background(), "file-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading trace file: %v", err)
+	}
+	if !strings.Contains(string(data), `"Name":"file-span"`) {
+		t.Errorf("trace file does not contain the span: %s", data)
+	}
+}
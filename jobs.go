@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// jobs.status 값
+const (
+	jobStatusPending   = "pending"
+	jobStatusRunning   = "running"
+	jobStatusSucceeded = "succeeded"
+	jobStatusDead      = "dead"
+)
+
+const (
+	// 실행할 job을 확인하는 주기
+	jobPollInterval = 2 * time.Second
+	// 한번에 가져오는(동시에 실행하는) 최대 job 수
+	jobBatchSize = 10
+	// job 하나의 최대 실행 시간
+	jobTimeout = time.Minute
+	// 실행 중인 job을 다른 worker가 가져가지 않는 시간 (jobTimeout보다 길어야 한다)
+	jobLease = 5 * time.Minute
+	// 기본 최대 시도 횟수
+	defaultJobMaxAttempts = 5
+	// 재시도 간격 : jobRetryBase * 2^(시도 횟수 - 1), 최대 jobRetryMax
+	jobRetryBase = 10 * time.Second
+	jobRetryMax  = time.Hour
+	// 완료된 job을 보관하는 기간
+	jobRetention = 7 * 24 * time.Hour
+	// jobs.last_error 최대 길이
+	jobErrorMaxLength = 1000
+	// 서버 종료로 중단된 job을 다시 대기시킬 때 기다리는 최대 시간
+	jobReleaseTimeout = 5 * time.Second
+)
+
+// 다시 시도해도 같은 결과인 에러 (ex: payload를 읽을 수 없음) : 바로 dead로 보낸다
+type permanentJobError struct {
+	err error
+}
+
+func (e permanentJobError) Error() string { return e.err.Error() }
+func (e permanentJobError) Unwrap() error { return e.err }
+
+// 다음 시도 시간을 handler가 정하는 에러 (ex: webhook 전송은 자체 backoff 간격을 쓴다)
+type retryJobError struct {
+	err   error
+	after time.Duration
+}
+
+func (e retryJobError) Error() string { return e.err.Error() }
+func (e retryJobError) Unwrap() error { return e.err }
+
+// job의 결과를 저장하는 함수 (저장할 게 없는 job은 nil)
+// job 완료 표시와 같은 트랜잭션의 q를 받으므로 DB 변경은 job이 완료될 때만 커밋된다
+type jobCommit func(ctx context.Context, q *database.Queries) error
+
+// job 종류 하나 : 이름, payload 타입 T, 실행 함수
+// handler는 트랜잭션 밖에서 실행된다 ==> 외부 호출(http 요청, 저장소)이 오래 걸려도 db 연결을 트랜잭션으로 붙잡지 않는다
+// DB 변경은 반환한 jobCommit에서 한다
+type jobKind[T any] struct {
+	name        string
+	maxAttempts int
+	handle      func(cfg *apiConfig, ctx context.Context, payload T) (jobCommit, error)
+}
+
+// 저장된 payload로 job을 실행하는 interface (jobKind[T]가 구현)
+type jobRunner interface {
+	run(ctx context.Context, cfg *apiConfig, payload json.RawMessage) (jobCommit, error)
+}
+
+// 이름 ==> 실행 함수 (newJobKind로 등록)
+var jobRegistry = map[string]jobRunner{}
+
+// job 종류를 만들고 worker가 실행할 수 있도록 등록하는 함수 (패키지 변수 초기화에서 호출)
+// maxAttempts가 0 이하이면 defaultJobMaxAttempts
+func newJobKind[T any](name string, maxAttempts int, handle func(cfg *apiConfig, ctx context.Context, payload T) (jobCommit, error)) jobKind[T] {
+	if _, ok := jobRegistry[name]; ok {
+		panic("duplicate job kind " + name)
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultJobMaxAttempts
+	}
+
+	kind := jobKind[T]{name: name, maxAttempts: maxAttempts, handle: handle}
+	jobRegistry[name] = kind
+	return kind
+}
+
+// job을 대기열에 추가하는 함수 (DB 변경과 같은 트랜잭션의 q로 호출 ==> 트랜잭션이 커밋되어야 실행된다)
+func (k jobKind[T]) enqueue(ctx context.Context, q *database.Queries, payload T) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding job payload: %w", err)
+	}
+
+	_, err = q.EnqueueJob(ctx, database.EnqueueJobParams{
+		Kind:        k.name,
+		Payload:     raw,
+		MaxAttempts: int32(k.maxAttempts),
+	})
+	return err
+}
+
+func (k jobKind[T]) run(ctx context.Context, cfg *apiConfig, raw json.RawMessage) (jobCommit, error) {
+	var payload T
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, permanentJobError{fmt.Errorf("error decoding job payload: %w", err)}
+	}
+	return k.handle(cfg, ctx, payload)
+}
+
+// 대기열의 job들을 실행하는 background worker
+// ctx가 취소되면 종료 (실행 중이던 job은 다시 대기시킨다)
+func (cfg *apiConfig) runJobWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.runDueJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 실행할 job이 남아있지 않을 때까지 batch 단위로 동시에 실행하는 함수
+func (cfg *apiConfig) runDueJobs(ctx context.Context) {
+	for {
+		jobs, err := cfg.ptrDB.ClaimJobs(ctx, database.ClaimJobsParams{
+			LockedUntil: time.Now().Add(jobLease),
+			Limit:       jobBatchSize,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error claiming jobs", "error", err)
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, job := range jobs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cfg.runJob(ctx, job)
+			}()
+		}
+		wg.Wait()
+
+		if len(jobs) < jobBatchSize {
+			return
+		}
+	}
+}
+
+// job 하나를 실행하고 실패하면 다시 시도할 시간(또는 dead)을 기록하는 함수
+func (cfg *apiConfig) runJob(ctx context.Context, job database.Job) {
+	// job 실행과 그 안의 쿼리들을 한 trace로 묶는다
+	ctx, span := tracing.Start(ctx, "job "+job.Kind,
+		attribute.String("job.id", job.ID.String()),
+		attribute.Int("job.attempt", int(job.Attempts)),
+	)
+	defer span.End()
+
+	err := cfg.executeJob(ctx, job)
+	tracing.RecordError(span, err)
+	if err == nil {
+		return
+	}
+	// 서버 종료로 중단된 job은 실패로 세지 않고 다시 대기시킨다
+	// (마지막 시도가 중단된 job이 다음 실행에서 attempts > max_attempts로 바로 dead가 되지 않도록)
+	if ctx.Err() != nil {
+		cfg.releaseJob(ctx, job)
+		return
+	}
+
+	params := database.FailJobParams{
+		LastError: truncateUTF8(err.Error(), jobErrorMaxLength),
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+	}
+	var permanent permanentJobError
+	if !errors.As(err, &permanent) && job.Attempts < job.MaxAttempts {
+		delay := jobRetryDelay(int(job.Attempts))
+		var retry retryJobError
+		if errors.As(err, &retry) {
+			delay = retry.after
+		}
+		params.RunAt.Time = time.Now().Add(delay)
+		params.RunAt.Valid = true
+		slog.Warn("Error running job, retrying later", "job_id", job.ID, "job_kind", job.Kind, "attempt", job.Attempts, "max_attempts", job.MaxAttempts, "error", err)
+	} else {
+		slog.Error("Error running job, moving to dead jobs", "job_id", job.ID, "job_kind", job.Kind, "attempt", job.Attempts, "error", err)
+	}
+
+	if _, err := cfg.ptrDB.FailJob(ctx, params); err != nil && ctx.Err() == nil {
+		slog.Error("Error recording job failure", "job_id", job.ID, "error", err)
+	}
+}
+
+// 서버 종료로 중단된 job의 이번 시도를 되돌려 다시 대기시키는 함수
+// 실패하면 lease가 끝난 뒤 다시 실행된다
+func (cfg *apiConfig) releaseJob(ctx context.Context, job database.Job) {
+	// 종료 중이라 취소된 ctx 대신 잠깐만 쓰는 context 사용
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobReleaseTimeout)
+	defer cancel()
+
+	if _, err := cfg.ptrDB.ReleaseJob(ctx, database.ReleaseJobParams{
+		ID:       job.ID,
+		Attempts: job.Attempts,
+	}); err != nil {
+		slog.Error("Error releasing interrupted job", "job_id", job.ID, "job_kind", job.Kind, "error", err)
+	}
+}
+
+// handler를 실행하고 결과 저장과 완료 표시를 한 트랜잭션으로 처리하는 함수
+// 트랜잭션은 handler가 끝난 뒤에 짧게 연다
+func (cfg *apiConfig) executeJob(ctx context.Context, job database.Job) error {
+	runner, ok := jobRegistry[job.Kind]
+	if !ok {
+		return permanentJobError{fmt.Errorf("unknown job kind %q", job.Kind)}
+	}
+	// 실행 중에 worker가 죽어서 lease가 끝난 job을 다시 가져오면 attempts가 max_attempts를 넘는다
+	// ==> 매번 서버를 죽이는 job이 계속 다시 실행되지 않도록 dead로 보낸다
+	if job.Attempts > job.MaxAttempts {
+		return permanentJobError{errors.New("job did not finish before its lease expired")}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	commit, err := runner.run(ctx, cfg, job.Payload)
+	if err != nil {
+		return err
+	}
+
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	if commit != nil {
+		if err := commit(ctx, qtx); err != nil {
+			return err
+		}
+	}
+
+	completed, err := qtx.CompleteJob(ctx, database.CompleteJobParams{
+		ID:       job.ID,
+		Attempts: job.Attempts,
+	})
+	if err != nil {
+		return err
+	}
+	if completed == 0 {
+		// lease가 끝나서 다른 worker가 다시 가져간 job ==> 그쪽 결과를 따르도록 결과 저장은 취소
+		slog.Warn("Job lease expired before it finished", "job_id", job.ID, "job_kind", job.Kind)
+		return nil
+	}
+
+	return tx.Commit()
+}
+
+// attempts번 실패한 뒤 다음 시도까지 기다리는 시간
+func jobRetryDelay(attempts int) time.Duration {
+	delay := jobRetryBase
+	for i := 1; i < attempts && delay < jobRetryMax; i++ {
+		delay *= 2
+	}
+	return withJitter(min(delay, jobRetryMax))
+}
+
+// 보관 기간이 지난 완료된 job을 주기적으로 지우는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runJobRetention(ctx context.Context) {
+	ticker := time.NewTicker(chirpRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cfg.ptrDB.DeleteSucceededJobsBefore(ctx, time.Now().Add(-jobRetention)); err != nil && ctx.Err() == nil {
+			slog.Error("Error deleting finished jobs", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// link_previews.status 값
+const (
+	linkPreviewOK     = "ok"
+	linkPreviewFailed = "failed"
+)
+
+type linkPreviewResBody struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+type linkResBody struct {
+	URL string `json:"url"`
+	// 본문에서의 위치 (rune 단위, [start, end))
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+	// 미리보기를 아직 가져오지 못했거나 실패했으면 null
+	Preview *linkPreviewResBody `json:"preview"`
+}
+
+type linkPreviewJobPayload struct {
+	URL string `json:"url"`
+}
+
+// chirp에 들어있는 url의 미리보기(OpenGraph)를 가져오는 job
+// QueueLinkPreview로 url이 pending이 될 때 추가
+var jobLinkPreview = newJobKind("link.preview", 0, (*apiConfig).fetchLinkPreview)
+
+// 미리보기를 가져와 저장하는 함수
+// 페이지를 가져오지 못한 것은 job 실패가 아니라 failed 미리보기로 저장한다 (1시간 뒤 다른 chirp에 다시 나오면 다시 가져온다)
+func (cfg *apiConfig) fetchLinkPreview(ctx context.Context, payload linkPreviewJobPayload) (jobCommit, error) {
+	params := database.SaveLinkPreviewParams{Url: payload.URL, Status: linkPreviewOK}
+
+	preview, err := cfg.linkFetcher.Fetch(ctx, payload.URL)
+	if err != nil {
+		// 서버 종료로 중단된 경우는 저장하지 않는다 ==> job이 다시 실행된다
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		slog.Info("Error fetching link preview", "url", payload.URL, "error", err)
+		params.Status = linkPreviewFailed
+	} else {
+		params.Title = preview.Title
+		params.Description = preview.Description
+		params.ImageUrl = preview.ImageURL
+	}
+
+	return func(ctx context.Context, q *database.Queries) error {
+		return q.SaveLinkPreview(ctx, params)
+	}, nil
+}
+
+// response용 chirp들에 url과 미리보기를 한번의 쿼리로 채우는 함수
+func (cfg *apiConfig) fillChirpLinks(ctx context.Context, resBody []cResBodySuccess) error {
+	if len(resBody) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(resBody))
+	for _, c := range resBody {
+		chirpIDs = append(chirpIDs, c.ID)
+	}
+
+	rows, err := cfg.ptrDB.GetChirpLinks(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	// 쿼리 결과가 본문 순서대로이므로 순서대로 append
+	linksByChirpID := make(map[uuid.UUID][]linkResBody)
+	for _, row := range rows {
+		link := linkResBody{URL: row.Url, Start: row.StartIndex, End: row.EndIndex}
+		// 제목이 없는 페이지는 카드로 보여줄 게 없으므로 미리보기 없음으로 처리
+		if row.Status == linkPreviewOK && row.Title != "" {
+			link.Preview = &linkPreviewResBody{
+				Title:       row.Title,
+				Description: row.Description,
+				ImageURL:    row.ImageUrl,
+			}
+		}
+		linksByChirpID[row.ChirpID] = append(linksByChirpID[row.ChirpID], link)
+	}
+
+	for i := range resBody {
+		if ls, ok := linksByChirpID[resBody[i].ID]; ok {
+			resBody[i].Links = ls
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 외부 webhook endpoint가 구독할 수 있는 event
+const (
+	webhookEventChirpCreated = "chirp.created"
+	webhookEventChirpDeleted = "chirp.deleted"
+	webhookEventUserFollowed = "user.followed"
+)
+
+var webhookEvents = []string{webhookEventChirpCreated, webhookEventChirpDeleted, webhookEventUserFollowed}
+
+// 보내는 webhook의 header (서명은 polka webhook과 같은 방식 : "v1=" + HMAC-SHA256("{timestamp}.{body}"))
+const (
+	webhookEventHeader     = "X-Chirpy-Event"
+	webhookDeliveryHeader  = "X-Chirpy-Delivery"
+	webhookTimestampHeader = "X-Chirpy-Timestamp"
+	webhookSignatureHeader = "X-Chirpy-Signature"
+)
+
+const (
+	// 요청 하나의 timeout (jobTimeout보다 짧아야 한다)
+	webhookDeliveryTimeout = 10 * time.Second
+	// 이 횟수만큼 실패하면 더 이상 시도하지 않는다
+	webhookMaxAttempts = 10
+	// 재시도 간격 : webhookRetryBase * 2^(시도 횟수 - 1), 최대 webhookRetryMax
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+	// 연속으로 이만큼 실패했고 처음 실패한 뒤 webhookDisableAfter가 지났으면 endpoint를 끈다
+	webhookDisableFailures = 20
+	webhookDisableAfter    = 24 * time.Hour
+	// 끝난 전송 기록을 보관하는 기간
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+	// 전송 기록에 남기는 응답 body, 에러 최대 길이
+	webhookErrorMaxLength = 500
+)
+
+// 보내는 webhook body
+type webhookPayload struct {
+	// event id (같은 event를 여러번 받으면 이 id로 중복 제거)
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type webhookChirpData struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"user_id"`
+	Body             string     `json:"body,omitempty"`
+	Visibility       string     `json:"visibility"`
+	InReplyToChirpID *uuid.UUID `json:"in_reply_to_chirp_id,omitempty"`
+	QuotedChirpID    *uuid.UUID `json:"quoted_chirp_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type webhookFollowData struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// event를 받을 endpoint마다 전송을 outbox(webhook_deliveries)에 기록하고 보낼 job을 추가하는 함수 (event를 만든 트랜잭션의 q로 호출)
+// ownerID 유저가 등록한 endpoint는 항상 받고, public이면 앱 endpoint도 받는다
+func enqueueWebhookEvent(ctx context.Context, q *database.Queries, event string, ownerID uuid.UUID, public bool, data any) error {
+	payload := webhookPayload{
+		ID:        uuid.New(),
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	deliveryIDs, err := q.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		EventID: payload.ID,
+		Event:   event,
+		Payload: raw,
+		OwnerID: ownerID,
+		Public:  public,
+	})
+	if err != nil {
+		return err
+	}
+	return enqueueWebhookDeliveryJobs(ctx, q, deliveryIDs)
+}
+
+// chirp event를 기록하는 함수
+// 공개 chirp이고 작성자가 비공개 계정이 아닐 때만 앱 endpoint도 받는다
+func enqueueChirpWebhook(ctx context.Context, q *database.Queries, event string, chirp database.Chirp) error {
+	author, err := q.GetUserByID(ctx, chirp.UserID)
+	if err != nil {
+		return err
+	}
+
+	data := webhookChirpData{
+		ID:         chirp.ID,
+		UserID:     chirp.UserID,
+		Visibility: chirp.Visibility,
+		CreatedAt:  chirp.CreatedAt,
+	}
+	// 삭제된 chirp는 본문을 보내지 않는다
+	if event == webhookEventChirpCreated {
+		data.Body = chirp.Body
+	}
+	if chirp.InReplyToChirpID.Valid {
+		data.InReplyToChirpID = &chirp.InReplyToChirpID.UUID
+	}
+	if chirp.QuotedChirpID.Valid {
+		data.QuotedChirpID = &chirp.QuotedChirpID.UUID
+	}
+
+	public := chirp.Visibility == visibilityPublic && !author.IsProtected
+	return enqueueWebhookEvent(ctx, q, event, chirp.UserID, public, data)
+}
+
+// 팔로우 event를 기록하는 함수 (팔로우가 승인됐을 때)
+// 팔로우 당한 유저의 endpoint가 받고, 비공개 계정이 아니면 앱 endpoint도 받는다
+func enqueueFollowWebhook(ctx context.Context, q *database.Queries, follow database.Follow, followeeProtected bool) error {
+	return enqueueWebhookEvent(ctx, q, webhookEventUserFollowed, follow.FolloweeID, !followeeProtected, webhookFollowData{
+		FollowerID: follow.FollowerID,
+		FolloweeID: follow.FolloweeID,
+		FollowedAt: follow.AcceptedAt.Time,
+	})
+}
+
+type webhookDeliveryJobPayload struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// 전송 하나를 보내는 job (전송을 기록하는 트랜잭션에서 추가)
+// 재시도 간격은 webhookRetryDelay, 최대 시도 횟수는 전송의 attempts로 정한다
+var jobDeliverWebhook = newJobKind("webhook.deliver", webhookMaxAttempts, (*apiConfig).deliverWebhookJob)
+
+// 전송들을 보내는 job들을 추가하는 함수 (전송을 기록한 트랜잭션의 q로 호출)
+func enqueueWebhookDeliveryJobs(ctx context.Context, q *database.Queries, deliveryIDs []uuid.UUID) error {
+	for _, id := range deliveryIDs {
+		if err := jobDeliverWebhook.enqueue(ctx, q, webhookDeliveryJobPayload{DeliveryID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 전송을 보내고 결과를 기록하는 함수
+// 요청은 트랜잭션 밖에서 보내고 성공 기록은 job 완료 표시와 같은 트랜잭션에서 한다
+func (cfg *apiConfig) deliverWebhookJob(ctx context.Context, payload webhookDeliveryJobPayload) (jobCommit, error) {
+	delivery, err := cfg.ptrDB.GetWebhookDeliveryByID(ctx, payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// endpoint가 지워져서 전송도 cascade로 지워짐
+			return nil, nil
+		}
+		return nil, err
+	}
+	// 이미 보낸 전송 (보낸 뒤 완료 표시 전에 중단되어 다시 실행된 경우)
+	if delivery.Status != "pending" {
+		return nil, nil
+	}
+
+	endpoint, err := cfg.ptrDB.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// 꺼진 endpoint에는 보내지 않고 전송을 pending으로 남겨둔다 (다시 켤 때 job을 다시 추가)
+	if endpoint.DisabledAt.Valid {
+		return nil, nil
+	}
+
+	// 전송마다 span을 만든다 (webhookClient가 traceparent header를 붙여서 받는 쪽 trace와 이어진다)
+	sendCtx, span := tracing.Start(ctx, "webhook "+delivery.Event,
+		attribute.String("webhook.delivery_id", delivery.ID.String()),
+		attribute.String("webhook.endpoint_id", endpoint.ID.String()),
+	)
+	status, deliverErr := cfg.sendWebhook(sendCtx, endpoint, delivery)
+	tracing.RecordError(span, deliverErr)
+	span.End()
+
+	if deliverErr == nil {
+		return func(ctx context.Context, q *database.Queries) error {
+			if err := q.MarkWebhookDeliveryDelivered(ctx, database.MarkWebhookDeliveryDeliveredParams{
+				ResponseStatus: int32(status),
+				ID:             delivery.ID,
+			}); err != nil {
+				return err
+			}
+			if endpoint.ConsecutiveFailures > 0 {
+				return q.RecordWebhookEndpointSuccess(ctx, endpoint.ID)
+			}
+			return nil
+		}, nil
+	}
+	// 종료 중에 끊긴 전송은 실패로 세지 않는다 (job runner가 다시 대기시킨다)
+	if ctx.Err() != nil {
+		return nil, deliverErr
+	}
+
+	retryAfter, err := cfg.recordWebhookFailure(ctx, endpoint, delivery, status, deliverErr)
+	if err != nil {
+		return nil, err
+	}
+	// 최대 시도 횟수를 넘겨 failed로 기록한 전송은 job도 끝낸다
+	if retryAfter == 0 {
+		return nil, nil
+	}
+	return nil, retryJobError{err: deliverErr, after: retryAfter}
+}
+
+// 실패한 전송의 다음 시도 시간(또는 포기)과 endpoint의 연속 실패를 기록하고 다음 시도까지 기다릴 시간을 반환하는 함수
+// 더 이상 시도하지 않으면 0 반환
+// status는 응답을 받지 못했으면 0
+func (cfg *apiConfig) recordWebhookFailure(ctx context.Context, endpoint database.WebhookEndpoint, delivery database.WebhookDelivery, status int, deliverErr error) (time.Duration, error) {
+	params := database.MarkWebhookDeliveryFailedParams{
+		LastError: truncateUTF8(deliverErr.Error(), webhookErrorMaxLength),
+		ID:        delivery.ID,
+	}
+	if status != 0 {
+		params.ResponseStatus.Int32 = int32(status)
+		params.ResponseStatus.Valid = true
+	}
+	var retryAfter time.Duration
+	if attempts := int(delivery.Attempts) + 1; attempts < webhookMaxAttempts {
+		retryAfter = webhookRetryDelay(attempts)
+		params.NextAttemptAt.Time = time.Now().Add(retryAfter)
+		params.NextAttemptAt.Valid = true
+	}
+	if err := cfg.ptrDB.MarkWebhookDeliveryFailed(ctx, params); err != nil {
+		return 0, err
+	}
+
+	updated, err := cfg.ptrDB.RecordWebhookEndpointFailure(ctx, database.RecordWebhookEndpointFailureParams{
+		MaxFailures:   webhookDisableFailures,
+		FailingBefore: time.Now().Add(-webhookDisableAfter),
+		ID:            endpoint.ID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if updated.DisabledAt.Valid && !endpoint.DisabledAt.Valid {
+		slog.Warn("Disabled webhook endpoint", "endpoint_id", endpoint.ID, "consecutive_failures", updated.ConsecutiveFailures)
+	}
+	return retryAfter, nil
+}
+
+// attempts번 실패한 뒤 다음 시도까지 기다리는 시간
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return withJitter(min(delay, webhookRetryMax))
+}
+
+// 서명한 payload를 endpoint로 보내는 함수
+// 2xx 응답이면 성공, 아니면 응답 코드(받지 못했으면 0)와 에러 반환
+func (cfg *apiConfig) sendWebhook(ctx context.Context, endpoint database.WebhookEndpoint, delivery database.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(webhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookSignatureHeader, "v1="+auth.SignWebhook(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := cfg.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorMaxLength))
+		return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	// 연결을 다시 쓸 수 있도록 body를 조금 읽고 닫는다
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookErrorMaxLength))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// limit 쿼리가 없을 때 한 페이지 크기
+	defaultPageLimit = 20
+	// 한 페이지 최대 크기
+	maxPageLimit = 100
+)
+
+// cursor pagination에서 마지막으로 받은 항목의 위치
+// (정렬 기준 시간, id) 쌍으로 같은 시간에 생성된 항목들도 빠짐없이 구분한다
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// 페이지가 나뉘는 목록 response용 구조체
+// next_cursor가 비어있으면 마지막 페이지
+type pageResBody[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor를 url 쿼리에 넣을 수 있는 string으로 변환
+func (c pageCursor) encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// encode로 만든 string을 다시 cursor로 변환
+func decodePageCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("error decoding cursor: %w", err)
+	}
+
+	createdAtString, idString, found := strings.Cut(string(raw), "|")
+	if !found {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("error parsing cursor time: %w", err)
+	}
+
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("error parsing cursor id: %w", err)
+	}
+
+	return pageCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// ?limit=&cursor= 쿼리를 읽어서 페이지 크기와 cursor를 반환하는 함수
+// cursor 쿼리가 없으면 첫 페이지이므로 nil 반환
+func parsePageParams(r *http.Request) (int32, *pageCursor, error) {
+	limit := defaultPageLimit
+	if r.URL.Query().Has("limit") {
+		parsed, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || parsed < 1 {
+			return 0, nil, fmt.Errorf("invalid limit: %s", r.URL.Query().Get("limit"))
+		}
+		limit = min(parsed, maxPageLimit)
+	}
+
+	if !r.URL.Query().Has("cursor") {
+		return int32(limit), nil, nil
+	}
+
+	cursor, err := decodePageCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return int32(limit), &cursor, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	// 삭제 표시된 chirp를 실제로 지우기 전까지 보관하는 기간 (복구 기간보다 길어야 한다)
+	chirpRetentionPeriod = 90 * 24 * time.Hour
+	// 보관 기간이 지난 chirp를 확인하는 주기
+	chirpRetentionInterval = time.Hour
+	// 한번의 트랜잭션으로 지우는 최대 chirp 수
+	chirpRetentionBatchSize = 100
+	// 처리한 polka webhook event id를 보관하는 기간
+	// 서명 timestamp tolerance가 지난 요청은 어차피 거절되므로 그보다 충분히 길기만 하면 된다
+	polkaEventRetention = 7 * 24 * time.Hour
+)
+
+// 보관 기간이 지난 삭제된 chirp를 주기적으로 실제로 지우는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runChirpRetention(ctx context.Context) {
+	ticker := time.NewTicker(chirpRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.hardDeleteExpiredChirps(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 보관 기간이 지난 chirp가 남아있지 않을 때까지 batch 단위로 지우는 함수
+func (cfg *apiConfig) hardDeleteExpiredChirps(ctx context.Context) {
+	for {
+		deleted, err := cfg.hardDeleteExpiredChirpBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error deleting expired chirps", "error", err)
+			}
+			return
+		}
+
+		if deleted > 0 {
+			slog.Info("Deleted expired chirps", "count", deleted)
+		}
+
+		if deleted < chirpRetentionBatchSize {
+			return
+		}
+	}
+}
+
+// chirp와 (cascade로) 연결된 행들을 지우고 첨부 media 파일을 지우는 job을 같은 트랜잭션에서 추가하는 함수
+func (cfg *apiConfig) hardDeleteExpiredChirpBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	ids, err := qtx.GetExpiredDeletedChirpIDs(ctx, database.GetExpiredDeletedChirpIDsParams{
+		DeletedBefore: time.Now().Add(-chirpRetentionPeriod),
+		Limit:         chirpRetentionBatchSize,
+	})
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	attached, err := qtx.GetMediaByChirpIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := qtx.HardDeleteChirps(ctx, ids); err != nil {
+		return 0, err
+	}
+
+	if len(attached) > 0 {
+		mediaIDs := make([]uuid.UUID, 0, len(attached))
+		for _, m := range attached {
+			mediaIDs = append(mediaIDs, m.ID)
+		}
+		if err := jobDeleteMediaBlobs.enqueue(ctx, qtx, deleteMediaBlobsJobPayload{MediaIDs: mediaIDs}); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), tx.Commit()
+}
+
+// 보관 기간이 지난 polka webhook event id와 끝난 외부 webhook 전송 기록을 주기적으로 지우는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runWebhookRetention(ctx context.Context) {
+	ticker := time.NewTicker(chirpRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cfg.ptrDB.DeletePolkaEventsBefore(ctx, time.Now().Add(-polkaEventRetention)); err != nil && ctx.Err() == nil {
+			slog.Error("Error deleting expired polka events", "error", err)
+		}
+		if _, err := cfg.ptrDB.DeleteWebhookDeliveriesBefore(ctx, time.Now().Add(-webhookDeliveryRetention)); err != nil && ctx.Err() == nil {
+			slog.Error("Error deleting expired webhook deliveries", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 업로드한 뒤 보관 기간 동안 chirp에 첨부되지 않은 media를 주기적으로 지우는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runUnattachedMediaRetention(ctx context.Context) {
+	ticker := time.NewTicker(chirpRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.deleteUnattachedMedia(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 보관 기간이 지난 첨부되지 않은 media가 남아있지 않을 때까지 batch 단위로 지우는 함수
+func (cfg *apiConfig) deleteUnattachedMedia(ctx context.Context) {
+	for {
+		deleted, err := cfg.deleteUnattachedMediaBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error deleting unattached media", "error", err)
+			}
+			return
+		}
+
+		if deleted > 0 {
+			slog.Info("Deleted unattached media", "count", deleted)
+		}
+
+		if deleted < unattachedMediaBatchSize {
+			return
+		}
+	}
+}
+
+// media 행을 지우고 파일을 지우는 job을 같은 트랜잭션에서 추가하는 함수
+func (cfg *apiConfig) deleteUnattachedMediaBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	ids, err := qtx.DeleteUnattachedMediaBefore(ctx, database.DeleteUnattachedMediaBeforeParams{
+		CreatedBefore: time.Now().Add(-unattachedMediaRetention),
+		Limit:         unattachedMediaBatchSize,
+	})
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	if err := jobDeleteMediaBlobs.enqueue(ctx, qtx, deleteMediaBlobsJobPayload{MediaIDs: ids}); err != nil {
+		return 0, err
+	}
+
+	return len(ids), tx.Commit()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// 예약 chirp를 확인하는 주기
+	chirpSchedulerInterval = 10 * time.Second
+	// 한번의 쿼리로 게시하는 최대 chirp 수
+	chirpSchedulerBatchSize = 100
+)
+
+// 예약 시간이 지난 chirp들을 주기적으로 게시하는 background 스케줄러
+// ctx가 취소되면 종료
+// 게시는 PublishDueChirps 쿼리로 처리되므로 여러 서버가 같은 db에서 동시에 돌려도 chirp는 한번만 게시된다
+func (cfg *apiConfig) runChirpScheduler(ctx context.Context) {
+	ticker := time.NewTicker(chirpSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.publishDueChirps(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 지금 게시할 예약 chirp가 남아있지 않을 때까지 batch 단위로 게시하는 함수
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) {
+	for {
+		published, err := cfg.publishDueChirpBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error publishing scheduled chirps", "error", err)
+			}
+			return
+		}
+
+		if published > 0 {
+			slog.Info("Published scheduled chirps", "count", published)
+		}
+
+		// batch가 꽉 차지 않았으면 남은 chirp가 없다
+		if published < chirpSchedulerBatchSize {
+			return
+		}
+	}
+}
+
+// 예약 chirp들을 게시하고 언급, 답글 알림을 한 트랜잭션으로 만드는 함수
+func (cfg *apiConfig) publishDueChirpBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	// 투표가 이미 마감된 chirp는 게시하지 않고 임시저장으로 되돌린다 (PublishDueChirps도 이런 chirp는 건너뛴다)
+	unscheduled, err := qtx.UnscheduleClosedPollChirps(ctx, chirpSchedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(unscheduled) > 0 {
+		slog.Warn("Moved scheduled chirps with closed polls back to drafts", "count", len(unscheduled))
+	}
+
+	published, err := qtx.PublishDueChirps(ctx, chirpSchedulerBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, chirp := range published {
+		if err := notifyChirpPublished(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(published), tx.Commit()
+}
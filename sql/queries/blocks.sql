@@ -0,0 +1,34 @@
+-- name: CreateBlock :exec
+INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+-- 두 유저 중 한쪽이라도 다른 쪽을 차단했는지 확인
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (user_blocks.blocker_id = sqlc.arg('user_id')::uuid AND user_blocks.blocked_id = sqlc.arg('other_id')::uuid)
+    OR (user_blocks.blocker_id = sqlc.arg('other_id')::uuid AND user_blocks.blocked_id = sqlc.arg('user_id')::uuid)
+) AS blocked;
+
+-- name: IsBlockedAmong :one
+-- 유저들 중 어느 두 명이라도 한쪽이 다른 쪽을 차단했는지 확인
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = ANY(sqlc.arg('user_ids')::uuid[])
+    AND user_blocks.blocked_id = ANY(sqlc.arg('user_ids')::uuid[])
+) AS blocked;
+
+-- name: GetBlockedUserIDs :many
+-- 유저가 차단한 유저들
+SELECT blocked_id FROM user_blocks
+WHERE blocker_id = $1;
@@ -0,0 +1,29 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+-- 유저가 북마크한 chirp들 중 지금도 그 유저가 볼 수 있는 것만 최근 북마크 순으로 반환
+-- before_created_at, before_id가 주어지면 그 북마크 이전 것들만 반환 (cursor pagination)
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND bookmarks.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+AND chirp_visible_to(chirps.id, sqlc.arg('user_id')::uuid)
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('limit');
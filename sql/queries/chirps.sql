@@ -1,11 +1,21 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+-- 바로 게시되는 chirp면 published_at도 작성 시간으로 채운다
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, visibility, quoted_chirp_id, status, publish_at, content_warning, sensitive, content_warning_set_by, in_reply_to_chirp_id, published_at)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    CASE WHEN $5 = 'published' THEN NOW() END
 )
 RETURNING *;
 
@@ -17,12 +27,199 @@ ORDER BY created_at;
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+ORDER BY created_at;
+
 -- name: GetChirpsByAuthorID :many
 SELECT * FROM chirps
 WHERE user_id = $1
 ORDER BY created_at;
 
--- name: DeleteChirpByID :exec
+-- name: SoftDeleteChirp :one
+-- 실제로 지우지 않고 삭제 표시 (고정도 해제)
+UPDATE chirps
+SET deleted_at = NOW(), deleted_by = $1, deletion_reason = $2, pinned_at = NULL, updated_at = NOW()
+WHERE id = $3
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreChirp :one
+-- 작성자가 직접 지운 chirp만 restorable_since 이후에 지운 경우 복구 가능
+UPDATE chirps
+SET deleted_at = NULL, deleted_by = NULL, deletion_reason = NULL, updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND user_id = sqlc.arg('user_id')
+AND deleted_by = user_id
+AND deleted_at > sqlc.arg('restorable_since')::timestamptz
+RETURNING *;
+
+-- name: GetExpiredDeletedChirpIDs :many
+-- deleted_before 이전에 삭제된 chirp id들 (retention job용)
+-- 트랜잭션 안에서 실행하면 삭제 전까지 행이 잠겨서 그 사이에 복구되지 않는다
+SELECT id FROM chirps
+WHERE deleted_at < sqlc.arg('deleted_before')::timestamptz
+ORDER BY deleted_at
+LIMIT sqlc.arg('limit')
+FOR UPDATE SKIP LOCKED;
+
+-- name: HardDeleteChirps :execrows
 DELETE FROM chirps
-WHERE id = $1 
-AND user_id = $2;
\ No newline at end of file
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND deleted_at IS NOT NULL;
+
+-- name: GetChirpMentionedUserIDs :many
+SELECT user_id FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+SELECT sqlc.arg('chirp_id')::uuid, users.id FROM users
+WHERE users.id = sqlc.arg('user_id')
+ON CONFLICT DO NOTHING;
+
+-- name: GetVisibleChirps :many
+-- 보는 유저(viewer_id, 비로그인이면 NULL)가 볼 수 있는 게시된 chirp만 반환
+-- 작성자가 지운 chirp는 빠지고 moderator가 지운 chirp는 tombstone으로 남는다
+-- 공개 범위 확인은 chirp_visible_to 함수 (모든 chirp 조회가 같은 규칙을 쓴다)
+SELECT chirps.* FROM chirps
+WHERE chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND chirp_visible_to(chirps.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.published_at;
+
+-- name: GetVisibleChirpByID :one
+-- 아직 게시되지 않은 chirp(임시저장, 예약)와 작성자가 지운 chirp는 작성자만 볼 수 있다
+SELECT chirps.* FROM chirps
+WHERE chirps.id = sqlc.arg('id')
+AND (chirps.status = 'published' OR chirps.user_id = sqlc.narg('viewer_id'))
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id OR chirps.user_id = sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, sqlc.narg('viewer_id')::uuid);
+
+-- name: GetVisibleChirpsByAuthorID :many
+-- 고정(pin)된 chirp가 최근 고정 순으로 먼저 나오고 나머지는 게시 시간 순
+SELECT chirps.* FROM chirps
+WHERE chirps.user_id = sqlc.arg('author_id')
+AND chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND chirp_visible_to(chirps.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.pinned_at IS NULL, chirps.pinned_at DESC, chirps.published_at;
+
+-- name: GetChirpStats :many
+-- 여러 chirp의 rechirp, 좋아요 수와 보는 유저의 rechirp, 좋아요, 북마크 여부를 한번에 반환
+SELECT chirps.id,
+    (SELECT COUNT(*) FROM rechirps WHERE rechirps.chirp_id = chirps.id) AS rechirp_count,
+    EXISTS (
+        SELECT 1 FROM rechirps
+        WHERE rechirps.chirp_id = chirps.id
+        AND rechirps.user_id = sqlc.narg('viewer_id')
+    ) AS rechirped_by_me,
+    (SELECT COUNT(*) FROM chirp_likes WHERE chirp_likes.chirp_id = chirps.id) AS like_count,
+    EXISTS (
+        SELECT 1 FROM chirp_likes
+        WHERE chirp_likes.chirp_id = chirps.id
+        AND chirp_likes.user_id = sqlc.narg('viewer_id')
+    ) AS liked_by_me,
+    EXISTS (
+        SELECT 1 FROM bookmarks
+        WHERE bookmarks.chirp_id = chirps.id
+        AND bookmarks.user_id = sqlc.narg('viewer_id')
+    ) AS bookmarked_by_me
+FROM chirps
+WHERE chirps.id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1
+AND pinned_at IS NOT NULL;
+
+-- name: PinChirp :one
+-- 이미 고정된 chirp면 원래 고정 시간을 유지
+UPDATE chirps
+SET pinned_at = COALESCE(pinned_at, NOW())
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: UnpinChirp :one
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
+
+-- name: GetUnpublishedChirpsByAuthorID :many
+-- 작성자 본인에게만 보여주는 임시저장, 예약 chirp 목록
+SELECT * FROM chirps
+WHERE user_id = $1
+AND status <> 'published'
+AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: PublishChirp :one
+-- 임시저장, 예약 chirp를 지금 바로 게시
+-- 타임라인은 게시 시간 기준으로 정렬되므로 published_at을 채운다 (created_at은 그대로)
+UPDATE chirps
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND user_id = $2
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ScheduleChirp :one
+-- 임시저장, 예약 chirp의 게시 예약 시간 설정 (이미 예약된 chirp면 시간 변경)
+UPDATE chirps
+SET status = 'scheduled', publish_at = $1, updated_at = NOW()
+WHERE id = $2
+AND user_id = $3
+AND status <> 'published'
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDueChirps :many
+-- 예약 시간이 지난 chirp들을 게시하고 이번에 게시한 chirp들을 반환
+-- FOR UPDATE SKIP LOCKED로 여러 서버가 동시에 실행해도 같은 chirp를 두번 게시하지 않는다
+UPDATE chirps
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled'
+    AND publish_at <= NOW()
+    AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM polls
+        WHERE polls.chirp_id = chirps.id
+        AND polls.closes_at <= NOW()
+    )
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+AND status = 'scheduled'
+RETURNING *;
+
+-- name: UnscheduleClosedPollChirps :many
+-- 예약 시간이 지났지만 투표가 이미 마감된 chirp들은 게시하지 않고 임시저장으로 되돌린다
+-- (스케줄러가 늦게 돌아 예약 시간과 마감 시간 사이를 놓친 경우)
+UPDATE chirps
+SET status = 'draft', publish_at = NULL, updated_at = NOW()
+WHERE id IN (
+    SELECT chirps.id FROM chirps
+    JOIN polls ON polls.chirp_id = chirps.id
+    WHERE chirps.status = 'scheduled'
+    AND chirps.publish_at <= NOW()
+    AND chirps.deleted_at IS NULL
+    AND polls.closes_at <= NOW()
+    LIMIT $1
+    FOR UPDATE OF chirps SKIP LOCKED
+)
+AND status = 'scheduled'
+RETURNING id;
+-- name: SetChirpContentWarning :one
+-- 경고 문구, 민감 여부 변경 (content_warning이 NULL이고 sensitive가 false면 경고 해제)
+UPDATE chirps
+SET content_warning = $1, sensitive = $2, content_warning_set_by = $3, updated_at = NOW()
+WHERE id = $4
+RETURNING *;
@@ -0,0 +1,138 @@
+-- name: GetConversationByDirectKey :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: CreateConversation :one
+-- 같은 1:1 대화가 이미 있으면(동시에 만들어진 경우 포함) 만들지 않고 ErrNoRows ==> GetConversationByDirectKey로 다시 가져온다
+INSERT INTO conversations (id, created_at, updated_at, created_by, is_group, direct_key)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (direct_key) DO NOTHING
+RETURNING *;
+
+-- name: AddConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationForParticipant :one
+-- 참여자가 아니면 대화가 없는 것과 같이 ErrNoRows
+SELECT conversations.* FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg('id')
+AND conversation_participants.user_id = sqlc.arg('user_id');
+
+-- name: GetConversationsForParticipant :many
+-- 유저가 참여중인 대화들을 최근 메시지 순으로 반환 (before_updated_at, before_id가 주어지면 그 이전 것들만)
+-- unread_count : 내가 보내지 않았고, 마지막으로 읽은 이후에 온, 내가 차단하지 않은 유저의 메시지 수
+SELECT sqlc.embed(conversations),
+    (SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> sqlc.arg('user_id')
+        AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+        AND NOT EXISTS (
+            SELECT 1 FROM user_blocks
+            WHERE user_blocks.blocker_id = sqlc.arg('user_id')
+            AND user_blocks.blocked_id = messages.sender_id
+        )
+    ) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('before_updated_at')::timestamp IS NULL
+    OR (conversations.updated_at, conversations.id) < (sqlc.narg('before_updated_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetConversationParticipants :many
+SELECT * FROM conversation_participants
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: CreateMessage :one
+-- 보내는 유저가 대화 참여자일 때만 생성 (아니면 ErrNoRows)
+INSERT INTO messages (id, conversation_id, sender_id, body, created_at)
+SELECT gen_random_uuid(),
+    conversation_participants.conversation_id,
+    conversation_participants.user_id,
+    sqlc.arg('body')::text,
+    NOW()
+FROM conversation_participants
+WHERE conversation_participants.conversation_id = sqlc.arg('conversation_id')
+AND conversation_participants.user_id = sqlc.arg('sender_id')
+RETURNING *;
+
+-- name: GetMessageByID :one
+SELECT * FROM messages
+WHERE id = $1;
+
+-- name: GetMessagesForParticipant :many
+-- 참여자가 아니면 빈 목록, 내가 차단한 유저의 메시지는 제외하고 최근 순으로 반환
+-- before_created_at, before_id가 주어지면 그 메시지 이전 것들만 반환 (cursor pagination)
+SELECT messages.* FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE messages.conversation_id = sqlc.arg('conversation_id')
+AND conversation_participants.user_id = sqlc.arg('user_id')
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = sqlc.arg('user_id')
+    AND user_blocks.blocked_id = messages.sender_id
+)
+AND (
+    sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (messages.created_at, messages.id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+ORDER BY messages.created_at DESC, messages.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLatestMessages :many
+-- 대화별 마지막 메시지 (user_id가 차단한 유저의 메시지는 제외)
+SELECT * FROM messages
+WHERE id IN (
+    SELECT DISTINCT ON (latest.conversation_id) latest.id FROM messages AS latest
+    WHERE latest.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+    AND NOT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE user_blocks.blocker_id = sqlc.arg('user_id')::uuid
+        AND user_blocks.blocked_id = latest.sender_id
+    )
+    ORDER BY latest.conversation_id, latest.created_at DESC, latest.id DESC
+);
+
+-- name: MarkConversationRead :execrows
+-- read_at까지의 메시지를 읽음으로 표시 (이미 더 나중 메시지까지 읽었으면 그대로)
+UPDATE conversation_participants
+SET last_read_at = GREATEST(last_read_at, sqlc.arg('read_at')::timestamp)
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND user_id = sqlc.arg('user_id');
+
+-- name: GetUnreadMessageCount :one
+-- 참여중인 모든 대화(conversation_id가 주어지면 그 대화만)의 안읽은 메시지 수
+SELECT COUNT(*) AS unread_count FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE conversation_participants.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('conversation_id')::uuid IS NULL OR messages.conversation_id = sqlc.narg('conversation_id')::uuid)
+AND messages.sender_id <> sqlc.arg('user_id')
+AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = sqlc.arg('user_id')
+    AND user_blocks.blocked_id = messages.sender_id
+);
@@ -0,0 +1,46 @@
+-- name: CreateFollow :one
+-- 비공개 계정이 아니면 바로 accepted, 비공개 계정이면 accepted_at이 NULL인 팔로우 요청 상태로 생성
+-- 이미 팔로우(또는 요청) 중이면 기존 행을 그대로 반환
+INSERT INTO follows (follower_id, followee_id, created_at, updated_at, accepted_at)
+VALUES (
+    sqlc.arg('follower_id'),
+    sqlc.arg('followee_id'),
+    NOW(),
+    NOW(),
+    CASE WHEN sqlc.arg('accepted')::boolean THEN NOW() ELSE NULL END
+)
+ON CONFLICT (follower_id, followee_id) DO UPDATE
+SET updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteFollow :exec
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: GetFollowRequests :many
+SELECT * FROM follows
+WHERE followee_id = $1
+AND accepted_at IS NULL
+ORDER BY created_at;
+
+-- name: AcceptFollowRequest :one
+UPDATE follows
+SET accepted_at = NOW(), updated_at = NOW()
+WHERE follower_id = $1
+AND followee_id = $2
+AND accepted_at IS NULL
+RETURNING *;
+
+-- name: AcceptAllFollowRequests :many
+UPDATE follows
+SET accepted_at = NOW(), updated_at = NOW()
+WHERE followee_id = $1
+AND accepted_at IS NULL
+RETURNING *;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2
+AND accepted_at IS NULL;
@@ -0,0 +1,111 @@
+-- name: EnqueueJob :one
+-- run_at이 NULL이면 바로 실행
+INSERT INTO jobs (id, kind, payload, status, max_attempts, run_at, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    sqlc.arg('kind')::text,
+    sqlc.arg('payload')::jsonb,
+    'pending',
+    sqlc.arg('max_attempts')::int,
+    COALESCE(sqlc.narg('run_at')::timestamp, NOW()),
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: ClaimJobs :many
+-- 실행할 차례인 job들을 running으로 바꾸고 locked_until까지 다른 worker가 가져가지 않도록 한다
+-- locked_until이 지난 running job은 실행하던 worker가 죽은 것으로 보고 다시 가져간다
+-- FOR UPDATE SKIP LOCKED로 여러 서버의 worker가 같은 job을 동시에 가져가지 않는다
+UPDATE jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_until = sqlc.arg('locked_until')::timestamp,
+    updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE (status = 'pending' AND run_at <= NOW())
+    OR (status = 'running' AND locked_until < NOW())
+    ORDER BY run_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+-- attempts가 다르면 lease가 끝나 다른 worker가 다시 가져간 job이므로 바꾸지 않는다 (0 rows)
+UPDATE jobs
+SET status = 'succeeded',
+    locked_until = NULL,
+    last_error = NULL,
+    finished_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid
+AND status = 'running'
+AND attempts = sqlc.arg('attempts')::int;
+
+-- name: FailJob :execrows
+-- run_at이 NULL이면 더 이상 시도하지 않는다 (dead)
+UPDATE jobs
+SET status = CASE WHEN sqlc.narg('run_at')::timestamp IS NULL THEN 'dead' ELSE 'pending' END,
+    run_at = COALESCE(sqlc.narg('run_at')::timestamp, run_at),
+    locked_until = NULL,
+    last_error = sqlc.arg('last_error')::text,
+    finished_at = CASE WHEN sqlc.narg('run_at')::timestamp IS NULL THEN NOW() ELSE NULL END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid
+AND status = 'running'
+AND attempts = sqlc.arg('attempts')::int;
+
+-- name: ReleaseJob :execrows
+-- 서버 종료로 중단된 job을 다시 대기시킨다 (중단된 시도는 세지 않도록 attempts를 되돌린다)
+-- attempts가 다르면 lease가 끝나 다른 worker가 다시 가져간 job이므로 바꾸지 않는다 (0 rows)
+UPDATE jobs
+SET status = 'pending',
+    attempts = attempts - 1,
+    locked_until = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid
+AND status = 'running'
+AND attempts = sqlc.arg('attempts')::int;
+
+-- name: RetryDeadJob :one
+-- dead job을 처음부터 다시 시도
+UPDATE jobs
+SET status = 'pending',
+    attempts = 0,
+    run_at = NOW(),
+    finished_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+AND status = 'dead'
+RETURNING *;
+
+-- name: GetJobByID :one
+SELECT * FROM jobs
+WHERE id = $1;
+
+-- name: GetJobs :many
+-- job을 최근 순으로 반환 (status, kind가 주어지면 그것만, before_created_at, before_id가 주어지면 그 이전 것들만)
+SELECT * FROM jobs
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+AND (sqlc.narg('kind')::text IS NULL OR kind = sqlc.narg('kind')::text)
+AND (
+    sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetJobCounts :many
+-- 종류, 상태별 job 수
+SELECT kind, status, COUNT(*) AS count
+FROM jobs
+GROUP BY kind, status
+ORDER BY kind, status;
+
+-- name: DeleteSucceededJobsBefore :execrows
+-- 보관 기간이 지난 완료된 job 삭제 (dead job은 관리자가 확인할 수 있도록 남긴다)
+DELETE FROM jobs
+WHERE status = 'succeeded'
+AND finished_at < sqlc.arg('finished_before')::timestamp;
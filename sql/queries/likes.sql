@@ -0,0 +1,15 @@
+-- name: CreateLike :execrows
+-- 이미 좋아요한 chirp면 0 반환
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteLike :execrows
+-- 좋아요하지 않은 chirp면 0 반환
+DELETE FROM chirp_likes
+WHERE user_id = $1
+AND chirp_id = $2;
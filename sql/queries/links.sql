@@ -0,0 +1,38 @@
+-- name: CreateChirpLink :exec
+INSERT INTO chirp_links (chirp_id, position, url, start_index, end_index)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: QueueLinkPreview :execrows
+-- 처음 보는 url이면 pending으로 추가
+-- 이미 있는 url은 오래된 미리보기(성공 7일, 실패 1시간)일 때만 다시 가져오도록 pending으로 변경
+-- 새로 pending이 되면 1 (미리보기를 가져오는 job을 추가해야 한다), 아니면 0
+INSERT INTO link_previews (url, status, created_at)
+VALUES (
+    $1,
+    'pending',
+    NOW()
+)
+ON CONFLICT (url) DO UPDATE
+SET status = 'pending'
+WHERE (link_previews.status = 'ok' AND link_previews.fetched_at < NOW() - INTERVAL '7 days')
+OR (link_previews.status = 'failed' AND link_previews.fetched_at < NOW() - INTERVAL '1 hour');
+
+-- name: SaveLinkPreview :exec
+UPDATE link_previews
+SET status = $2, title = $3, description = $4, image_url = $5, fetched_at = NOW()
+WHERE url = $1;
+
+-- name: GetChirpLinks :many
+-- 여러 chirp의 url들과 미리보기를 한번에 반환
+SELECT chirp_links.chirp_id, chirp_links.url, chirp_links.start_index, chirp_links.end_index,
+    link_previews.status, link_previews.title, link_previews.description, link_previews.image_url
+FROM chirp_links
+JOIN link_previews ON link_previews.url = chirp_links.url
+WHERE chirp_links.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_links.chirp_id, chirp_links.position;
@@ -0,0 +1,45 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, width, height, thumbnail_content_type)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetMediaByID :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: AttachMediaToChirp :execrows
+-- 업로드한 유저 본인의, 아직 첨부되지 않은 media만 첨부되고 순서는 media_ids의 순서를 따른다
+-- 반환된 행 수가 media_ids 길이보다 작으면 첨부할 수 없는 media가 섞여 있던 것
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id')::uuid,
+    position = array_position(sqlc.arg('media_ids')::uuid[], id)
+WHERE id = ANY(sqlc.arg('media_ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND chirp_id IS NULL;
+
+-- name: GetMediaByChirpIDs :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteUnattachedMediaBefore :many
+-- created_before 이전에 업로드된 뒤 chirp에 첨부되지 않은 media를 최대 limit개 지우고 id 반환
+DELETE FROM media
+WHERE id IN (
+    SELECT id FROM media
+    WHERE chirp_id IS NULL
+    AND created_at < sqlc.arg('created_before')
+    ORDER BY created_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id;
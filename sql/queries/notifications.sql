@@ -0,0 +1,77 @@
+-- name: UpsertNotification :one
+-- 같은 group_key의 안읽은 알림이 있으면 그 알림에 묶고(updated_at 갱신) 없으면 새로 생성
+-- 받는 유저가 actor_id 유저를 차단했으면 만들지 않는다 (ErrNoRows)
+INSERT INTO notifications (id, user_id, type, chirp_id, group_key, created_at, updated_at)
+SELECT gen_random_uuid(),
+    sqlc.arg('user_id')::uuid,
+    sqlc.arg('type')::text,
+    sqlc.narg('chirp_id')::uuid,
+    sqlc.arg('group_key')::text,
+    NOW(),
+    NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = sqlc.arg('user_id')::uuid
+    AND user_blocks.blocked_id = sqlc.narg('actor_id')::uuid
+)
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO UPDATE
+SET updated_at = NOW()
+RETURNING *;
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (notification_id, actor_id) DO UPDATE
+SET created_at = NOW();
+
+-- name: GetNotifications :many
+-- 유저의 알림을 최근 순으로 반환 (unread_only면 안읽은 알림만, before_updated_at, before_id가 주어지면 그 이전 것들만)
+-- 대상 chirp가 삭제된 알림은 제외
+SELECT sqlc.embed(notifications),
+    (SELECT COUNT(*) FROM notification_actors WHERE notification_actors.notification_id = notifications.id) AS actor_count
+FROM notifications
+LEFT JOIN chirps ON chirps.id = notifications.chirp_id
+WHERE notifications.user_id = sqlc.arg('user_id')
+AND (NOT sqlc.arg('unread_only')::boolean OR notifications.read_at IS NULL)
+AND (notifications.chirp_id IS NULL OR chirps.deleted_at IS NULL)
+AND (
+    sqlc.narg('before_updated_at')::timestamp IS NULL
+    OR (notifications.updated_at, notifications.id) < (sqlc.narg('before_updated_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+ORDER BY notifications.updated_at DESC, notifications.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetRecentNotificationActors :many
+-- 알림별로 가장 최근에 행동한 유저 3명까지 반환
+SELECT * FROM notification_actors
+WHERE notification_actors.notification_id = ANY(sqlc.arg('notification_ids')::uuid[])
+AND (
+    SELECT COUNT(*) FROM notification_actors AS newer
+    WHERE newer.notification_id = notification_actors.notification_id
+    AND (newer.created_at, newer.actor_id) > (notification_actors.created_at, notification_actors.actor_id)
+) < 3
+ORDER BY notification_actors.notification_id, notification_actors.created_at DESC, notification_actors.actor_id DESC;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND id = ANY(sqlc.arg('ids')::uuid[])
+AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: GetUnreadNotificationCount :one
+SELECT COUNT(*) AS unread_count FROM notifications
+LEFT JOIN chirps ON chirps.id = notifications.chirp_id
+WHERE notifications.user_id = $1
+AND notifications.read_at IS NULL
+AND (notifications.chirp_id IS NULL OR chirps.deleted_at IS NULL);
@@ -0,0 +1,13 @@
+-- name: CreatePolkaEvent :execrows
+-- 이미 처리한 event면 0 반환
+INSERT INTO polka_events (id, event, received_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: DeletePolkaEventsBefore :execrows
+DELETE FROM polka_events
+WHERE received_at < sqlc.arg('received_before');
@@ -0,0 +1,51 @@
+-- name: CreatePoll :one
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES (
+    $1,
+    NOW(),
+    $2
+)
+RETURNING *;
+
+-- name: CreatePollOption :one
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetPollByChirpID :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollOptionByID :one
+SELECT * FROM poll_options
+WHERE id = $1
+AND chirp_id = $2;
+
+-- name: CreatePollVote :execrows
+-- 이미 투표했거나 마감된 투표면 아무것도 저장하지 않고 0 반환
+INSERT INTO poll_votes (chirp_id, user_id, option_id, created_at)
+SELECT polls.chirp_id, sqlc.arg('user_id')::uuid, sqlc.arg('option_id')::uuid, NOW()
+FROM polls
+WHERE polls.chirp_id = sqlc.arg('chirp_id')
+AND polls.closes_at > NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: GetPollResults :many
+-- 여러 chirp의 투표 선택지와 득표 수, 보는 유저가 고른 선택지 여부를 한번에 반환
+SELECT polls.chirp_id, polls.closes_at,
+    poll_options.id AS option_id, poll_options.position, poll_options.text,
+    (SELECT COUNT(*) FROM poll_votes WHERE poll_votes.option_id = poll_options.id) AS votes,
+    EXISTS (
+        SELECT 1 FROM poll_votes
+        WHERE poll_votes.option_id = poll_options.id
+        AND poll_votes.user_id = sqlc.narg('viewer_id')
+    ) AS voted_by_me
+FROM polls
+JOIN poll_options ON poll_options.chirp_id = polls.chirp_id
+WHERE polls.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY polls.chirp_id, poll_options.position;
@@ -0,0 +1,98 @@
+-- name: GetRealtimeEvents :many
+-- after_id 이후의 event 중 websocket 구독자(user_id)가 구독한 채널의 event만 반환
+-- timeline : 본인과 팔로우한 유저의 chirp, 좋아요 event
+-- thread : thread_ids chirp와 그 답글들의 chirp, 좋아요 event
+-- conversation : 참여 중인 conversation_ids 대화의 메시지 (내가 차단한 유저의 메시지 제외)
+-- chirp event는 그 chirp를 볼 수 있는 유저만 받는다
+SELECT sqlc.embed(stream_events), chirps.in_reply_to_chirp_id,
+(
+    sqlc.arg('timeline')::bool
+    AND (
+        stream_events.author_id = sqlc.arg('user_id')::uuid
+        OR EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.followee_id = stream_events.author_id
+            AND follows.follower_id = sqlc.arg('user_id')::uuid
+            AND follows.accepted_at IS NOT NULL
+        )
+    )
+)::bool AS in_timeline
+FROM stream_events
+LEFT JOIN chirps ON chirps.id = stream_events.chirp_id
+WHERE stream_events.id > sqlc.arg('after_id')
+AND (
+    (
+        stream_events.type = 'message_created'
+        AND stream_events.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+        AND EXISTS (
+            SELECT 1 FROM conversation_participants
+            WHERE conversation_participants.conversation_id = stream_events.conversation_id
+            AND conversation_participants.user_id = sqlc.arg('user_id')::uuid
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM user_blocks
+            WHERE user_blocks.blocker_id = sqlc.arg('user_id')::uuid
+            AND user_blocks.blocked_id = stream_events.actor_id
+        )
+    )
+    OR (
+        stream_events.type IN ('chirp_created', 'chirp_deleted', 'chirp_liked', 'chirp_unliked')
+        AND (
+            (
+                sqlc.arg('timeline')::bool
+                AND (
+                    stream_events.author_id = sqlc.arg('user_id')::uuid
+                    OR EXISTS (
+                        SELECT 1 FROM follows
+                        WHERE follows.followee_id = stream_events.author_id
+                        AND follows.follower_id = sqlc.arg('user_id')::uuid
+                        AND follows.accepted_at IS NOT NULL
+                    )
+                )
+            )
+            OR chirps.id = ANY(sqlc.arg('thread_ids')::uuid[])
+            OR chirps.in_reply_to_chirp_id = ANY(sqlc.arg('thread_ids')::uuid[])
+        )
+        AND chirp_visible_to(chirps.id, sqlc.arg('user_id')::uuid)
+    )
+)
+ORDER BY stream_events.id
+LIMIT sqlc.arg('limit');
+
+-- name: NotifyRealtimeSignal :exec
+-- 저장하지 않는 신호(입력 중, 접속 상태)를 모든 서버 인스턴스에 바로 알린다
+SELECT pg_notify('realtime_signals', sqlc.arg('payload')::text);
+
+-- name: UpsertPresenceSession :exec
+INSERT INTO presence_sessions (id, user_id, status, last_seen_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (id) DO UPDATE
+SET status = EXCLUDED.status,
+last_seen_at = NOW();
+
+-- name: TouchPresenceSession :exec
+UPDATE presence_sessions
+SET last_seen_at = NOW()
+WHERE id = $1;
+
+-- name: DeletePresenceSession :exec
+DELETE FROM presence_sessions
+WHERE id = $1;
+
+-- name: GetUsersPresence :many
+-- 유저별로 살아있는 session 중 하나라도 online이면 online, 아니면 away
+-- 살아있는 session이 없는 유저는 결과에 없다 (offline)
+SELECT user_id, (CASE WHEN bool_or(status = 'online') THEN 'online' ELSE 'away' END)::text AS status
+FROM presence_sessions
+WHERE user_id = ANY(sqlc.arg('user_ids')::uuid[])
+AND last_seen_at > sqlc.arg('seen_after')
+GROUP BY user_id;
+
+-- name: DeleteStalePresenceSessions :execrows
+DELETE FROM presence_sessions
+WHERE last_seen_at < sqlc.arg('seen_before');
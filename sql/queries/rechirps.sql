@@ -0,0 +1,26 @@
+-- name: CreateRechirp :one
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: DeleteRechirp :exec
+DELETE FROM rechirps
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: GetVisibleRechirps :many
+-- 보는 유저가 볼 수 있는 chirp의 rechirp들을 rechirp한 유저, 시간과 함께 반환
+-- user_id가 NULL이 아니면 그 유저가 rechirp한 것만 반환
+SELECT sqlc.embed(chirps), rechirps.user_id AS rechirped_by, rechirps.created_at AS rechirped_at FROM rechirps
+JOIN chirps ON chirps.id = rechirps.chirp_id
+WHERE chirps.status = 'published'
+AND (chirps.deleted_at IS NULL OR chirps.deleted_by IS DISTINCT FROM chirps.user_id)
+AND (sqlc.narg('user_id')::uuid IS NULL OR rechirps.user_id = sqlc.narg('user_id'))
+AND chirp_visible_to(chirps.id, sqlc.narg('viewer_id')::uuid)
+ORDER BY rechirps.created_at;
@@ -0,0 +1,64 @@
+-- name: QueueStreamEvent :exec
+-- event를 outbox에 추가 (event를 만드는 DB 변경과 같은 트랜잭션에서 호출, 커밋 후 PublishStreamEvents가 옮긴다)
+INSERT INTO stream_event_outbox (type, chirp_id, author_id, recipient_id, notification_id, actor_id, conversation_id, message_id, created_at)
+VALUES (
+    sqlc.arg('type'),
+    sqlc.narg('chirp_id'),
+    sqlc.narg('author_id'),
+    sqlc.narg('recipient_id'),
+    sqlc.narg('notification_id'),
+    sqlc.narg('actor_id'),
+    sqlc.narg('conversation_id'),
+    sqlc.narg('message_id'),
+    NOW()
+);
+
+-- name: LockStreamEventPublisher :exec
+-- publisher 트랜잭션들을 줄 세워서 먼저 붙인 id가 먼저 커밋되게 한다 (event를 기록하는 트랜잭션은 기다리지 않는다)
+SELECT pg_advisory_xact_lock(hashtext('stream_event_publisher'));
+
+-- name: PublishStreamEvents :execrows
+-- 커밋된 outbox event를 추가된 순서대로 stream_events로 옮긴다 (이때 id가 붙는다)
+WITH pending AS (
+    DELETE FROM stream_event_outbox
+    RETURNING *
+)
+INSERT INTO stream_events (type, chirp_id, author_id, recipient_id, notification_id, actor_id, conversation_id, message_id, created_at)
+SELECT type, chirp_id, author_id, recipient_id, notification_id, actor_id, conversation_id, message_id, created_at
+FROM pending
+ORDER BY id;
+
+-- name: GetLatestStreamEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id FROM stream_events;
+
+-- name: GetStreamEvents :many
+-- after_id 이후의 event 중 구독자(viewer_id, 비로그인이면 NULL)가 받을 event만 반환
+-- 알림은 받는 유저 본인만, chirp event는 그 chirp를 볼 수 있는 유저만 받는다 (삭제 여부와 상관없이)
+-- author_id가 주어지면 그 작성자의 chirp만, timeline이면 본인과 팔로우한 유저의 chirp만
+SELECT stream_events.* FROM stream_events
+LEFT JOIN chirps ON chirps.id = stream_events.chirp_id
+WHERE stream_events.id > sqlc.arg('after_id')
+AND (
+    (stream_events.type = 'notification' AND stream_events.recipient_id = sqlc.narg('viewer_id'))
+    OR (
+        stream_events.type IN ('chirp_created', 'chirp_deleted')
+        AND (sqlc.narg('author_id')::uuid IS NULL OR stream_events.author_id = sqlc.narg('author_id'))
+        AND (
+            NOT sqlc.arg('timeline')::bool
+            OR stream_events.author_id = sqlc.narg('viewer_id')
+            OR EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.followee_id = stream_events.author_id
+                AND follows.follower_id = sqlc.narg('viewer_id')
+                AND follows.accepted_at IS NOT NULL
+            )
+        )
+        AND chirp_visible_to(chirps.id, sqlc.narg('viewer_id')::uuid)
+    )
+)
+ORDER BY stream_events.id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteStreamEventsBefore :execrows
+DELETE FROM stream_events
+WHERE created_at < sqlc.arg('created_before');
@@ -0,0 +1,98 @@
+-- name: GetSubscriptionByUserID :one
+SELECT * FROM subscriptions
+WHERE user_id = $1;
+
+-- name: UpsertSubscription :one
+-- 구독 시작 : 처음 구독하거나 끝난 구독을 다시 시작하면 새 기간으로 active
+-- polka가 기간 끝을 보내지 않으면 한 달
+INSERT INTO subscriptions (id, user_id, plan, status, current_period_start, current_period_end, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    sqlc.arg('user_id'),
+    sqlc.arg('plan'),
+    'active',
+    NOW(),
+    COALESCE(sqlc.narg('current_period_end')::timestamp, NOW() + INTERVAL '1 month'),
+    NOW(),
+    NOW()
+)
+ON CONFLICT (user_id) DO UPDATE
+SET plan = EXCLUDED.plan,
+status = 'active',
+current_period_start = EXCLUDED.current_period_start,
+current_period_end = EXCLUDED.current_period_end,
+cancel_at_period_end = FALSE,
+canceled_at = NULL,
+payment_failed_at = NULL,
+grace_until = NULL,
+ended_at = NULL,
+updated_at = NOW()
+RETURNING *;
+
+-- name: RenewSubscription :one
+-- 갱신 결제 성공 : 다음 기간으로 넘어가고 결제 실패 상태는 풀린다
+-- 환불된 구독은 갱신되지 않는다 (다시 구독해야 한다)
+-- polka가 기간 끝을 보내지 않으면 이전 기간 끝(이미 지났으면 지금)부터 한 달
+UPDATE subscriptions
+SET status = 'active',
+current_period_start = GREATEST(current_period_end, NOW()),
+current_period_end = COALESCE(sqlc.narg('current_period_end')::timestamp, GREATEST(current_period_end, NOW()) + INTERVAL '1 month'),
+payment_failed_at = NULL,
+grace_until = NULL,
+ended_at = NULL,
+updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND status <> 'refunded'
+RETURNING *;
+
+-- name: MarkSubscriptionPastDue :one
+-- 갱신 결제 실패 : grace_until까지 혜택을 유지하며 다시 결제되기를 기다린다
+UPDATE subscriptions
+SET status = 'past_due',
+payment_failed_at = NOW(),
+grace_until = sqlc.arg('grace_until')::timestamp,
+updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND status IN ('active', 'past_due')
+RETURNING *;
+
+-- name: CancelSubscription :one
+-- 해지 : 결제된 기간이 끝날 때까지는 혜택 유지 (결제 실패 중이면 바로 끝난다)
+UPDATE subscriptions
+SET cancel_at_period_end = TRUE,
+canceled_at = NOW(),
+status = CASE WHEN status = 'past_due' THEN 'canceled' ELSE status END,
+ended_at = CASE WHEN status = 'past_due' THEN NOW() ELSE ended_at END,
+updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND status IN ('active', 'past_due')
+RETURNING *;
+
+-- name: RefundSubscription :one
+-- 환불 : 바로 끝난다
+UPDATE subscriptions
+SET status = 'refunded',
+current_period_end = LEAST(current_period_end, NOW()),
+ended_at = NOW(),
+updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND status IN ('active', 'past_due', 'canceled')
+RETURNING *;
+
+-- name: ExpireSubscriptions :many
+-- 끝난 구독들을 닫고 유저 id를 반환
+-- 해지한 구독은 기간이 끝나면 바로, 갱신을 기다리는 구독은 renewal_grace가 지나도록(period_ended_before) 갱신되지 않으면,
+-- 결제 실패한 구독은 grace_until이 지나면 끝난다
+UPDATE subscriptions
+SET status = CASE WHEN cancel_at_period_end AND status = 'active' THEN 'canceled' ELSE 'expired' END,
+ended_at = NOW(),
+updated_at = NOW()
+WHERE id IN (
+    SELECT due.id FROM subscriptions AS due
+    WHERE (due.status = 'active' AND due.cancel_at_period_end AND due.current_period_end < NOW())
+    OR (due.status = 'active' AND due.current_period_end < sqlc.arg('period_ended_before'))
+    OR (due.status = 'past_due' AND due.grace_until < NOW())
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING user_id;
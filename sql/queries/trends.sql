@@ -0,0 +1,173 @@
+-- name: CreateChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, tag)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: TryLockTrendAggregator :one
+-- 트랜잭션이 끝날 때까지 유지되는 advisory lock
+-- 여러 서버의 집계 worker 중 lock을 얻은 하나만 집계한다
+SELECT pg_try_advisory_xact_lock(hashtext('trend_aggregator'))::boolean AS locked;
+
+-- name: DeleteTrendHashtagBucketsSince :exec
+DELETE FROM trend_hashtag_buckets
+WHERE bucket_start >= sqlc.arg('since')::timestamptz;
+
+-- name: DeleteTrendChirpBucketsSince :exec
+DELETE FROM trend_chirp_buckets
+WHERE bucket_start >= sqlc.arg('since')::timestamptz;
+
+-- name: RollupTrendHashtagBuckets :exec
+-- since 이후 활동을 5분 구간, hashtag, chirp별로 묶어서 추가 (같은 구간은 먼저 지우고 다시 계산)
+-- 새 chirp는 chirps, 반응(rechirp, 인용)은 반응한 유저 수로 반응 받은 chirp의 hashtag에 집계
+-- 팔로워 전용, 비공개 계정, 삭제된 chirp와 작성자 본인의 반응은 집계하지 않는다
+-- (집계 후에 삭제되거나 비공개가 된 chirp는 ComputeTrendingHashtags가 거른다)
+INSERT INTO trend_hashtag_buckets (bucket_start, tag, chirp_id, chirps, engagements)
+SELECT date_bin('5 minutes', activity.at::timestamptz, TIMESTAMPTZ '2000-01-01 00:00:00+00'),
+    chirp_hashtags.tag,
+    chirps.id,
+    COUNT(*) FILTER (WHERE activity.new_chirp),
+    COUNT(DISTINCT activity.actor_id) FILTER (WHERE NOT activity.new_chirp)
+FROM (
+    SELECT chirps.id AS chirp_id, chirps.user_id AS actor_id, chirps.published_at AS at, TRUE AS new_chirp
+    FROM chirps
+    WHERE chirps.published_at >= sqlc.arg('since')::timestamptz
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at, FALSE
+    FROM rechirps
+    WHERE rechirps.created_at >= sqlc.arg('since')::timestamptz
+    UNION ALL
+    SELECT quotes.quoted_chirp_id, quotes.user_id, quotes.published_at, FALSE
+    FROM chirps AS quotes
+    WHERE quotes.quoted_chirp_id IS NOT NULL
+    AND quotes.status = 'published'
+    AND quotes.deleted_at IS NULL
+    AND quotes.published_at >= sqlc.arg('since')::timestamptz
+) AS activity
+JOIN chirps ON chirps.id = activity.chirp_id
+JOIN users ON users.id = chirps.user_id
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+WHERE chirps.status = 'published'
+AND chirps.deleted_at IS NULL
+AND chirps.visibility = 'public'
+AND NOT users.is_protected
+AND (activity.new_chirp OR activity.actor_id <> chirps.user_id)
+GROUP BY 1, 2, 3;
+
+-- name: RollupTrendChirpBuckets :exec
+-- since 이후 chirp별 반응(rechirp, 인용)한 유저 수를 5분 구간별로 추가
+INSERT INTO trend_chirp_buckets (bucket_start, chirp_id, engagements)
+SELECT date_bin('5 minutes', activity.at::timestamptz, TIMESTAMPTZ '2000-01-01 00:00:00+00'),
+    chirps.id,
+    COUNT(DISTINCT activity.actor_id)
+FROM (
+    SELECT rechirps.chirp_id, rechirps.user_id AS actor_id, rechirps.created_at AS at
+    FROM rechirps
+    WHERE rechirps.created_at >= sqlc.arg('since')::timestamptz
+    UNION ALL
+    SELECT quotes.quoted_chirp_id, quotes.user_id, quotes.published_at
+    FROM chirps AS quotes
+    WHERE quotes.quoted_chirp_id IS NOT NULL
+    AND quotes.status = 'published'
+    AND quotes.deleted_at IS NULL
+    AND quotes.published_at >= sqlc.arg('since')::timestamptz
+) AS activity
+JOIN chirps ON chirps.id = activity.chirp_id
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.status = 'published'
+AND chirps.deleted_at IS NULL
+AND chirps.visibility = 'public'
+AND NOT users.is_protected
+AND activity.actor_id <> chirps.user_id
+GROUP BY 1, 2;
+
+-- name: PruneTrendHashtagBuckets :exec
+DELETE FROM trend_hashtag_buckets
+WHERE bucket_start < sqlc.arg('before')::timestamptz;
+
+-- name: PruneTrendChirpBuckets :exec
+DELETE FROM trend_chirp_buckets
+WHERE bucket_start < sqlc.arg('before')::timestamptz;
+
+-- name: DeleteTrendingHashtags :exec
+DELETE FROM trending_hashtags
+WHERE time_window = $1;
+
+-- name: ComputeTrendingHashtags :exec
+-- window_start 이후 rollup으로 hashtag 점수를 계산해서 상위 limit개 저장
+-- 구간 활동량은 half_life_seconds마다 절반이 되도록 최근 활동일수록 크게 반영하고
+-- 작성자별 활동량은 ln(1 + x)로 감쇠해서 더한다
+-- ==> 한 작성자가 같은 hashtag를 도배해도 여러 작성자가 함께 쓰는 hashtag를 넘기 어렵다
+-- 집계 후에 삭제되거나 더 이상 공개가 아닌 chirp(작성자가 비공개 계정이 된 경우 포함)의 활동은 빼고 계산한다
+INSERT INTO trending_hashtags (time_window, tag, score, chirp_count, author_count, computed_at)
+SELECT sqlc.arg('time_window')::text,
+    per_author.tag,
+    SUM(LN(1 + per_author.activity)),
+    SUM(per_author.chirps),
+    COUNT(*),
+    NOW()
+FROM (
+    SELECT trend_hashtag_buckets.tag,
+        chirps.user_id,
+        SUM(trend_hashtag_buckets.chirps) AS chirps,
+        SUM((trend_hashtag_buckets.chirps + trend_hashtag_buckets.engagements)
+            * POWER(0.5, EXTRACT(EPOCH FROM (NOW() - trend_hashtag_buckets.bucket_start)) / sqlc.arg('half_life_seconds')::float8)) AS activity
+    FROM trend_hashtag_buckets
+    JOIN chirps ON chirps.id = trend_hashtag_buckets.chirp_id
+    WHERE trend_hashtag_buckets.bucket_start >= sqlc.arg('window_start')::timestamptz
+    AND chirps.deleted_at IS NULL
+    AND chirp_visible_to(chirps.id, NULL)
+    GROUP BY trend_hashtag_buckets.tag, chirps.user_id
+) AS per_author
+GROUP BY per_author.tag
+ORDER BY 3 DESC
+LIMIT sqlc.arg('limit')::integer;
+
+-- name: DeleteTrendingChirps :exec
+DELETE FROM trending_chirps
+WHERE time_window = $1;
+
+-- name: ComputeTrendingChirps :exec
+-- window_start 이후 rollup으로 chirp 점수를 계산해서 상위 limit개 저장
+-- 같은 작성자의 chirp는 점수 순서대로 1, 1/2, 1/4, ...배로 줄여서 한 작성자가 목록을 채우지 못하게 한다
+-- 집계 후에 삭제되거나 더 이상 공개가 아닌 chirp는 빼고 계산한다
+INSERT INTO trending_chirps (time_window, chirp_id, score, computed_at)
+SELECT sqlc.arg('time_window')::text, ranked.chirp_id, ranked.score, NOW()
+FROM (
+    SELECT per_chirp.chirp_id,
+        per_chirp.activity * POWER(0.5, ROW_NUMBER() OVER (PARTITION BY chirps.user_id ORDER BY per_chirp.activity DESC) - 1) AS score
+    FROM (
+        SELECT trend_chirp_buckets.chirp_id,
+            SUM(trend_chirp_buckets.engagements
+                * POWER(0.5, EXTRACT(EPOCH FROM (NOW() - trend_chirp_buckets.bucket_start)) / sqlc.arg('half_life_seconds')::float8)) AS activity
+        FROM trend_chirp_buckets
+        WHERE trend_chirp_buckets.bucket_start >= sqlc.arg('window_start')::timestamptz
+        GROUP BY trend_chirp_buckets.chirp_id
+    ) AS per_chirp
+    JOIN chirps ON chirps.id = per_chirp.chirp_id
+    WHERE chirps.deleted_at IS NULL
+    AND chirp_visible_to(chirps.id, NULL)
+) AS ranked
+ORDER BY ranked.score DESC
+LIMIT sqlc.arg('limit')::integer;
+
+-- name: GetTrendingHashtags :many
+SELECT * FROM trending_hashtags
+WHERE time_window = sqlc.arg('time_window')
+ORDER BY score DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingChirps :many
+-- 계산 후에 삭제되거나 비공개로 바뀐 chirp는 제외하고 점수 순서대로 반환
+SELECT chirps.* FROM trending_chirps
+JOIN chirps ON chirps.id = trending_chirps.chirp_id
+JOIN users ON users.id = chirps.user_id
+WHERE trending_chirps.time_window = sqlc.arg('time_window')
+AND chirps.status = 'published'
+AND chirps.deleted_at IS NULL
+AND chirps.visibility = 'public'
+AND NOT users.is_protected
+ORDER BY trending_chirps.score DESC
+LIMIT sqlc.arg('limit');
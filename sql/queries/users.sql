@@ -19,12 +19,43 @@ SET email = $1, hashed_password = $2, updated_at = NOW()
 WHERE id = $3
 RETURNING *;
 
--- name: UpdateUserMembership :one
+-- name: SyncUserMemberships :many
+-- is_chirpy_red를 구독 상태에서 다시 계산 (active, past_due 구독이 있으면 true)
 UPDATE users
-SET is_chirpy_red = true, updated_at = NOW()
-WHERE id = $1
+SET is_chirpy_red = EXISTS (
+    SELECT 1 FROM subscriptions
+    WHERE subscriptions.user_id = users.id
+    AND subscriptions.status IN ('active', 'past_due')
+), updated_at = NOW()
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
 RETURNING *;
 
 
--- name: ResetUsers :exec
-DELETE FROM users;
\ No newline at end of file
+-- name: ResetDatabase :exec
+-- /admin/reset (dev 전용) : users와 users를 참조하는 모든 테이블(CASCADE로 chirps, 대화, webhook 등),
+-- users를 참조하지 않아 유저를 지워도 남는 job, stream event, outbox, 해시태그 trend rollup, polka event 기록을 비운다
+-- link_previews는 유저 데이터가 아닌 url별 미리보기 cache라서 남겨둔다
+-- stream_events id sequence는 재시작하지 않는다 (접속 중인 client의 last_event_id보다 작은 id가 다시 나오지 않도록)
+TRUNCATE users, jobs, stream_events, stream_event_outbox, trend_hashtag_buckets, trending_hashtags, polka_events CASCADE;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+-- 트랜잭션이 끝날 때까지 유저 행을 잠가서 같은 유저의 동시 요청들을 순서대로 처리
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateUserProtected :one
+UPDATE users
+SET is_protected = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserAutoExpandSensitive :one
+UPDATE users
+SET auto_expand_sensitive = $1, updated_at = NOW()
+WHERE id = $2
+RETURNING *;
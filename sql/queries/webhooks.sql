@@ -0,0 +1,165 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, user_id, url, secret, events, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    sqlc.narg('user_id')::uuid,
+    sqlc.arg('url')::text,
+    sqlc.arg('secret')::text,
+    sqlc.arg('events')::text[],
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: CountWebhookEndpoints :one
+-- user_id가 NULL이면 앱 endpoint 수
+SELECT COUNT(*) FROM webhook_endpoints
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid;
+
+-- name: GetWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid
+ORDER BY created_at, id;
+
+-- name: GetWebhookEndpoint :one
+-- user_id가 등록한 endpoint만 반환 (NULL이면 앱 endpoint)
+SELECT * FROM webhook_endpoints
+WHERE id = sqlc.arg('id')::uuid
+AND user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid;
+
+-- name: GetWebhookEndpointByID :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: UpdateWebhookEndpoint :one
+-- enabled가 true면 다시 켜고 실패 기록을 초기화, false면 끄고, NULL이면 그대로
+UPDATE webhook_endpoints
+SET url = sqlc.arg('url')::text,
+    events = sqlc.arg('events')::text[],
+    disabled_at = CASE
+        WHEN sqlc.narg('enabled')::boolean IS NULL THEN disabled_at
+        WHEN sqlc.narg('enabled')::boolean THEN NULL
+        ELSE COALESCE(disabled_at, NOW())
+    END,
+    disabled_reason = CASE
+        WHEN sqlc.narg('enabled')::boolean IS NULL THEN disabled_reason
+        WHEN sqlc.narg('enabled')::boolean THEN NULL
+        WHEN disabled_at IS NULL THEN 'disabled by owner'
+        ELSE disabled_reason
+    END,
+    consecutive_failures = CASE WHEN sqlc.narg('enabled')::boolean THEN 0 ELSE consecutive_failures END,
+    failing_since = CASE WHEN sqlc.narg('enabled')::boolean THEN NULL ELSE failing_since END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid
+AND user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = sqlc.arg('id')::uuid
+AND user_id IS NOT DISTINCT FROM sqlc.narg('user_id')::uuid;
+
+-- name: RecordWebhookEndpointSuccess :exec
+UPDATE webhook_endpoints
+SET consecutive_failures = 0,
+    failing_since = NULL,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RecordWebhookEndpointFailure :one
+-- 연속 실패가 max_failures 이상이고 처음 실패한 시간이 failing_before 이전이면 endpoint를 끈다
+-- (잠깐 내려간 endpoint는 실패가 몰려도 꺼지지 않도록 시간 조건도 같이 본다)
+UPDATE webhook_endpoints
+SET consecutive_failures = consecutive_failures + 1,
+    failing_since = COALESCE(failing_since, NOW()),
+    disabled_at = CASE
+        WHEN disabled_at IS NULL
+        AND consecutive_failures + 1 >= sqlc.arg('max_failures')::int
+        AND COALESCE(failing_since, NOW()) <= sqlc.arg('failing_before')::timestamp
+        THEN NOW()
+        ELSE disabled_at
+    END,
+    disabled_reason = CASE
+        WHEN disabled_at IS NULL
+        AND consecutive_failures + 1 >= sqlc.arg('max_failures')::int
+        AND COALESCE(failing_since, NOW()) <= sqlc.arg('failing_before')::timestamp
+        THEN 'too many failed deliveries'
+        ELSE disabled_reason
+    END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid
+RETURNING *;
+
+-- name: EnqueueWebhookDeliveries :many
+-- event를 받을 endpoint마다 전송을 하나씩 기록하고 id들을 반환 (event를 만든 트랜잭션의 q로 호출)
+-- owner_id 유저가 등록한 endpoint와, public이면 앱 endpoint도 받는다
+INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event, payload, status, next_attempt_at, created_at, updated_at)
+SELECT gen_random_uuid(),
+    webhook_endpoints.id,
+    sqlc.arg('event_id')::uuid,
+    sqlc.arg('event')::text,
+    sqlc.arg('payload')::jsonb,
+    'pending',
+    NOW(),
+    NOW(),
+    NOW()
+FROM webhook_endpoints
+WHERE webhook_endpoints.disabled_at IS NULL
+AND sqlc.arg('event')::text = ANY(webhook_endpoints.events)
+AND (
+    webhook_endpoints.user_id = sqlc.arg('owner_id')::uuid
+    OR (webhook_endpoints.user_id IS NULL AND sqlc.arg('public')::boolean)
+)
+RETURNING id;
+
+-- name: GetWebhookDeliveryByID :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: GetPendingWebhookDeliveryIDs :many
+-- endpoint가 꺼져 있는 동안 보내지 않고 남겨둔 전송들 (다시 켤 때 job을 다시 추가)
+SELECT id FROM webhook_deliveries
+WHERE endpoint_id = $1
+AND status = 'pending'
+ORDER BY created_at;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_attempt_at = NOW(),
+    response_status = sqlc.arg('response_status')::int,
+    last_error = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- next_attempt_at이 NULL이면 더 이상 시도하지 않는다 (failed)
+-- response_status는 응답을 받지 못했으면(연결 실패, timeout) NULL
+UPDATE webhook_deliveries
+SET status = CASE WHEN sqlc.narg('next_attempt_at')::timestamp IS NULL THEN 'failed' ELSE 'pending' END,
+    attempts = attempts + 1,
+    next_attempt_at = COALESCE(sqlc.narg('next_attempt_at')::timestamp, next_attempt_at),
+    last_attempt_at = NOW(),
+    response_status = sqlc.narg('response_status')::int,
+    last_error = sqlc.arg('last_error')::text,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')::uuid;
+
+-- name: GetWebhookDeliveries :many
+-- endpoint의 전송 기록을 최근 순으로 반환 (status가 주어지면 그 상태만, before_created_at, before_id가 주어지면 그 이전 것들만)
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg('endpoint_id')::uuid
+AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+AND (
+    sqlc.narg('before_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteWebhookDeliveriesBefore :execrows
+-- 보관 기간이 지난 끝난 전송 기록 삭제
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending'
+AND created_at < $1;
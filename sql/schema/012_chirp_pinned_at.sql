@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN pinned_at TIMESTAMP;
+
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN pinned_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+CHECK (status IN ('draft', 'scheduled', 'published'));
+
+-- 예약 게시 시간 (status가 scheduled일 때 사용)
+ALTER TABLE chirps
+ADD COLUMN publish_at TIMESTAMPTZ;
+
+-- 스케줄러가 게시할 chirp를 찾을 때 사용
+CREATE INDEX idx_chirps_scheduled ON chirps (publish_at)
+WHERE status = 'scheduled';
+
+
+-- +goose Down
+DROP INDEX idx_chirps_scheduled;
+
+ALTER TABLE chirps
+DROP COLUMN publish_at;
+
+ALTER TABLE chirps
+DROP COLUMN status;
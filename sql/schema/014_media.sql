@@ -0,0 +1,22 @@
+-- +goose Up
+-- 업로드된 이미지 정보 (파일 자체는 BlobStore에 media/<id>, media/<id>_thumb key로 저장)
+-- chirp_id가 NULL이면 아직 chirp에 첨부되지 않은 media
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    -- chirp 안에서의 순서 (1부터)
+    position INTEGER,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    thumbnail_content_type TEXT NOT NULL
+);
+
+CREATE INDEX idx_media_chirp_id ON media (chirp_id);
+
+
+-- +goose Down
+DROP TABLE media;
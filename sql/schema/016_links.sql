@@ -0,0 +1,33 @@
+-- +goose Up
+-- chirp 본문에서 찾은 url들 (start_index, end_index는 본문에서의 rune 위치)
+CREATE TABLE chirp_links (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    start_index INTEGER NOT NULL,
+    end_index INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, position)
+);
+
+-- url별 미리보기 캐시 : 같은 url이 여러 chirp에 있어도 한번만 가져온다
+-- pending(대기) ==> fetching(worker가 가져가는 중) ==> ok 또는 failed
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'fetching', 'ok', 'failed')),
+    title TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    claimed_at TIMESTAMPTZ,
+    fetched_at TIMESTAMPTZ
+);
+
+-- worker가 가져갈 미리보기를 찾을 때 사용
+CREATE INDEX idx_link_previews_queue ON link_previews (created_at)
+WHERE status IN ('pending', 'fetching');
+
+
+-- +goose Down
+DROP TABLE link_previews;
+DROP TABLE chirp_links;
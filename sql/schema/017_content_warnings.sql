@@ -0,0 +1,28 @@
+-- +goose Up
+-- 내용 경고 문구와 민감한 media 여부
+-- content_warning_set_by는 경고를 붙인 유저 (작성자 본인 또는 moderator)
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT,
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN content_warning_set_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- 경고가 붙은 chirp를 접지 않고 바로 펼쳐서 볼지 여부
+ALTER TABLE users
+ADD COLUMN auto_expand_sensitive BOOLEAN NOT NULL DEFAULT false;
+
+-- 다른 유저의 chirp에 경고를 붙일 수 있는 moderator 여부 (db에서 직접 지정)
+ALTER TABLE users
+ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT false;
+
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_moderator;
+
+ALTER TABLE users
+DROP COLUMN auto_expand_sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN content_warning_set_by,
+DROP COLUMN sensitive,
+DROP COLUMN content_warning;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- chirp를 바로 지우지 않고 삭제 시간, 삭제한 유저(작성자 또는 moderator), 사유를 남긴다
+-- 보관 기간이 지난 chirp는 retention job이 실제로 삭제
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMPTZ,
+ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN deletion_reason TEXT;
+
+-- retention job이 보관 기간이 지난 chirp를 찾을 때 사용
+CREATE INDEX idx_chirps_deleted_at ON chirps (deleted_at)
+WHERE deleted_at IS NOT NULL;
+
+
+-- +goose Down
+DROP INDEX idx_chirps_deleted_at;
+
+ALTER TABLE chirps
+DROP COLUMN deletion_reason,
+DROP COLUMN deleted_by,
+DROP COLUMN deleted_at;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- chirp 본문의 hashtag (# 없이 NFC, 소문자로 정규화해서 저장)
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+-- 집계 worker가 최근 활동만 읽을 때 사용
+CREATE INDEX idx_chirps_created_at ON chirps (created_at);
+CREATE INDEX idx_rechirps_created_at ON rechirps (created_at);
+
+-- 5분 단위 hashtag 활동량 rollup (집계 worker가 채운다)
+-- 점수 계산 때 작성자별로 감쇠할 수 있도록 작성자별로 나눠서 저장
+CREATE TABLE trend_hashtag_buckets (
+    bucket_start TIMESTAMPTZ NOT NULL,
+    tag TEXT NOT NULL,
+    author_id UUID NOT NULL,
+    chirps INTEGER NOT NULL,
+    engagements INTEGER NOT NULL,
+    PRIMARY KEY (bucket_start, tag, author_id)
+);
+
+-- 5분 단위 chirp별 반응(rechirp, 인용) 수 rollup
+CREATE TABLE trend_chirp_buckets (
+    bucket_start TIMESTAMPTZ NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    engagements INTEGER NOT NULL,
+    PRIMARY KEY (bucket_start, chirp_id)
+);
+
+-- 구간(1h, 24h)별로 계산해둔 trend 점수 : GET /api/trends는 이 테이블들만 읽는다
+CREATE TABLE trending_hashtags (
+    time_window TEXT NOT NULL CHECK (time_window IN ('1h', '24h')),
+    tag TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    chirp_count INTEGER NOT NULL,
+    author_count INTEGER NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (time_window, tag)
+);
+
+CREATE TABLE trending_chirps (
+    time_window TEXT NOT NULL CHECK (time_window IN ('1h', '24h')),
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (time_window, chirp_id)
+);
+
+
+-- +goose Down
+DROP TABLE trending_chirps;
+DROP TABLE trending_hashtags;
+DROP TABLE trend_chirp_buckets;
+DROP TABLE trend_hashtag_buckets;
+DROP INDEX idx_rechirps_created_at;
+DROP INDEX idx_chirps_created_at;
+DROP TABLE chirp_hashtags;
@@ -0,0 +1,51 @@
+-- +goose Up
+-- 유저 차단 : 차단 관계인 유저와는 대화를 시작하거나 1:1 메시지를 주고받을 수 없고
+-- 그룹 대화에서는 내가 차단한 유저의 메시지가 보이지 않는다
+CREATE TABLE user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    -- 마지막 메시지 시간 (대화 목록 정렬 기준)
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    is_group BOOLEAN NOT NULL,
+    -- 같은 두 유저의 1:1 대화가 여러개 생기지 않도록 두 유저 id를 정렬해서 이어붙인 값 (그룹 대화는 NULL)
+    direct_key TEXT UNIQUE
+);
+
+-- last_read_at : 마지막으로 읽은 메시지의 created_at (읽음 표시와 안읽은 메시지 수 계산에 사용)
+CREATE TABLE conversation_participants (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+-- 유저의 대화 목록을 찾을 때 사용
+CREATE INDEX idx_conversation_participants_user_id ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- 대화의 메시지를 최근 순으로 pagination할 때 사용
+CREATE INDEX idx_messages_conversation_id ON messages (conversation_id, created_at, id);
+
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
+DROP TABLE user_blocks;
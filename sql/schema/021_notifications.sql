@@ -0,0 +1,54 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX idx_chirp_likes_chirp_id ON chirp_likes (chirp_id);
+
+-- 답글이면 답글을 단 chirp
+ALTER TABLE chirps
+ADD COLUMN in_reply_to_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_chirps_in_reply_to_chirp_id ON chirps (in_reply_to_chirp_id);
+
+-- 알림 : 같은 group_key의 안읽은 알림은 하나로 묶이고(ex: 같은 chirp의 좋아요) 행동한 유저들은 notification_actors에 쌓인다
+-- updated_at : 마지막으로 묶인 시간 (목록 정렬 기준)
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL
+    CHECK (type IN ('mention', 'reply', 'like', 'follow', 'follow_request', 'chirpy_red')),
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP
+);
+
+-- 안읽은 알림은 그룹별로 하나만 있도록 해서 새 알림을 기존 알림에 묶는다
+CREATE UNIQUE INDEX idx_notifications_unread_group ON notifications (user_id, group_key)
+WHERE read_at IS NULL;
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, updated_at);
+
+CREATE TABLE notification_actors (
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+
+-- +goose Down
+DROP TABLE notification_actors;
+DROP TABLE notifications;
+
+DROP INDEX idx_chirps_in_reply_to_chirp_id;
+
+ALTER TABLE chirps
+DROP COLUMN in_reply_to_chirp_id;
+
+DROP TABLE chirp_likes;
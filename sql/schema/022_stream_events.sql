@@ -0,0 +1,34 @@
+-- +goose Up
+-- 실시간 stream(SSE)으로 보내는 event 기록 : 접속이 끊긴 client는 Last-Event-ID(id) 이후의 event부터 다시 받는다
+-- chirp event(chirp_created, chirp_deleted)는 author_id, 알림 event(notification)는 recipient_id로 받는 유저를 거른다
+CREATE TABLE stream_events (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL
+    CHECK (type IN ('chirp_created', 'chirp_deleted', 'notification')),
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    notification_id UUID REFERENCES notifications(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_stream_events_created_at ON stream_events (created_at);
+
+-- event가 커밋되면 모든 서버 인스턴스의 LISTEN 연결에 새 event id를 알린다
+-- +goose StatementBegin
+CREATE FUNCTION notify_stream_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('stream_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER stream_events_notify
+AFTER INSERT ON stream_events
+FOR EACH ROW EXECUTE FUNCTION notify_stream_event();
+
+-- +goose Down
+DROP TRIGGER stream_events_notify ON stream_events;
+DROP FUNCTION notify_stream_event();
+DROP TABLE stream_events;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- websocket 채널(timeline, thread, conversation)로 보내는 좋아요, 메시지 event도 stream_events에 기록
+-- actor_id : 좋아요한 유저, 메시지를 보낸 유저
+ALTER TABLE stream_events
+DROP CONSTRAINT stream_events_type_check,
+ADD CONSTRAINT stream_events_type_check
+CHECK (type IN ('chirp_created', 'chirp_deleted', 'chirp_liked', 'chirp_unliked', 'message_created', 'notification')),
+ADD COLUMN actor_id UUID REFERENCES users(id) ON DELETE CASCADE,
+ADD COLUMN conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+ADD COLUMN message_id UUID REFERENCES messages(id) ON DELETE CASCADE;
+
+-- websocket 연결별 접속 상태 : 연결이 살아있는 동안 주기적으로 last_seen_at을 갱신하고
+-- last_seen_at이 오래된 session은 (서버가 갑자기 꺼진 경우 등) 접속 중으로 보지 않는다
+CREATE TABLE presence_sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL
+    CHECK (status IN ('online', 'away')),
+    last_seen_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_presence_sessions_user_id ON presence_sessions (user_id);
+
+-- +goose Down
+DROP TABLE presence_sessions;
+
+DELETE FROM stream_events
+WHERE type IN ('chirp_liked', 'chirp_unliked', 'message_created');
+
+ALTER TABLE stream_events
+DROP COLUMN message_id,
+DROP COLUMN conversation_id,
+DROP COLUMN actor_id,
+DROP CONSTRAINT stream_events_type_check,
+ADD CONSTRAINT stream_events_type_check
+CHECK (type IN ('chirp_created', 'chirp_deleted', 'notification'));
@@ -0,0 +1,12 @@
+-- +goose Up
+-- 처리한 polka webhook event id : 같은 event가 다시 와도 한번만 처리한다
+CREATE TABLE polka_events (
+    id TEXT PRIMARY KEY,
+    event TEXT NOT NULL,
+    received_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_polka_events_received_at ON polka_events (received_at);
+
+-- +goose Down
+DROP TABLE polka_events;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- chirpy red 구독 : 유저마다 하나, 다시 구독하면 같은 행을 다시 쓴다
+-- status
+--   active : 이번 기간(current_period_start ~ current_period_end) 결제 완료
+--   past_due : 갱신 결제 실패, grace_until까지는 혜택 유지
+--   canceled : 해지 후 기간이 끝남
+--   expired : 갱신되지 않고 기간이 끝남
+--   refunded : 환불로 바로 끝남
+-- cancel_at_period_end : 해지했지만 이번 기간이 끝날 때까지는 active
+-- users.is_chirpy_red는 이 표에서 계산되는 값 (status가 active, past_due면 true)
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL
+    CHECK (status IN ('active', 'past_due', 'canceled', 'expired', 'refunded')),
+    current_period_start TIMESTAMP NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    canceled_at TIMESTAMP,
+    payment_failed_at TIMESTAMP,
+    grace_until TIMESTAMP,
+    ended_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- 만료 job이 끝난 기간을 찾을 때 사용
+CREATE INDEX idx_subscriptions_status_period_end ON subscriptions (status, current_period_end);
+
+-- 이미 chirpy red인 유저들은 지금부터 한 달짜리 구독으로 옮긴다 (그 뒤로는 polka의 갱신 event로 연장)
+INSERT INTO subscriptions (id, user_id, plan, status, current_period_start, current_period_end, created_at, updated_at)
+SELECT gen_random_uuid(), id, 'chirpy_red', 'active', NOW(), NOW() + INTERVAL '1 month', NOW(), NOW()
+FROM users
+WHERE is_chirpy_red;
+
+-- +goose Down
+DROP TABLE subscriptions;
@@ -0,0 +1,56 @@
+-- +goose Up
+-- 외부 서비스가 등록한 webhook endpoint
+-- user_id가 있으면 그 유저가 등록한 endpoint로 유저 본인과 관련된 event만 받고
+-- NULL이면 앱(관리자 키로 등록)의 endpoint로 공개된 event를 모두 받는다
+-- events : 받을 event 종류 (chirp.created, chirp.deleted, user.followed)
+-- consecutive_failures, failing_since : 연속으로 실패한 전송 수와 처음 실패한 시간 (성공하면 초기화)
+-- disabled_at : 계속 실패해서 자동으로 꺼지거나 등록한 쪽이 끈 시간 (꺼진 endpoint에는 전송하지 않는다)
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    failing_since TIMESTAMP,
+    disabled_at TIMESTAMP,
+    disabled_reason TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_webhook_endpoints_user_id ON webhook_endpoints (user_id);
+
+-- 전송할 webhook (outbox) : event를 만든 트랜잭션에서 같이 기록하고 worker가 전송한다
+-- event_id : 같은 event를 받는 endpoint들이 공유하는 id (받는 쪽에서 중복 제거에 사용)
+-- status
+--   pending : 전송 전이거나 next_attempt_at에 다시 시도
+--   delivered : 2xx 응답을 받음
+--   failed : 최대 시도 횟수를 넘김
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- worker가 보낼 차례인 전송을 찾을 때 사용
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at)
+WHERE status = 'pending';
+
+-- 전송 기록 목록
+CREATE INDEX idx_webhook_deliveries_endpoint_id ON webhook_deliveries (endpoint_id, created_at);
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_endpoints;
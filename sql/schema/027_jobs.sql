@@ -0,0 +1,52 @@
+-- +goose Up
+-- background job 대기열 : job은 필요한 DB 변경과 같은 트랜잭션에서 추가하고 worker가 커밋 이후에 실행한다
+-- status
+--   pending : run_at 이후 실행 대기 (실패한 job은 run_at을 미뤄서 다시 pending)
+--   running : worker가 locked_until까지 실행 중 (그때까지 끝나지 않으면 worker가 죽은 것으로 보고 다시 실행)
+--   succeeded : 실행 완료
+--   dead : max_attempts만큼 실패했거나 다시 시도해도 소용없는 에러 (관리자가 확인 후 다시 시도)
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'running', 'succeeded', 'dead')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+-- worker가 실행할 job을 찾을 때 사용
+CREATE INDEX idx_jobs_queue ON jobs (run_at)
+WHERE status IN ('pending', 'running');
+
+-- 관리자 목록
+CREATE INDEX idx_jobs_status_created_at ON jobs (status, created_at);
+
+-- 링크 미리보기는 link_previews 표의 자체 대기열 대신 job으로 가져온다
+-- 가져오는 중이던 미리보기도 다시 대기시키고 대기 중인 미리보기마다 job 추가
+UPDATE link_previews SET status = 'pending' WHERE status = 'fetching';
+
+INSERT INTO jobs (id, kind, payload, status, max_attempts, run_at, created_at, updated_at)
+SELECT gen_random_uuid(), 'link.preview', jsonb_build_object('url', url), 'pending', 5, NOW(), NOW(), NOW()
+FROM link_previews
+WHERE status = 'pending';
+
+DROP INDEX idx_link_previews_queue;
+
+ALTER TABLE link_previews
+DROP COLUMN claimed_at;
+
+-- +goose Down
+ALTER TABLE link_previews
+ADD COLUMN claimed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_link_previews_queue ON link_previews (created_at)
+WHERE status IN ('pending', 'fetching');
+
+DROP TABLE jobs;
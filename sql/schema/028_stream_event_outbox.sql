@@ -0,0 +1,42 @@
+-- +goose Up
+-- event를 기록하는 트랜잭션은 outbox에만 추가하고, publisher가 커밋된 outbox event를 stream_events로 옮기면서 id를 붙인다
+-- (예전에는 id 순서와 커밋 순서를 맞추려고 event를 기록하는 모든 트랜잭션을 커밋까지 전역 lock으로 줄 세웠다)
+-- publisher 하나만 id를 붙이므로 구독자는 Last-Event-ID 이후만 읽어도 event를 놓치지 않는다
+CREATE TABLE stream_event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL
+    CHECK (type IN ('chirp_created', 'chirp_deleted', 'chirp_liked', 'chirp_unliked', 'message_created', 'notification')),
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    notification_id UUID REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- outbox에 event가 커밋되면 모든 서버 인스턴스의 publisher를 깨운다
+-- +goose StatementBegin
+CREATE FUNCTION notify_stream_event_pending() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('stream_events_pending', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER stream_event_outbox_notify
+AFTER INSERT ON stream_event_outbox
+FOR EACH STATEMENT EXECUTE FUNCTION notify_stream_event_pending();
+
+-- +goose Down
+-- 아직 옮기지 않은 event는 stream_events로 옮기고 지운다
+INSERT INTO stream_events (type, chirp_id, author_id, recipient_id, notification_id, actor_id, conversation_id, message_id, created_at)
+SELECT type, chirp_id, author_id, recipient_id, notification_id, actor_id, conversation_id, message_id, created_at
+FROM stream_event_outbox
+ORDER BY id;
+
+DROP TRIGGER stream_event_outbox_notify ON stream_event_outbox;
+DROP FUNCTION notify_stream_event_pending();
+DROP TABLE stream_event_outbox;
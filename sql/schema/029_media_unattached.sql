@@ -0,0 +1,7 @@
+-- +goose Up
+-- 업로드한 뒤 첨부되지 않은 media를 정리할 때 사용
+CREATE INDEX idx_media_unattached_created_at ON media (created_at)
+WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP INDEX idx_media_unattached_created_at;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- 게시된 시간 (임시저장, 예약 chirp는 게시될 때 채워진다)
+-- 타임라인은 created_at 대신 이 값으로 정렬한다
+ALTER TABLE chirps
+ADD COLUMN published_at TIMESTAMP;
+
+-- 지금까지는 게시할 때 created_at을 게시 시간으로 바꿨으므로 그대로 옮긴다
+UPDATE chirps
+SET published_at = created_at
+WHERE status = 'published';
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN published_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- webhook 전송은 자체 대기열 대신 job(webhook.deliver)으로 보낸다
+-- webhook_deliveries는 전송 기록으로 남고 next_attempt_at은 다음 시도 예정 시간 표시용
+DROP INDEX idx_webhook_deliveries_pending;
+
+-- 대기 중인 전송마다 job 추가 (꺼진 endpoint의 전송은 다시 켤 때 추가된다)
+INSERT INTO jobs (id, kind, payload, status, max_attempts, run_at, created_at, updated_at)
+SELECT gen_random_uuid(), 'webhook.deliver', jsonb_build_object('delivery_id', webhook_deliveries.id), 'pending', 10, webhook_deliveries.next_attempt_at, NOW(), NOW()
+FROM webhook_deliveries
+JOIN webhook_endpoints ON webhook_endpoints.id = webhook_deliveries.endpoint_id
+WHERE webhook_deliveries.status = 'pending'
+AND webhook_endpoints.disabled_at IS NULL;
+
+-- +goose Down
+DELETE FROM jobs
+WHERE kind = 'webhook.deliver'
+AND status IN ('pending', 'running');
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at)
+WHERE status = 'pending';
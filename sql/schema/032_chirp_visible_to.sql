@@ -0,0 +1,38 @@
+-- +goose Up
+-- 보는 유저(viewer_id, 비로그인이면 NULL)가 chirp의 공개 범위 안에 있는지 확인하는 함수
+-- chirp를 읽는 모든 쿼리가 이 함수를 쓴다 (규칙이 바뀌면 여기만 고친다)
+-- 작성자 본인, 언급된 유저는 항상 볼 수 있고
+-- public chirp는 비공개 계정이 아니면 누구나, followers chirp(와 비공개 계정의 public chirp)는 승인된 팔로워만 볼 수 있다
+-- 게시 여부, 삭제 여부는 쿼리마다 다르게 다루므로 여기서 보지 않는다
+-- (인자 이름이 chirp_mentions.chirp_id 같은 column 이름과 겹치면 column이 우선되므로 target_chirp_id)
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(target_chirp_id UUID, viewer_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps
+        JOIN users ON users.id = chirps.user_id
+        WHERE chirps.id = target_chirp_id
+        AND (
+            chirps.user_id = viewer_id
+            OR EXISTS (
+                SELECT 1 FROM chirp_mentions
+                WHERE chirp_mentions.chirp_id = chirps.id
+                AND chirp_mentions.user_id = viewer_id
+            )
+            OR (chirps.visibility = 'public' AND NOT users.is_protected)
+            OR (chirps.visibility IN ('public', 'followers') AND EXISTS (
+                SELECT 1 FROM follows
+                WHERE follows.followee_id = chirps.user_id
+                AND follows.follower_id = viewer_id
+                AND follows.accepted_at IS NOT NULL
+            ))
+        )
+    );
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to(UUID, UUID);
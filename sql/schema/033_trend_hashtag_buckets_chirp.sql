@@ -0,0 +1,20 @@
+-- +goose Up
+-- hashtag rollup을 작성자별이 아니라 chirp별로 저장
+-- ==> 집계 후에 chirp가 삭제되거나 작성자가 비공개 계정이 되면 점수를 계산할 때 현재 공개 여부로 거를 수 있다
+-- rollup은 chirps, rechirps로 다시 만들 수 있으므로 비우고 (서버 시작 때 집계 worker가 가장 긴 구간 전체를 다시 집계한다)
+DELETE FROM trend_hashtag_buckets;
+
+ALTER TABLE trend_hashtag_buckets
+DROP CONSTRAINT trend_hashtag_buckets_pkey,
+DROP COLUMN author_id,
+ADD COLUMN chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+ADD PRIMARY KEY (bucket_start, tag, chirp_id);
+
+-- +goose Down
+DELETE FROM trend_hashtag_buckets;
+
+ALTER TABLE trend_hashtag_buckets
+DROP CONSTRAINT trend_hashtag_buckets_pkey,
+DROP COLUMN chirp_id,
+ADD COLUMN author_id UUID NOT NULL,
+ADD PRIMARY KEY (bucket_start, tag, author_id);
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// stream_events.type 값
+const (
+	streamEventChirpCreated   = "chirp_created"
+	streamEventChirpDeleted   = "chirp_deleted"
+	streamEventChirpLiked     = "chirp_liked"
+	streamEventChirpUnliked   = "chirp_unliked"
+	streamEventMessageCreated = "message_created"
+	streamEventNotification   = "notification"
+)
+
+const (
+	// 새 event id를 알리는 postgres NOTIFY channel (022_stream_events.sql의 trigger)
+	streamNotifyChannel = "stream_events"
+	// outbox에 새 event가 커밋됐다고 publisher에게 알리는 postgres NOTIFY channel (028_stream_event_outbox.sql의 trigger)
+	streamPendingChannel = "stream_events_pending"
+	// NOTIFY를 놓쳐도 outbox event를 옮기도록 publisher가 확인하는 주기
+	streamPublishInterval = 5 * time.Second
+	// 저장하지 않는 실시간 신호(입력 중, 접속 상태)를 알리는 postgres NOTIFY channel
+	realtimeSignalChannel = "realtime_signals"
+	// websocket 구독자별로 아직 처리하지 못한 신호를 쌓아두는 최대 개수 (넘치면 버린다)
+	realtimeSignalBuffer = 64
+	// LISTEN 연결이 살아있는지 확인하는 주기
+	streamListenerPingInterval = 90 * time.Second
+	// LISTEN이 실패했을 때 다시 시도하기 전 기다리는 시간 (실패할 때마다 2배씩 최대 streamListenRetryMaxDelay까지)
+	streamListenRetryDelay    = time.Second
+	streamListenRetryMaxDelay = time.Minute
+	// Last-Event-ID로 이어받을 수 있도록 event를 보관하는 기간
+	streamEventRetention = 24 * time.Hour
+	// 보관 기간이 지난 event를 지우는 주기
+	streamEventRetentionInterval = time.Hour
+	// 서버 종료 때 구독자들이 모두 끊겼는지 확인하는 주기
+	streamShutdownPollInterval = 100 * time.Millisecond
+)
+
+// 입력 중, 접속 상태 신호 (realtime_signals channel의 payload)
+type realtimeSignal struct {
+	// realtimeSignalTyping 또는 realtimeSignalPresence
+	Type string `json:"type"`
+	// 입력 중 신호의 대화 (접속 상태 신호는 대화와 상관없이 보낸다)
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	// 접속 상태 : online, away, offline
+	Status string `json:"status,omitempty"`
+}
+
+// realtimeSignal.Type 값
+const (
+	realtimeSignalTyping   = "typing"
+	realtimeSignalPresence = "presence"
+	// UserID의 차단 목록이 바뀜 (그 유저의 websocket 연결들이 차단 목록을 다시 읽는다)
+	realtimeSignalBlocks = "blocks"
+)
+
+// stream 구독자 하나 (SSE 또는 websocket 연결)
+type streamSubscriber struct {
+	// 새 event가 커밋되면 값이 들어온다
+	wake chan struct{}
+	// 실시간 신호 (SSE 구독자는 nil)
+	signals chan realtimeSignal
+}
+
+// 이 서버 인스턴스에 접속 중인 stream 구독자들에게 새 event가 커밋됐다고 알리는 구조체
+// event 내용은 보내지 않고 깨우기만 한다 ==> 구독자는 자기 Last-Event-ID 이후의 event를 db에서 직접 읽는다
+// (구독자마다 볼 수 있는 chirp가 다르고, 알림이 몰려와도 channel 하나에 묶여 한번만 읽는다)
+// 저장하지 않는 실시간 신호는 websocket 구독자들에게 그대로 전달한다
+type streamHub struct {
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]struct{}
+	// 연결 종류별 구독자 수
+	sseCount       int
+	websocketCount int
+	// 서버가 종료 중이면 닫힌다 (구독자는 연결을 끝낸다)
+	closed    chan struct{}
+	closeOnce sync.Once
+	// outbox에 새 event가 커밋되면 값이 들어온다 (publisher를 깨운다)
+	pending chan struct{}
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{
+		subscribers: make(map[*streamSubscriber]struct{}),
+		closed:      make(chan struct{}),
+		pending:     make(chan struct{}, 1),
+	}
+}
+
+// 구독자를 등록하고 구독 해제 함수와 함께 반환
+// websocket이면 실시간 신호도 받는다
+func (h *streamHub) subscribe(websocket bool) (*streamSubscriber, func()) {
+	sub := &streamSubscriber{
+		wake: make(chan struct{}, 1),
+	}
+	if websocket {
+		sub.signals = make(chan realtimeSignal, realtimeSignalBuffer)
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.addCount(websocket, 1)
+	h.mu.Unlock()
+
+	return sub, func() {
+		h.mu.Lock()
+		delete(h.subscribers, sub)
+		h.addCount(websocket, -1)
+		h.mu.Unlock()
+	}
+}
+
+func (h *streamHub) addCount(websocket bool, delta int) {
+	if websocket {
+		h.websocketCount += delta
+	} else {
+		h.sseCount += delta
+	}
+}
+
+// 접속 중인 SSE, websocket 연결 수
+func (h *streamHub) counts() (sse, websocket int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sseCount, h.websocketCount
+}
+
+// 서버 종료 때 모든 구독자에게 연결을 끝내라고 알리는 함수 (http.Server.RegisterOnShutdown으로 등록)
+// SSE는 handler가 끝나고, websocket은 재접속 hint를 받고 끊긴다
+func (h *streamHub) close() {
+	h.closeOnce.Do(func() { close(h.closed) })
+}
+
+// 구독자가 모두 구독 해제할 때까지 기다리는 함수
+// http.Server.Shutdown은 hijack된 websocket 연결을 기다리지 않으므로 db를 닫기 전에 따로 기다린다
+func (h *streamHub) wait(ctx context.Context) error {
+	ticker := time.NewTicker(streamShutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if sse, websocket := h.counts(); sse+websocket == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// publisher를 깨우는 함수 (이미 깨울 예정이면 건너뛴다)
+func (h *streamHub) notifyPending() {
+	select {
+	case h.pending <- struct{}{}:
+	default:
+	}
+}
+
+// 모든 구독자를 깨우는 함수
+// channel에 이미 값이 있는 구독자는 아직 읽기 전이므로 건너뛴다 (느린 구독자 때문에 막히지 않도록)
+func (h *streamHub) broadcast() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// websocket 구독자들에게 실시간 신호를 전달하는 함수 (받을지는 구독자가 판단)
+// 처리가 밀린 구독자에게는 버린다 (입력 중, 접속 상태는 곧 다시 온다)
+func (h *streamHub) broadcastSignal(signal realtimeSignal) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if sub.signals == nil {
+			continue
+		}
+		select {
+		case sub.signals <- signal:
+		default:
+		}
+	}
+}
+
+// 실시간 신호를 모든 서버 인스턴스에 알리는 함수
+func (cfg *apiConfig) sendRealtimeSignal(ctx context.Context, signal realtimeSignal) error {
+	payload, err := json.Marshal(signal)
+	if err != nil {
+		return err
+	}
+	return cfg.ptrDB.NotifyRealtimeSignal(ctx, string(payload))
+}
+
+// stream event를 기록하는 함수 (chirp 게시, 삭제, 알림과 같은 트랜잭션의 q로 호출)
+// outbox에 추가만 하므로 다른 트랜잭션을 기다리지 않는다
+// 커밋되면 publisher가 id를 붙여서 옮기고, trigger의 NOTIFY로 모든 서버 인스턴스의 구독자에게 전달된다
+func recordStreamEvent(ctx context.Context, q *database.Queries, params database.QueueStreamEventParams) error {
+	return q.QueueStreamEvent(ctx, params)
+}
+
+// outbox에 커밋된 event를 stream_events로 옮기는 background worker
+// 모든 서버 인스턴스에서 돌지만 publisher lock으로 한번에 하나씩 옮기므로 id 순서와 커밋 순서가 같다
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runStreamPublisher(ctx context.Context) {
+	ticker := time.NewTicker(streamPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.publishStreamEvents(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Error publishing stream events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-cfg.streamHub.pending:
+		case <-ticker.C:
+		}
+	}
+}
+
+// outbox event를 한 트랜잭션에서 옮기는 함수 (lock은 커밋할 때 풀린다)
+func (cfg *apiConfig) publishStreamEvents(ctx context.Context) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	if err := qtx.LockStreamEventPublisher(ctx); err != nil {
+		return err
+	}
+	if _, err := qtx.PublishStreamEvents(ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// postgres LISTEN으로 다른 서버 인스턴스를 포함한 모든 곳에서 커밋된 event를 받아 구독자들을 깨우는 background worker
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runStreamListener(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Error in stream listener connection", "error", err)
+		}
+	})
+	// Listen은 db에 연결될 때까지 block되므로 ctx가 취소되면 listener를 닫아서 깨운다
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+	defer listener.Close()
+
+	for _, channel := range []string{streamNotifyChannel, streamPendingChannel, realtimeSignalChannel} {
+		if !listenWithRetry(ctx, listener, channel) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(streamListenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n != nil && n.Channel == streamPendingChannel {
+				cfg.streamHub.notifyPending()
+				continue
+			}
+			if n != nil && n.Channel == realtimeSignalChannel {
+				signal := realtimeSignal{}
+				if err := json.Unmarshal([]byte(n.Extra), &signal); err != nil {
+					slog.Error("Error decoding realtime signal", "error", err)
+					continue
+				}
+				cfg.streamHub.broadcastSignal(signal)
+				continue
+			}
+			// 재연결되면 nil이 오는데 끊긴 동안 놓친 event가 있을 수 있으므로 이때도 깨운다
+			if n == nil {
+				cfg.streamHub.notifyPending()
+			}
+			cfg.streamHub.broadcast()
+		case <-ticker.C:
+			if err := listener.Ping(); err != nil && ctx.Err() == nil {
+				slog.Error("Error pinging stream listener", "error", err)
+			}
+		}
+	}
+}
+
+// channel을 LISTEN하는 함수 : 실패하면 ctx가 취소될 때까지 backoff로 다시 시도한다
+// (한번 실패했다고 worker가 끝나면 서버를 재시작할 때까지 SSE, websocket에 event가 전달되지 않는다)
+// ctx가 취소되어 멈췄으면 false 반환
+func listenWithRetry(ctx context.Context, listener *pq.Listener, channel string) bool {
+	delay := streamListenRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := listener.Listen(channel)
+		if err == nil || errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		slog.Error("Error listening to channel", "channel", channel, "attempt", attempt, "retry_in", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, streamListenRetryMaxDelay)
+	}
+}
+
+// 보관 기간이 지난 stream event와 끊긴 websocket 연결의 접속 상태를 주기적으로 지우는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runStreamEventRetention(ctx context.Context) {
+	ticker := time.NewTicker(streamEventRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := cfg.ptrDB.DeleteStreamEventsBefore(ctx, time.Now().Add(-streamEventRetention)); err != nil && ctx.Err() == nil {
+			slog.Error("Error deleting expired stream events", "error", err)
+		}
+		if _, err := cfg.ptrDB.DeleteStalePresenceSessions(ctx, time.Now().Add(-presenceSessionTTL)); err != nil && ctx.Err() == nil {
+			slog.Error("Error deleting stale presence sessions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
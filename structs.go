@@ -1,56 +1,202 @@
 package main
 
 import (
+	"database/sql"
 	"net/http"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/blobstore"
 	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/links"
+	"github.com/paokimsiwoong/chirpy/internal/metrics"
 )
 
 type apiConfig struct {
-	// standard-library type that allows us to safely increment and read an integer value across multiple goroutines (HTTP requests in this project)
-	fileserverHits atomic.Int32
-	// db 쿼리함수 접근을 위한 포인터
+	// /metrics, /admin/metrics에 보여줄 metric들 (요청 수, 쿼리 시간, 로그인 수 등)
+	metrics *metrics.Metrics
+	// db 쿼리함수 접근을 위한 포인터 (쿼리 시간을 기록하는 wrapper 위에서 동작)
 	ptrDB *database.Queries
+	// 트랜잭션 시작(BeginTx)에 쓰이는 db 연결 ==> cfg.txQueries(tx)로 트랜잭션 안에서 쿼리 실행
+	db *sql.DB
 	// dev냐 일반유저냐에 따라 몇몇 페이지 제한 여부가 갈림
 	platform string
 	// JWT 생성에 사용할 시크릿 키
 	tokenSecret string
-	// polka webhook 인증에 쓰이는 api 키
-	polkaKey string
-}
-
-// 이 wrapper method로 http.Handler를 감싸는 새로운 http.Handler 반환
-// 이 새 http.Handler는 원본 http.Handler의 ServeHTTP메소드를 그대로 호출하면서 추가로 fileserverHits 가 1씩 증가
-func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
-
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.fileserverHits.Add(1)
-		// atomic.Int32 타입의 method Add (func (x *atomic.Int32) Add(delta int32) (new int32))로 값 변경
-		next.ServeHTTP(w, r)
-	})
-	// @@@@@ type HandlerFunc func(ResponseWriter, *Request)은 일반 함수를 http.Handler로 취급할 수 있게 해주는 일종의 툴
-	// @@@@@ http.HandlerFunc(함수)로 함수를 HandlerFunc 타입으로 형변환을 하면 이 HandlerFunc 타입은 ServeHTTP 메소드를 가지고 있으므로 http.Handler 인터페이스를 구현한다
-	// @@@@@ 단 함수 시그니처가 func(ResponseWriter, *Request)여야 한다
-	// @@@@@@@@ HandlerFunc 타입의 ServeHTTP 메소드 : func (f http.HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) { f(w,r) }
-	// @@@@@@@@ ===> 단순히 HandlerFunc 타입인 함수 자기 자신을 호출
-	// https://pkg.go.dev/net/http#HandlerFunc
+	// polka webhook 인증과 서명 확인에 쓰이는 키들 (키 교체 중에는 현재 키와 이전 키 둘 다 유효)
+	polkaKeys []string
+	// 업로드된 media 파일 저장소
+	blobStore blobstore.BlobStore
+	// chirp 최대 길이 (grapheme cluster 수). chirpy red 유저는 chirpMaxLengthRed
+	chirpMaxLength    int
+	chirpMaxLengthRed int
+	// chirp 속 url의 미리보기를 가져오는 fetcher
+	linkFetcher *links.Fetcher
+	// 실시간 stream(SSE) 구독자들
+	streamHub *streamHub
+	// 외부 webhook 전송에 쓰이는 http client (PLATFORM=dev가 아니면 공인 주소로만 보낸다)
+	webhookClient *http.Client
+	// 관리자 api(/admin/webhooks, /admin/jobs)에 쓰이는 키 (비어있으면 관리자 api 사용 불가)
+	adminKey string
 }
 
 // @@@ 여러개의 함수에서 사용되는 구조체들은 structs.go에 저장
 type cReqBody struct {
 	Body string `json:"body"`
 	// UserID uuid.UUID `json:"user_id"` //@@@ auth.ValidateJWT가 토큰정보를 받아 uuid 반환하므로 삭제
+	// 비어있으면 "public"
+	Visibility       string      `json:"visibility"`
+	MentionedUserIDs []uuid.UUID `json:"mentioned_user_ids"`
+	// quote chirp일 경우 인용할 chirp의 id
+	QuotedChirpID *uuid.UUID `json:"quoted_chirp_id"`
+	// 답글일 경우 답글을 달 chirp의 id
+	InReplyToChirpID *uuid.UUID `json:"in_reply_to_chirp_id"`
+	// 예약 게시 시간 (미래 시간만 가능)
+	PublishAt *time.Time `json:"publish_at"`
+	// true면 게시하지 않고 임시저장
+	Draft bool `json:"draft"`
+	// 첨부할 media id들 (POST /api/media로 먼저 업로드, 최대 maxChirpMedia개)
+	MediaIDs []uuid.UUID `json:"media_ids"`
+	// 함께 만들 투표 (선택)
+	Poll *pollReqBody `json:"poll"`
+	// 내용 경고 문구 (비어있으면 경고 없음)
+	ContentWarning string `json:"content_warning"`
+	// 첨부 media가 민감한 내용인지 여부
+	Sensitive bool `json:"sensitive"`
 }
 
+// chirp 공개 범위
+const (
+	// 누구나 (비공개 계정이면 승인된 팔로워만)
+	visibilityPublic = "public"
+	// 승인된 팔로워만
+	visibilityFollowers = "followers"
+	// 언급된 유저만
+	visibilityMentioned = "mentioned"
+)
+
+// chirp 게시 상태
+const (
+	// 임시저장 (작성자만 볼 수 있음)
+	chirpStatusDraft = "draft"
+	// 예약 게시 대기중 (작성자만 볼 수 있음)
+	chirpStatusScheduled = "scheduled"
+	// 게시됨
+	chirpStatusPublished = "published"
+)
+
 type cResBodySuccess struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Visibility string     `json:"visibility"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	// 게시된 시간 (임시저장, 예약 chirp는 비어 있다)
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	QuotedChirpID *uuid.UUID `json:"quoted_chirp_id"`
+	// 답글이면 답글을 단 chirp의 id
+	InReplyToChirpID *uuid.UUID `json:"in_reply_to_chirp_id"`
+	Pinned           bool       `json:"pinned"`
+	// 내용 경고 문구와 민감한 media 여부
+	ContentWarning *string `json:"content_warning"`
+	Sensitive      bool    `json:"sensitive"`
+	// 경고가 있어 접힌 상태로 보여줘야 하는지 (보는 유저의 설정에 따라 cfg.fillChirpStats가 정한다)
+	Collapsed     bool       `json:"collapsed"`
+	PinnedAt      *time.Time `json:"pinned_at,omitempty"`
+	RechirpCount  int64      `json:"rechirp_count"`
+	RechirpedByMe bool       `json:"rechirped_by_me"`
+	LikeCount     int64      `json:"like_count"`
+	LikedByMe     bool       `json:"liked_by_me"`
+	// 북마크는 본인만 볼 수 있으므로 로그인한 유저 자신의 북마크 여부만 표시
+	BookmarkedByMe bool `json:"bookmarked_by_me"`
+	// 타임라인에서 rechirp로 표시될 때만 채워지는 rechirp한 유저와 시간
+	RechirpedBy *uuid.UUID `json:"rechirped_by,omitempty"`
+	RechirpedAt *time.Time `json:"rechirped_at,omitempty"`
+	// 첨부된 media (cfg.fillChirpMedia로 채운다)
+	Media []mediaResBody `json:"media"`
+	// 본문 속 url과 미리보기 (cfg.fillChirpLinks로 채운다)
+	Links []linkResBody `json:"links"`
+	// 투표가 있는 chirp만 채워진다 (cfg.fillChirpPolls로 채운다)
+	Poll *pollResBody `json:"poll,omitempty"`
+	// 삭제된 chirp 정보 (작성자가 아닌 유저에게는 redactDeletedChirps가 내용을 지운다)
+	Deleted        bool       `json:"deleted"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	DeletionReason *string    `json:"deletion_reason,omitempty"`
+	// moderator가 지운 chirp에 내용 대신 표시하는 문구
+	Tombstone string `json:"tombstone,omitempty"`
+	// 작성자가 직접 지운 chirp를 복구할 수 있는 기한 (작성자에게만 표시)
+	RestorableUntil *time.Time `json:"restorable_until,omitempty"`
+	// 삭제 사유와 삭제한 유저는 보는 유저에 따라 공개 여부가 달라 json에서 제외하고 따로 보관
+	deletionReason     *string
+	removedByModerator bool
+}
+
+// db의 chirp를 response용 구조체로 변환하는 함수
+// rechirp 수 등 보는 유저별 값들과 첨부 media, 투표, 링크는 cfg.fillChirpStats로 따로 채운다
+func newCResBodySuccess(chirp database.Chirp) cResBodySuccess {
+	resBody := cResBodySuccess{
+		ID:         chirp.ID,
+		CreatedAt:  chirp.CreatedAt,
+		UpdatedAt:  chirp.UpdatedAt,
+		Body:       chirp.Body,
+		UserID:     chirp.UserID,
+		Visibility: chirp.Visibility,
+		Status:     chirp.Status,
+		Media:      []mediaResBody{},
+		Links:      []linkResBody{},
+	}
+	if chirp.Status == chirpStatusScheduled && chirp.PublishAt.Valid {
+		resBody.PublishAt = &chirp.PublishAt.Time
+	}
+	if chirp.PublishedAt.Valid {
+		resBody.PublishedAt = &chirp.PublishedAt.Time
+	}
+	if chirp.QuotedChirpID.Valid {
+		resBody.QuotedChirpID = &chirp.QuotedChirpID.UUID
+	}
+	if chirp.InReplyToChirpID.Valid {
+		resBody.InReplyToChirpID = &chirp.InReplyToChirpID.UUID
+	}
+	if chirp.PinnedAt.Valid {
+		resBody.Pinned = true
+		resBody.PinnedAt = &chirp.PinnedAt.Time
+	}
+	if chirp.ContentWarning.Valid {
+		resBody.ContentWarning = &chirp.ContentWarning.String
+	}
+	resBody.Sensitive = chirp.Sensitive
+	resBody.Collapsed = chirp.ContentWarning.Valid || chirp.Sensitive
+	if chirp.DeletedAt.Valid {
+		resBody.Deleted = true
+		resBody.DeletedAt = &chirp.DeletedAt.Time
+		resBody.removedByModerator = chirp.DeletedBy.UUID != chirp.UserID
+		if chirp.DeletionReason.Valid {
+			resBody.deletionReason = &chirp.DeletionReason.String
+		}
+	}
+	return resBody
+}
+
+// 타임라인 정렬 기준 시간 : rechirp로 표시되면 rechirp 시간, 아니면 게시 시간
+func (c cResBodySuccess) activityAt() time.Time {
+	if c.RechirpedAt != nil {
+		return *c.RechirpedAt
+	}
+	if c.PublishedAt != nil {
+		return *c.PublishedAt
+	}
+	return c.CreatedAt
+}
+
+// chirp가 너무 길 때의 400 response body
+type chirpTooLongResBody struct {
+	Error     string `json:"error"`
+	Length    int    `json:"length"`
+	Limit     int    `json:"limit"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type uReqBody struct {
@@ -67,5 +213,23 @@ type uResBodySuccess struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	IsProtected  bool      `json:"is_protected"`
+	IsModerator  bool      `json:"is_moderator"`
+	// 경고가 붙은 chirp를 바로 펼쳐서 볼지 여부
+	AutoExpandSensitive bool `json:"auto_expand_sensitive"`
 	// @@@ hashed password는 절대 response로 반환하면 안된다 => 보안문제
 }
+
+// db의 user를 response용 구조체로 변환하는 함수 (토큰 필드는 로그인 handler에서만 채운다)
+func newUResBodySuccess(user database.User) uResBodySuccess {
+	return uResBodySuccess{
+		ID:                  user.ID,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+		Email:               user.Email,
+		IsChirpyRed:         user.IsChirpyRed,
+		IsProtected:         user.IsProtected,
+		IsModerator:         user.IsModerator,
+		AutoExpandSensitive: user.AutoExpandSensitive,
+	}
+}
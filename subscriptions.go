@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// polka webhook의 구독 event
+const (
+	polkaEventUpgraded      = "user.upgraded"
+	polkaEventRenewed       = "user.renewed"
+	polkaEventPaymentFailed = "user.payment_failed"
+	polkaEventDowngraded    = "user.downgraded"
+	polkaEventRefunded      = "user.refunded"
+)
+
+const (
+	// polka가 plan을 보내지 않을 때의 구독 plan
+	defaultSubscriptionPlan = "chirpy_red"
+	// 갱신 결제가 실패한 뒤 혜택을 유지하며 다시 결제되기를 기다리는 기간
+	subscriptionPaymentGrace = 7 * 24 * time.Hour
+	// 기간이 끝난 뒤 갱신 event가 늦게 오는 경우를 기다리는 시간
+	subscriptionRenewalGrace = 24 * time.Hour
+	// 끝난 구독을 확인하는 주기
+	subscriptionExpiryInterval = 5 * time.Minute
+	// 한번의 트랜잭션으로 닫는 최대 구독 수
+	subscriptionExpiryBatchSize = 100
+)
+
+type subscriptionResBody struct {
+	Plan               string    `json:"plan"`
+	Status             string    `json:"status"`
+	CurrentPeriodStart time.Time `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time `json:"current_period_end"`
+	// 해지했지만 이번 기간이 끝날 때까지는 혜택 유지
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`
+	CanceledAt        *time.Time `json:"canceled_at"`
+	// 결제 실패 후 혜택이 유지되는 시간
+	GraceUntil *time.Time `json:"grace_until"`
+	EndedAt    *time.Time `json:"ended_at"`
+}
+
+func newSubscriptionResBody(s database.Subscription) subscriptionResBody {
+	resBody := subscriptionResBody{
+		Plan:               s.Plan,
+		Status:             s.Status,
+		CurrentPeriodStart: s.CurrentPeriodStart,
+		CurrentPeriodEnd:   s.CurrentPeriodEnd,
+		CancelAtPeriodEnd:  s.CancelAtPeriodEnd,
+	}
+	if s.CanceledAt.Valid {
+		resBody.CanceledAt = &s.CanceledAt.Time
+	}
+	if s.GraceUntil.Valid {
+		resBody.GraceUntil = &s.GraceUntil.Time
+	}
+	if s.EndedAt.Valid {
+		resBody.EndedAt = &s.EndedAt.Time
+	}
+	return resBody
+}
+
+// 구독 상태를 바꾸는 polka event인지 확인
+func isSubscriptionEvent(event string) bool {
+	switch event {
+	case polkaEventUpgraded, polkaEventRenewed, polkaEventPaymentFailed, polkaEventDowngraded, polkaEventRefunded:
+		return true
+	}
+	return false
+}
+
+// polka 구독 event를 구독에 반영하는 함수 (webhook 트랜잭션의 q로 호출)
+// 지금 구독 상태에 해당하지 않는 event(ex: 해지된 구독의 결제 실패, 구독이 없는 유저의 갱신)면 sql.ErrNoRows
+// periodEnd가 nil이면 한 달 기간
+func applySubscriptionEvent(ctx context.Context, q *database.Queries, event string, userID uuid.UUID, plan string, periodEnd *time.Time) error {
+	end := sql.NullTime{}
+	if periodEnd != nil {
+		end = sql.NullTime{Time: *periodEnd, Valid: true}
+	}
+	if plan == "" {
+		plan = defaultSubscriptionPlan
+	}
+
+	var err error
+	switch event {
+	case polkaEventUpgraded:
+		_, err = q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+			UserID:           userID,
+			Plan:             plan,
+			CurrentPeriodEnd: end,
+		})
+	case polkaEventRenewed:
+		_, err = q.RenewSubscription(ctx, database.RenewSubscriptionParams{
+			CurrentPeriodEnd: end,
+			UserID:           userID,
+		})
+	case polkaEventPaymentFailed:
+		_, err = q.MarkSubscriptionPastDue(ctx, database.MarkSubscriptionPastDueParams{
+			GraceUntil: time.Now().Add(subscriptionPaymentGrace),
+			UserID:     userID,
+		})
+	case polkaEventDowngraded:
+		_, err = q.CancelSubscription(ctx, userID)
+	case polkaEventRefunded:
+		_, err = q.RefundSubscription(ctx, userID)
+	default:
+		err = fmt.Errorf("unknown subscription event %q", event)
+	}
+	return err
+}
+
+// 기간이 끝난 구독들을 주기적으로 닫고 유저의 chirpy red 여부를 다시 계산하는 background job
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runSubscriptionExpiry(ctx context.Context) {
+	ticker := time.NewTicker(subscriptionExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.expireSubscriptions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 닫을 구독이 남아있지 않을 때까지 batch 단위로 닫는 함수
+func (cfg *apiConfig) expireSubscriptions(ctx context.Context) {
+	for {
+		expired, err := cfg.expireSubscriptionBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error expiring subscriptions", "error", err)
+			}
+			return
+		}
+
+		if expired > 0 {
+			slog.Info("Expired subscriptions", "count", expired)
+		}
+
+		if expired < subscriptionExpiryBatchSize {
+			return
+		}
+	}
+}
+
+// 구독 종료와 유저의 chirpy red 여부 변경을 한 트랜잭션으로 처리하는 함수
+// 다른 서버가 처리 중인 구독은 건너뛰므로(SKIP LOCKED) 여러 서버에서 동시에 돌려도 된다
+func (cfg *apiConfig) expireSubscriptionBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	userIDs, err := qtx.ExpireSubscriptions(ctx, database.ExpireSubscriptionsParams{
+		PeriodEndedBefore: time.Now().Add(-subscriptionRenewalGrace),
+		Limit:             subscriptionExpiryBatchSize,
+	})
+	if err != nil || len(userIDs) == 0 {
+		return 0, err
+	}
+
+	if _, err := qtx.SyncUserMemberships(ctx, userIDs); err != nil {
+		return 0, err
+	}
+
+	return len(userIDs), tx.Commit()
+}
+
+// /api/users/me/subscription path GET handler : 내 chirpy red 구독 상태
+func (cfg *apiConfig) handlerSubscriptionGET(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := cfg.ptrDB.GetSubscriptionByUserID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find subscription", err)
+			// code 404
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting subscription in DB", fmt.Errorf("error getting subscription in DB: %w", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newSubscriptionResBody(subscription))
+}
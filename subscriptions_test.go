@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+// 실행된 query의 sqlc 이름과 인자를 기록하는 테스트용 database.DBTX
+// 실제 실행은 닫힌 sql.DB에 넘겨 항상 "sql: database is closed" 에러가 난다
+type recordingDBTX struct {
+	closed *sql.DB
+	name   string
+	args   []interface{}
+}
+
+func (r *recordingDBTX) record(query string, args []interface{}) {
+	// sqlc가 생성한 query는 "-- name: <이름> :<종류>"로 시작한다
+	r.name = strings.Fields(query)[2]
+	r.args = args
+}
+
+func (r *recordingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	r.record(query, args)
+	return r.closed.ExecContext(ctx, query, args...)
+}
+
+func (r *recordingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	r.record(query, nil)
+	return r.closed.PrepareContext(ctx, query)
+}
+
+func (r *recordingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	r.record(query, args)
+	return r.closed.QueryContext(ctx, query, args...)
+}
+
+func (r *recordingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	r.record(query, args)
+	return r.closed.QueryRowContext(ctx, query, args...)
+}
+
+func TestApplySubscriptionEvent(t *testing.T) {
+	// sql.Open은 연결하지 않으므로 postgres 없이도 닫힌 DB를 만들 수 있다
+	closed, err := sql.Open("postgres", "")
+	if err != nil {
+		t.Fatalf("sql.Open error: %v", err)
+	}
+	closed.Close()
+
+	userID := uuid.New()
+	periodEnd := testTime(0).AddDate(0, 1, 0)
+	end := sql.NullTime{Time: periodEnd, Valid: true}
+
+	cases := []struct {
+		name      string
+		event     string
+		plan      string
+		periodEnd *time.Time
+		// 실행되어야 하는 query (비어 있으면 query 없이 에러)
+		expectedQuery string
+		expectedArgs  []interface{}
+	}{
+		{
+			name:          "upgraded",
+			event:         polkaEventUpgraded,
+			plan:          "yearly",
+			periodEnd:     &periodEnd,
+			expectedQuery: "UpsertSubscription",
+			expectedArgs:  []interface{}{userID, "yearly", end},
+		},
+		{
+			name:          "upgraded without plan or period end",
+			event:         polkaEventUpgraded,
+			expectedQuery: "UpsertSubscription",
+			expectedArgs:  []interface{}{userID, defaultSubscriptionPlan, sql.NullTime{}},
+		},
+		{
+			name:          "renewed",
+			event:         polkaEventRenewed,
+			periodEnd:     &periodEnd,
+			expectedQuery: "RenewSubscription",
+			expectedArgs:  []interface{}{end, userID},
+		},
+		{
+			// 유예 기간 끝은 time.Now() 기준이라 아래에서 따로 확인
+			name:          "payment failed",
+			event:         polkaEventPaymentFailed,
+			expectedQuery: "MarkSubscriptionPastDue",
+			expectedArgs:  []interface{}{time.Time{}, userID},
+		},
+		{
+			name:          "downgraded",
+			event:         polkaEventDowngraded,
+			expectedQuery: "CancelSubscription",
+			expectedArgs:  []interface{}{userID},
+		},
+		{
+			name:          "refunded",
+			event:         polkaEventRefunded,
+			expectedQuery: "RefundSubscription",
+			expectedArgs:  []interface{}{userID},
+		},
+		{
+			name:  "unknown event",
+			event: "user.unknown",
+		},
+	}
+
+	for _, tc := range cases {
+		db := &recordingDBTX{closed: closed}
+		before := time.Now()
+		err := applySubscriptionEvent(context.Background(), database.New(db), tc.event, userID, tc.plan, tc.periodEnd)
+		if err == nil {
+			t.Errorf("%s: applySubscriptionEvent returned nil, expecting error", tc.name)
+		}
+
+		if db.name != tc.expectedQuery {
+			t.Errorf("%s: applySubscriptionEvent ran %q, expecting %q", tc.name, db.name, tc.expectedQuery)
+			continue
+		}
+
+		args := db.args
+		if tc.event == polkaEventPaymentFailed && len(args) > 0 {
+			graceUntil, ok := args[0].(time.Time)
+			if !ok || graceUntil.Before(before.Add(subscriptionPaymentGrace)) || graceUntil.After(time.Now().Add(subscriptionPaymentGrace)) {
+				t.Errorf("%s: grace_until = %v, expecting %v after now", tc.name, args[0], subscriptionPaymentGrace)
+			}
+			args[0] = time.Time{}
+		}
+		if !reflect.DeepEqual(args, tc.expectedArgs) {
+			t.Errorf("%s: applySubscriptionEvent args = %v, expecting %v", tc.name, args, tc.expectedArgs)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/paokimsiwoong/chirpy/internal/database"
+)
+
+const (
+	// rollup을 갱신하고 trend 점수를 다시 계산하는 주기
+	trendAggregatorInterval = time.Minute
+	// rollup 한 구간의 길이 (sql/queries/trends.sql의 date_bin 간격과 같아야 한다)
+	trendBucketSize = 5 * time.Minute
+	// 구간별로 저장하는 최대 hashtag, chirp 수
+	trendLimit = 50
+)
+
+// trend를 계산하는 구간
+// halfLife마다 활동량 가중치가 절반이 되어 구간 안에서도 최근 활동(속도)을 더 크게 반영한다
+type trendWindow struct {
+	name     string
+	length   time.Duration
+	halfLife time.Duration
+}
+
+var trendWindows = []trendWindow{
+	{name: "1h", length: time.Hour, halfLife: 15 * time.Minute},
+	{name: "24h", length: 24 * time.Hour, halfLife: 6 * time.Hour},
+}
+
+// 새 활동을 rollup 테이블에 모으고 구간별 trend 점수를 다시 계산하는 background worker
+// 요청마다 chirps를 훑지 않도록 GET /api/trends는 여기서 저장한 점수만 읽는다
+// ctx가 취소되면 종료
+func (cfg *apiConfig) runTrendAggregator(ctx context.Context) {
+	ticker := time.NewTicker(trendAggregatorInterval)
+	defer ticker.Stop()
+
+	// 서버 시작 후 처음에는 가장 긴 구간 전체를 다시 집계
+	since := time.Now().Add(-trendWindows[len(trendWindows)-1].length)
+	for {
+		now := time.Now()
+		if err := cfg.aggregateTrends(ctx, since); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Error aggregating trends", "error", err)
+			}
+		} else {
+			since = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// since 이후의 rollup 구간들을 다시 계산하고 구간별 점수를 교체하는 함수
+// 한 트랜잭션으로 처리하므로 GET /api/trends는 계산 도중의 빈 목록을 보지 않는다
+func (cfg *apiConfig) aggregateTrends(ctx context.Context, since time.Time) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.txQueries(tx)
+
+	// 다른 서버가 집계중이면 이번에는 건너뛴다
+	locked, err := qtx.TryLockTrendAggregator(ctx)
+	if err != nil || !locked {
+		return err
+	}
+
+	// 직전 실행 때 진행 중이던 구간과 그 직전 구간(늦게 커밋된 활동)부터 다시 집계
+	bucketSince := since.Add(-trendBucketSize).Truncate(trendBucketSize)
+	if err := qtx.DeleteTrendHashtagBucketsSince(ctx, bucketSince); err != nil {
+		return err
+	}
+	if err := qtx.DeleteTrendChirpBucketsSince(ctx, bucketSince); err != nil {
+		return err
+	}
+	if err := qtx.RollupTrendHashtagBuckets(ctx, bucketSince); err != nil {
+		return err
+	}
+	if err := qtx.RollupTrendChirpBuckets(ctx, bucketSince); err != nil {
+		return err
+	}
+
+	// 가장 긴 구간보다 오래된 rollup은 더 이상 쓰지 않는다
+	now := time.Now()
+	oldest := now.Add(-trendWindows[len(trendWindows)-1].length).Truncate(trendBucketSize)
+	if err := qtx.PruneTrendHashtagBuckets(ctx, oldest); err != nil {
+		return err
+	}
+	if err := qtx.PruneTrendChirpBuckets(ctx, oldest); err != nil {
+		return err
+	}
+
+	for _, window := range trendWindows {
+		windowStart := now.Add(-window.length).Truncate(trendBucketSize)
+
+		if err := qtx.DeleteTrendingHashtags(ctx, window.name); err != nil {
+			return err
+		}
+		if err := qtx.ComputeTrendingHashtags(ctx, database.ComputeTrendingHashtagsParams{
+			TimeWindow:      window.name,
+			HalfLifeSeconds: window.halfLife.Seconds(),
+			WindowStart:     windowStart,
+			Limit:           trendLimit,
+		}); err != nil {
+			return err
+		}
+
+		if err := qtx.DeleteTrendingChirps(ctx, window.name); err != nil {
+			return err
+		}
+		if err := qtx.ComputeTrendingChirps(ctx, database.ComputeTrendingChirpsParams{
+			TimeWindow:      window.name,
+			HalfLifeSeconds: window.halfLife.Seconds(),
+			WindowStart:     windowStart,
+			Limit:           trendLimit,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/paokimsiwoong/chirpy/internal/auth"
+	"github.com/paokimsiwoong/chirpy/internal/database"
+	"github.com/paokimsiwoong/chirpy/internal/reqlog"
+	"github.com/paokimsiwoong/chirpy/internal/tracing"
 )
 
 // 검열할 단어 리스트와 텍스트를 받아서 검열하는 함수
@@ -35,6 +44,18 @@ func censor(text string) string {
 	return strings.Trim(strings.Join(toJoin, " "), " ")
 }
 
+// s를 n byte 이하로 자르는 함수 (utf-8 글자 중간에서 자르지 않는다)
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
 // @@@ 해답 예시
 // func getCleanedBody(body string, badWords map[string]struct{}) string {
 // @@@@ empty struct는 메모리에서 0 byte 차지 ==> go의 모든 타입 중 제일 작다
@@ -51,19 +72,23 @@ func censor(text string) string {
 // }
 
 // @@@ 해답의 DRY 코드1 :
-// respondWithError는 입력된 error를 log.Println으로 출력하고 입력된 msg를 json에 담아 response하는 함수
+// respondWithError는 입력된 error를 요청 logger로 기록하고 입력된 msg(와 요청 id)를 json에 담아 response하는 함수
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	if err != nil {
-		log.Println(err)
-	}
+	// 요청 id와 유저 id가 붙은 요청 logger로 기록
+	logger := reqlog.LoggerFromResponse(w)
 	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
+		logger.Error("Responding with 5XX error", "status", code, "response", msg, "error", err)
+	} else if err != nil {
+		logger.Info("Responding with error", "status", code, "response", msg, "error", err)
 	}
 	type errorResponse struct {
 		Error string `json:"error"`
+		// 로그에서 이 요청을 찾을 수 있도록 요청 id를 함께 보낸다 (X-Request-ID header와 같은 값)
+		RequestID string `json:"request_id,omitempty"`
 	}
 	respondWithJSON(w, code, errorResponse{
-		Error: msg,
+		Error:     msg,
+		RequestID: w.Header().Get(reqlog.Header),
 	})
 }
 
@@ -72,10 +97,74 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		reqlog.LoggerFromResponse(w).Error("Error marshalling JSON", "error", err)
 		w.WriteHeader(500)
 		return
 	}
 	w.WriteHeader(code)
 	w.Write(dat)
 }
+
+// Authorization header의 JWT를 검증해서 요청한 유저의 id를 반환하는 함수
+// 실패하면 401 response까지 보내므로 ok가 false이면 handler는 바로 return하면 된다
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	// JWT string이 Authorization header에 저장되어 있는지 확인
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error parsing header", fmt.Errorf("error parsing header: %w", err))
+		// code 401
+		return uuid.Nil, false
+	}
+
+	// JWT 검증
+	userID, err := auth.ValidateJWT(tokenString, cfg.tokenSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error invalid token", fmt.Errorf("error invalid token: %w", err))
+		// code 401
+		return uuid.Nil, false
+	}
+	// 요청 로그에 로그인한 유저 기록
+	reqlog.SetUserID(r.Context(), userID)
+
+	return userID, true
+}
+
+// 관리자 api(/admin/webhooks, /admin/jobs) 인증 : ApiKey header가 ADMIN_API_KEY와 같은지 확인하는 함수
+// ADMIN_API_KEY가 설정되지 않았으면 항상 실패
+// 실패하면 401 response까지 보내므로 false이면 handler는 바로 return하면 된다
+func (cfg *apiConfig) authenticateAdmin(w http.ResponseWriter, r *http.Request) bool {
+	apiKey, err := auth.GetAPIKey(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error parsing header", fmt.Errorf("error parsing header: %w", err))
+		// code 401
+		return false
+	}
+	if !auth.MatchAPIKey(apiKey, []string{cfg.adminKey}) {
+		respondWithError(w, http.StatusUnauthorized, "Error invalid api key", errors.New("error invalid api key"))
+		// code 401
+		return false
+	}
+	return true
+}
+
+// 로그인이 선택인 GET handler들에서 보는 유저(viewer)의 id를 반환하는 함수
+// Authorization header가 없으면 비로그인 유저로 보고 Valid가 false인 uuid.NullUUID 반환
+// header가 있는데 토큰이 잘못된 경우에는 authenticate처럼 401 response 후 ok false 반환
+func (cfg *apiConfig) viewerID(w http.ResponseWriter, r *http.Request) (uuid.NullUUID, bool) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, true
+	}
+
+	userID, ok := cfg.authenticate(w, r)
+	if !ok {
+		return uuid.NullUUID{}, false
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, true
+}
+
+// 트랜잭션 안에서 쿼리를 실행하는 *database.Queries를 반환하는 함수
+// cfg.ptrDB.WithTx(tx)는 tx를 그대로 쓰므로 쿼리 시간과 span이 기록되지 않는다 ==> cfg.ptrDB와 같은 wrapper로 감싼다
+func (cfg *apiConfig) txQueries(tx *sql.Tx) *database.Queries {
+	return database.New(tracing.WrapDB(cfg.metrics.WrapDB(tx)))
+}
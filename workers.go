@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// 함께 시작하고 함께 멈추는 background worker 묶음
+// 서버 종료 때 묶음 단위로 순서대로 멈춘다
+type workerGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWorkerGroup() *workerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerGroup{ctx: ctx, cancel: cancel}
+}
+
+// worker를 goroutine으로 실행하는 함수 (run은 ctx가 취소되면 끝나야 한다)
+func (g *workerGroup) start(run func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		run(g.ctx)
+	}()
+}
+
+// 묶음의 worker들에게 멈추라고 알리고 모두 끝날 때까지 기다리는 함수
+// ctx가 먼저 끝나면 기다리지 않고 ctx.Err()를 반환
+func (g *workerGroup) stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}